@@ -0,0 +1,303 @@
+package banglisp
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// Integers are treated as infinite two's-complement bit strings. big.Int
+// implements this semantics for negative values in And, Or, Xor, Not and Rsh.
+
+func integerArgument(function string, obj *Object) (*big.Int, error) {
+	v, ok := bigIntValue(obj)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: function, argument: obj}
+	}
+
+	return v, nil
+}
+
+// indexArgument returns non-negative fixnum used as bit position or size
+func indexArgument(function string, obj *Object) (uint, error) {
+	v, ok := obj.value.(int64)
+	if !ok || v < 0 {
+		return 0, &ErrUnsupportedArgumentType{function: function, argument: obj}
+	}
+
+	return uint(v), nil
+}
+
+func logicalFold(function string, identity int64, op func(z, x, y *big.Int) *big.Int) builtinFunctionType {
+	return func(_ *Environment, args []*Object) (*Object, error) {
+		ret := big.NewInt(identity)
+		for _, arg := range args {
+			v, err := integerArgument(function, arg)
+			if err != nil {
+				return nil, err
+			}
+			op(ret, ret, v)
+		}
+
+		return newInteger(ret), nil
+	}
+}
+
+func eqv(z, x, y *big.Int) *big.Int {
+	z.Xor(x, y)
+	return z.Not(z)
+}
+
+func builtinLognot(_ *Environment, args []*Object) (*Object, error) {
+	// (lognot integer)
+	v, err := integerArgument("lognot", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return newInteger(new(big.Int).Not(v)), nil
+}
+
+func builtinLogtest(_ *Environment, args []*Object) (*Object, error) {
+	// (logtest integer1 integer2)
+	x, err := integerArgument("logtest", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := integerArgument("logtest", args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if new(big.Int).And(x, y).Sign() != 0 {
+		return tObj, nil
+	}
+
+	return nilObj, nil
+}
+
+func builtinLogbitp(_ *Environment, args []*Object) (*Object, error) {
+	// (logbitp index integer)
+	index, err := indexArgument("logbitp", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := integerArgument("logbitp", args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if v.Bit(int(index)) == 1 {
+		return tObj, nil
+	}
+
+	return nilObj, nil
+}
+
+func builtinLogcount(_ *Environment, args []*Object) (*Object, error) {
+	// (logcount integer)
+	v, err := integerArgument("logcount", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	// negative integers count zero bits
+	if v.Sign() < 0 {
+		v = new(big.Int).Not(v)
+	}
+
+	count := 0
+	for _, w := range v.Bits() {
+		count += bits.OnesCount(uint(w))
+	}
+
+	return newFixnum(int64(count)), nil
+}
+
+func builtinAsh(_ *Environment, args []*Object) (*Object, error) {
+	// (ash integer count)
+	v, err := integerArgument("ash", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	count, ok := args[1].value.(int64)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "ash", argument: args[1]}
+	}
+
+	if count >= 0 {
+		return newInteger(new(big.Int).Lsh(v, uint(count))), nil
+	}
+
+	return newInteger(new(big.Int).Rsh(v, uint(-count))), nil
+}
+
+func integerLength(v *big.Int) int {
+	if v.Sign() < 0 {
+		return new(big.Int).Not(v).BitLen()
+	}
+
+	return v.BitLen()
+}
+
+func builtinIntegerLength(_ *Environment, args []*Object) (*Object, error) {
+	// (integer-length integer)
+	v, err := integerArgument("integer-length", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return newFixnum(int64(integerLength(v))), nil
+}
+
+// A byte specifier is represented as cons of size and position
+
+func builtinByte(_ *Environment, args []*Object) (*Object, error) {
+	// (byte size position)
+	for _, arg := range args {
+		if _, err := indexArgument("byte", arg); err != nil {
+			return nil, err
+		}
+	}
+
+	return cons(args[0], args[1]), nil
+}
+
+func byteSpec(function string, obj *Object) (uint, uint, error) {
+	if obj.kind != ConsCellType || isEmptyList(obj) {
+		return 0, 0, &ErrUnsupportedArgumentType{function: function, argument: obj}
+	}
+
+	cell := obj.value.(*ConsCell)
+	size, err := indexArgument(function, cell.car)
+	if err != nil {
+		return 0, 0, &ErrUnsupportedArgumentType{function: function, argument: obj}
+	}
+
+	pos, err := indexArgument(function, cell.cdr)
+	if err != nil {
+		return 0, 0, &ErrUnsupportedArgumentType{function: function, argument: obj}
+	}
+
+	return size, pos, nil
+}
+
+func builtinByteSize(_ *Environment, args []*Object) (*Object, error) {
+	// (byte-size bytespec)
+	size, _, err := byteSpec("byte-size", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return newFixnum(int64(size)), nil
+}
+
+func builtinBytePosition(_ *Environment, args []*Object) (*Object, error) {
+	// (byte-position bytespec)
+	_, pos, err := byteSpec("byte-position", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return newFixnum(int64(pos)), nil
+}
+
+// byteMask returns the mask selecting the bits of bytespec
+func byteMask(size uint, pos uint) *big.Int {
+	mask := new(big.Int).Lsh(big.NewInt(1), size)
+	mask.Sub(mask, big.NewInt(1))
+	return mask.Lsh(mask, pos)
+}
+
+func builtinLdb(_ *Environment, args []*Object) (*Object, error) {
+	// (ldb bytespec integer)
+	size, pos, err := byteSpec("ldb", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := integerArgument("ldb", args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	ret := new(big.Int).And(v, byteMask(size, pos))
+	return newInteger(ret.Rsh(ret, pos)), nil
+}
+
+func builtinMaskField(_ *Environment, args []*Object) (*Object, error) {
+	// (mask-field bytespec integer)
+	size, pos, err := byteSpec("mask-field", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := integerArgument("mask-field", args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return newInteger(new(big.Int).And(v, byteMask(size, pos))), nil
+}
+
+// depositBits replaces the bits of integer selected by bytespec with the
+// ones of newbyte. newbyte is shifted into place when shift is true.
+func depositBits(function string, args []*Object, shift bool) (*Object, error) {
+	newByte, err := integerArgument(function, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	size, pos, err := byteSpec(function, args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := integerArgument(function, args[2])
+	if err != nil {
+		return nil, err
+	}
+
+	mask := byteMask(size, pos)
+	bits := new(big.Int).Set(newByte)
+	if shift {
+		bits.Lsh(bits, pos)
+	}
+	bits.And(bits, mask)
+
+	ret := new(big.Int).AndNot(v, mask)
+	return newInteger(ret.Or(ret, bits)), nil
+}
+
+func builtinDpb(_ *Environment, args []*Object) (*Object, error) {
+	// (dpb newbyte bytespec integer)
+	return depositBits("dpb", args, true)
+}
+
+func builtinDepositField(_ *Environment, args []*Object) (*Object, error) {
+	// (deposit-field newbyte bytespec integer)
+	return depositBits("deposit-field", args, false)
+}
+
+func initBitwiseFunctions() {
+	installBuiltinFunction("logand", logicalFold("logand", -1, (*big.Int).And), 0, true)
+	installBuiltinFunction("logior", logicalFold("logior", 0, (*big.Int).Or), 0, true)
+	installBuiltinFunction("logxor", logicalFold("logxor", 0, (*big.Int).Xor), 0, true)
+	installBuiltinFunction("logeqv", logicalFold("logeqv", -1, eqv), 0, true)
+	installBuiltinFunction("lognot", builtinLognot, 1, false)
+	installBuiltinFunction("logtest", builtinLogtest, 2, false)
+	installBuiltinFunction("logbitp", builtinLogbitp, 2, false)
+	installBuiltinFunction("logcount", builtinLogcount, 1, false)
+	installBuiltinFunction("ash", builtinAsh, 2, false)
+	installBuiltinFunction("integer-length", builtinIntegerLength, 1, false)
+
+	installBuiltinFunction("byte", builtinByte, 2, false)
+	installBuiltinFunction("byte-size", builtinByteSize, 1, false)
+	installBuiltinFunction("byte-position", builtinBytePosition, 1, false)
+	installBuiltinFunction("ldb", builtinLdb, 2, false)
+	installBuiltinFunction("dpb", builtinDpb, 3, false)
+	installBuiltinFunction("mask-field", builtinMaskField, 2, false)
+	installBuiltinFunction("deposit-field", builtinDepositField, 3, false)
+}
@@ -0,0 +1,104 @@
+package banglisp
+
+import (
+	"testing"
+)
+
+func TestBitwise(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "logand logior logxor",
+			expr: "(list (logand 12 10) (logior 12 10) (logxor 12 10) (logand) (logior))",
+			want: "(8 14 6 -1 0)",
+		},
+		{
+			name: "negative operands use two's complement",
+			expr: "(list (logand -1 255) (logand -256 1023) (logior -8 3) (logxor -1 5))",
+			want: "(255 768 -5 -6)",
+		},
+		{
+			name: "bignum operands",
+			expr: "(list (logand 340282366920938463463374607431768211455 -18446744073709551616) (lognot 18446744073709551615))",
+			want: "(340282366920938463444927863358058659840 -18446744073709551616)",
+		},
+		{
+			name: "logeqv and lognot",
+			expr: "(list (logeqv 12 10) (lognot 0) (lognot -1))",
+			want: "(-7 -1 0)",
+		},
+		{
+			name: "logtest and logbitp",
+			expr: "(list (logtest 4 3) (logtest 6 3) (logbitp 1 6) (logbitp 100 -1) (logbitp 0 -2))",
+			want: "(nil t t t nil)",
+		},
+		{
+			name: "logcount",
+			expr: "(list (logcount 13) (logcount -13) (logcount 18446744073709551615))",
+			want: "(3 2 64)",
+		},
+		{
+			name: "ash",
+			expr: "(list (ash 1 70) (ash 1180591620717411303424 -70) (ash -5 -1) (ash 5 -10))",
+			want: "(1180591620717411303424 1 -3 0)",
+		},
+		{
+			name: "integer-length",
+			expr: "(list (integer-length 0) (integer-length 255) (integer-length 256) (integer-length -1) (integer-length -256))",
+			want: "(0 8 9 0 8)",
+		},
+		{
+			name: "ldb",
+			expr: "(list (ldb (byte 4 4) 255) (ldb (byte 8 0) -1) (ldb (byte 4 64) 18446744073709551616))",
+			want: "(15 255 1)",
+		},
+		{
+			name: "dpb",
+			expr: "(list (dpb 1 (byte 4 4) 0) (dpb 0 (byte 8 0) -1) (dpb 31 (byte 2 0) 0))",
+			want: "(16 -256 3)",
+		},
+		{
+			name: "mask-field and deposit-field",
+			expr: "(list (mask-field (byte 4 4) 255) (deposit-field 255 (byte 4 4) 0))",
+			want: "(240 240)",
+		},
+		{
+			name: "byte accessors",
+			expr: "(list (byte-size (byte 3 5)) (byte-position (byte 3 5)))",
+			want: "(3 5)",
+		},
+		{
+			name:    "non integer argument",
+			expr:    "(logand 1.0 1)",
+			wantErr: true,
+		},
+		{
+			name:    "negative byte size",
+			expr:    "(byte -1 0)",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("could not evaluate %s: %v", tt.expr, err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("%s => got: %v, expected %s", tt.expr, *got, tt.want)
+				return
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package banglisp
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 )
 
 type builtinFunctionType func(env *Environment, args []*Object) (*Object, error)
@@ -46,6 +47,33 @@ func builtinEq(_ *Environment, args []*Object) (*Object, error) {
 	return nilObj, nil
 }
 
+func builtinEql(_ *Environment, args []*Object) (*Object, error) {
+	// (eql a b)
+	if isEql(args[0], args[1]) {
+		return tObj, nil
+	}
+
+	return nilObj, nil
+}
+
+func builtinEqual(_ *Environment, args []*Object) (*Object, error) {
+	// (equal a b)
+	if isEqual(args[0], args[1]) {
+		return tObj, nil
+	}
+
+	return nilObj, nil
+}
+
+func builtinEqualp(_ *Environment, args []*Object) (*Object, error) {
+	// (equalp a b)
+	if isEqualp(args[0], args[1]) {
+		return tObj, nil
+	}
+
+	return nilObj, nil
+}
+
 func builtinNull(_ *Environment, args []*Object) (*Object, error) {
 	// (null a)
 	if isNull(args[0]) {
@@ -67,7 +95,7 @@ func builtinAtom(_ *Environment, args []*Object) (*Object, error) {
 func builtinCar(_ *Environment, args []*Object) (*Object, error) {
 	c, ok := args[0].value.(*ConsCell)
 	if !ok {
-		return nil, &ErrUnsupportedArgumentType{"car", args[0]}
+		return nil, &ErrUnsupportedArgumentType{function: "car", argument: args[0]}
 	}
 	return c.car, nil
 }
@@ -75,7 +103,7 @@ func builtinCar(_ *Environment, args []*Object) (*Object, error) {
 func builtinCdr(_ *Environment, args []*Object) (*Object, error) {
 	c, ok := args[0].value.(*ConsCell)
 	if !ok {
-		return nil, &ErrUnsupportedArgumentType{"cdr", args[0]}
+		return nil, &ErrUnsupportedArgumentType{function: "cdr", argument: args[0]}
 	}
 	return c.cdr, nil
 }
@@ -88,72 +116,175 @@ func builtinCons(_ *Environment, args []*Object) (*Object, error) {
 	return cons(args[0], args[1]), nil
 }
 
-func builtinLength(_ *Environment, args []*Object) (*Object, error) {
-	switch args[0].kind {
-	case ConsCellType:
-		v := args[0].value.(*ConsCell)
-		var ret int64 = 1
-		next := v.cdr
-		for {
-			if next == emptyList {
-				break
+func builtinList(_ *Environment, args []*Object) (*Object, error) {
+	// (list obj...)
+	ret := emptyList
+	for i := len(args) - 1; i >= 0; i-- {
+		ret = cons(args[i], ret)
+	}
+
+	return ret, nil
+}
+
+// listLength counts the conses of list. The second return value is false if
+// list is circular, which is detected by a pointer moving twice as fast.
+func listLength(function string, list *Object) (int64, bool, error) {
+	var n int64
+	slow, fast := list, list
+	for {
+		for i := 0; i < 2; i++ {
+			if isEmptyList(fast) {
+				return n, true, nil
 			}
 
-			c, ok := next.value.(*ConsCell)
+			c, ok := fast.value.(*ConsCell)
 			if !ok {
-				return nil, &ErrUnsupportedArgumentType{"length", v.cdr}
+				return 0, false, &ErrUnsupportedArgumentType{function: function, argument: list}
 			}
 
-			next = c.cdr
-			ret++
+			fast = c.cdr
+			n++
+		}
+
+		slow = slow.value.(*ConsCell).cdr
+		if objectEqual(fast, slow) {
+			return n, false, nil
+		}
+	}
+}
+
+func builtinLength(_ *Environment, args []*Object) (*Object, error) {
+	// (length sequence)
+	switch args[0].kind {
+	case ConsCellType, SymbolType:
+		n, proper, err := listLength("length", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if !proper {
+			return nil, &ErrUnsupportedArgumentType{function: "length", argument: args[0]}
 		}
 
-		return newFixnum(ret), nil
+		return newFixnum(n), nil
 	case StringType:
 		v := args[0].value.(string)
-		return newFixnum(int64(len(v))), nil
+		return newFixnum(int64(utf8.RuneCountInString(v))), nil
 	default:
-		return nil, &ErrUnsupportedArgumentType{"length", args[0]}
+		return nil, &ErrUnsupportedArgumentType{function: "length", argument: args[0]}
 	}
+}
 
+func builtinListLength(_ *Environment, args []*Object) (*Object, error) {
+	// (list-length list)
+	n, proper, err := listLength("list-length", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if !proper {
+		return nilObj, nil
+	}
+
+	return newFixnum(n), nil
 }
 
-func builtinPrint(_ *Environment, args []*Object) (*Object, error) {
-	fmt.Printf("%v\n", args[0])
-	return nilObj, nil
+func builtinRplaca(_ *Environment, args []*Object) (*Object, error) {
+	// (rplaca cons object)
+	if !isCons(args[0]) {
+		return nil, &ErrUnsupportedArgumentType{function: "rplaca", argument: args[0]}
+	}
+
+	args[0].value.(*ConsCell).car = args[1]
+	return args[0], nil
 }
 
-func builtinFuncall(env *Environment, args []*Object) (*Object, error) {
-	switch fn := args[0].value.(type) {
+func builtinRplacd(_ *Environment, args []*Object) (*Object, error) {
+	// (rplacd cons object)
+	if !isCons(args[0]) {
+		return nil, &ErrUnsupportedArgumentType{function: "rplacd", argument: args[0]}
+	}
+
+	cdr := args[1]
+	if isNull(cdr) {
+		cdr = emptyList
+	}
+
+	args[0].value.(*ConsCell).cdr = cdr
+	return args[0], nil
+}
+
+func funcall(env *Environment, fnObj *Object, args []*Object) (*Object, error) {
+	switch fn := fnObj.value.(type) {
 	case *Symbol:
 		if isNull(fn.function) {
-			return nil, fmt.Errorf("void function: %v", *fn)
-		}
-		switch fn.function.kind {
-		case BuiltinFunctionType:
-			fn := fn.function.value.(*BuiltinFunction)
-			return fn.code(env, args[1:])
-		case ClosureType:
-			fn := fn.function.value.(*Closure)
-			return fn.apply(env, args[1:])
-		default:
-			return nil, &ErrUnsupportedArgumentType{"funcall", fn.function}
+			return nil, fmt.Errorf("void function: %v", *fn.name)
 		}
+
+		return funcall(env, fn.function, args)
 	case *BuiltinFunction:
-		return fn.code(env, args[1:])
+		if err := checkArity(fn.arity, fn.variadic, len(args)); err != nil {
+			return nil, err
+		}
+
+		return fn.code(env, args)
 	case *Closure:
-		return fn.apply(env, args[1:])
+		return fn.apply(env, args)
+	case *GenericFunction:
+		return fn.call(env, args)
 	default:
-		return nil, &ErrUnsupportedArgumentType{"funcall", args[0]}
+		return nil, &ErrUnsupportedArgumentType{function: "funcall", argument: fnObj}
 	}
 }
 
+func builtinFuncall(env *Environment, args []*Object) (*Object, error) {
+	// (funcall fn args...)
+	return funcall(env, args[0], args[1:])
+}
+
+func builtinValues(env *Environment, args []*Object) (*Object, error) {
+	// (values obj...)
+	return env.setValues(args), nil
+}
+
+func parseKeywordArguments(function string, args []*Object, keys ...string) (map[string]*Object, error) {
+	if len(args)%2 != 0 {
+		return nil, fmt.Errorf("%s: odd number of keyword arguments", function)
+	}
+
+	ret := make(map[string]*Object)
+	for i := 0; i < len(args); i += 2 {
+		name, ok := keywordName(args[i])
+		if !ok {
+			return nil, &ErrUnsupportedArgumentType{function: function, argument: args[i]}
+		}
+
+		found := false
+		for _, key := range keys {
+			if key == name {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("%s: unknown keyword argument %v", function, *args[i])
+		}
+
+		if _, ok := ret[name]; !ok {
+			ret[name] = args[i+1]
+		}
+	}
+
+	return ret, nil
+}
+
 func builtinStringConcat(_ *Environment, args []*Object) (*Object, error) {
 	var ss []string
 	for _, arg := range args {
 		v, ok := arg.value.(string)
 		if !ok {
-			return nil, &ErrUnsupportedArgumentType{"string-concat", arg}
+			return nil, &ErrUnsupportedArgumentType{function: "string-concat", argument: arg}
 		}
 
 		ss = append(ss, v)
@@ -166,10 +297,90 @@ func builtinStringConcat(_ *Environment, args []*Object) (*Object, error) {
 	return newString(strings.Join(ss, "")), nil
 }
 
+// sequenceIndex returns integer argument which must be in [0, limit]
+func sequenceIndex(function string, obj *Object, limit int) (int, error) {
+	v, ok := obj.value.(int64)
+	if !ok {
+		return 0, &ErrUnsupportedArgumentType{function: function, argument: obj}
+	}
+
+	if v < 0 || v > int64(limit) {
+		return 0, fmt.Errorf("%s: index %d is out of range", function, v)
+	}
+
+	return int(v), nil
+}
+
+func builtinChar(_ *Environment, args []*Object) (*Object, error) {
+	// (char string index)
+	str, ok := args[0].value.(string)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "char", argument: args[0]}
+	}
+
+	chars := []rune(str)
+	index, err := sequenceIndex("char", args[1], len(chars)-1)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCharacter(chars[index]), nil
+}
+
+func builtinSubseq(_ *Environment, args []*Object) (*Object, error) {
+	// (subseq sequence start &optional end)
+	if len(args) > 3 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 3, got: len(args)}
+	}
+
+	var elems []*Object
+	var chars []rune
+	length := 0
+	switch v := args[0].value.(type) {
+	case string:
+		chars = []rune(v)
+		length = len(chars)
+	case *ConsCell:
+		elems = noEvalArguments(args[0])
+		length = len(elems)
+	default:
+		if !isNull(args[0]) {
+			return nil, &ErrUnsupportedArgumentType{function: "subseq", argument: args[0]}
+		}
+	}
+
+	start, err := sequenceIndex("subseq", args[1], length)
+	if err != nil {
+		return nil, err
+	}
+
+	end := length
+	if len(args) == 3 && !isNull(args[2]) {
+		end, err = sequenceIndex("subseq", args[2], length)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if start > end {
+		return nil, fmt.Errorf("subseq: start %d is greater than end %d", start, end)
+	}
+
+	if args[0].kind == StringType {
+		return newString(string(chars[start:end])), nil
+	}
+
+	if start == end {
+		return nilObj, nil
+	}
+
+	return builtinList(nil, elems[start:end])
+}
+
 func builtinSymbolName(_ *Environment, args []*Object) (*Object, error) {
 	sym, ok := args[0].value.(*Symbol)
 	if !ok {
-		return nil, &ErrUnsupportedArgumentType{"symbol-name", args[0]}
+		return nil, &ErrUnsupportedArgumentType{function: "symbol-name", argument: args[0]}
 	}
 
 	return sym.name, nil
@@ -178,7 +389,7 @@ func builtinSymbolName(_ *Environment, args []*Object) (*Object, error) {
 func builtinSymbolValue(_ *Environment, args []*Object) (*Object, error) {
 	sym, ok := args[0].value.(*Symbol)
 	if !ok {
-		return nil, &ErrUnsupportedArgumentType{"symbol-value", args[0]}
+		return nil, &ErrUnsupportedArgumentType{function: "symbol-value", argument: args[0]}
 	}
 
 	return sym.value, nil
@@ -187,7 +398,7 @@ func builtinSymbolValue(_ *Environment, args []*Object) (*Object, error) {
 func builtinSymbolFunction(_ *Environment, args []*Object) (*Object, error) {
 	sym, ok := args[0].value.(*Symbol)
 	if !ok {
-		return nil, &ErrUnsupportedArgumentType{"symbol-function", args[0]}
+		return nil, &ErrUnsupportedArgumentType{function: "symbol-function", argument: args[0]}
 	}
 
 	return sym.function, nil
@@ -196,7 +407,7 @@ func builtinSymbolFunction(_ *Environment, args []*Object) (*Object, error) {
 func builtinSymbolPlist(_ *Environment, args []*Object) (*Object, error) {
 	sym, ok := args[0].value.(*Symbol)
 	if !ok {
-		return nil, &ErrUnsupportedArgumentType{"symbol-plist", args[0]}
+		return nil, &ErrUnsupportedArgumentType{function: "symbol-plist", argument: args[0]}
 	}
 
 	return sym.plist, nil
@@ -205,14 +416,224 @@ func builtinSymbolPlist(_ *Environment, args []*Object) (*Object, error) {
 func builtinSymbolPackage(_ *Environment, args []*Object) (*Object, error) {
 	sym, ok := args[0].value.(*Symbol)
 	if !ok {
-		return nil, &ErrUnsupportedArgumentType{"symbol-package", args[0]}
+		return nil, &ErrUnsupportedArgumentType{function: "symbol-package", argument: args[0]}
+	}
+
+	if sym.package_ == nil {
+		return nilObj, nil
 	}
 
 	return sym.package_, nil
 }
 
+func builtinKeywordp(_ *Environment, args []*Object) (*Object, error) {
+	// (keywordp obj)
+	if isKeyword(args[0]) {
+		return tObj, nil
+	}
+
+	return nilObj, nil
+}
+
+func typeOf(obj *Object) *Object {
+	switch obj.kind {
+	case FixnumType:
+		return newSymbol("fixnum")
+	case BignumType:
+		return newSymbol("bignum")
+	case RatioType:
+		return newSymbol("ratio")
+	case ComplexType:
+		return newSymbol("complex")
+	case FloatType:
+		return newSymbol("double-float")
+	case SingleFloatType:
+		return newSymbol("single-float")
+	case DecimalType:
+		return newSymbol("decimal")
+	case CharacterType:
+		return newSymbol("character")
+	case StringType:
+		return newSymbol("string")
+	case SymbolType:
+		if isNull(obj) {
+			return newSymbol("null")
+		} else if obj == tObj {
+			return newSymbol("boolean")
+		} else if isKeyword(obj) {
+			return newSymbol("keyword")
+		}
+		return newSymbol("symbol")
+	case PackageType:
+		return newSymbol("package")
+	case ConsCellType:
+		if obj == emptyList {
+			return newSymbol("null")
+		}
+		return newSymbol("cons")
+	case SpecialFormType:
+		return newSymbol("special-form")
+	case BuiltinFunctionType, ClosureType:
+		return newSymbol("function")
+	case GenericFunctionType:
+		return newSymbol("generic-function")
+	case HashTableType:
+		return newSymbol("hash-table")
+	case StructureType:
+		return obj.value.(*Structure).class.name
+	case StreamType:
+		return newSymbol("stream")
+	case RandomStateType:
+		return newSymbol("random-state")
+	case ReadtableType:
+		return newSymbol("readtable")
+	case PathnameType:
+		return newSymbol("pathname")
+	case ClassType:
+		return newSymbol("class")
+	case InstanceType:
+		return obj.value.(*Instance).class.name
+	default:
+		return tObj
+	}
+}
+
+// isOfType returns whether obj is of type typeSpec. The second return value
+// is false if typeSpec is not a known type specifier.
+func isOfType(obj *Object, typeSpec *Object) (bool, bool) {
+	if typeSpec.kind == ConsCellType && typeSpec != emptyList {
+		elems := noEvalArguments(typeSpec)
+		name, _ := symbolNameString(elems[0])
+		switch name {
+		case "or", "and":
+			for _, spec := range elems[1:] {
+				ok, known := isOfType(obj, spec)
+				if !known {
+					return false, false
+				}
+
+				if ok == (name == "or") {
+					return ok, true
+				}
+			}
+			return name == "and", true
+		case "not":
+			if len(elems) != 2 {
+				return false, false
+			}
+			ok, known := isOfType(obj, elems[1])
+			return !ok, known
+		case "member":
+			for _, elem := range elems[1:] {
+				if isEql(obj, elem) {
+					return true, true
+				}
+			}
+			return false, true
+		default:
+			return false, false
+		}
+	}
+
+	if isNull(typeSpec) {
+		return false, true
+	}
+
+	if typeSpec == tObj {
+		return true, true
+	}
+
+	if class, ok := structureClasses[typeSpec]; ok {
+		s, ok := obj.value.(*Structure)
+		return ok && s.class.isSubclassOf(class), true
+	}
+
+	name, ok := symbolNameString(typeSpec)
+	if !ok {
+		return false, false
+	}
+
+	switch name {
+	case "fixnum":
+		return obj.kind == FixnumType, true
+	case "bignum":
+		return obj.kind == BignumType, true
+	case "integer":
+		return isInteger(obj), true
+	case "ratio":
+		return obj.kind == RatioType, true
+	case "rational":
+		return isRational(obj), true
+	case "float":
+		return isFloat(obj), true
+	case "double-float", "long-float":
+		return obj.kind == FloatType, true
+	case "single-float", "short-float":
+		return obj.kind == SingleFloatType, true
+	case "number":
+		return isNumber(obj), true
+	case "real":
+		return isReal(obj), true
+	case "complex":
+		return obj.kind == ComplexType, true
+	case "decimal":
+		return obj.kind == DecimalType, true
+	case "character":
+		return obj.kind == CharacterType, true
+	case "string":
+		return obj.kind == StringType, true
+	case "symbol":
+		return obj.kind == SymbolType || obj == emptyList, true
+	case "keyword":
+		return isKeyword(obj), true
+	case "boolean":
+		return obj == tObj || isEmptyList(obj), true
+	case "null":
+		return isEmptyList(obj), true
+	case "cons":
+		return obj.kind == ConsCellType && obj != emptyList, true
+	case "list":
+		return obj.kind == ConsCellType || isNull(obj), true
+	case "atom":
+		return obj.kind != ConsCellType || obj == emptyList, true
+	case "function":
+		return obj.kind == BuiltinFunctionType || obj.kind == ClosureType || obj.kind == GenericFunctionType, true
+	case "hash-table":
+		return obj.kind == HashTableType, true
+	case "package":
+		return obj.kind == PackageType, true
+	default:
+		if c, ok := findClass(typeSpec); ok {
+			return classOf(obj).isSubclassOf(c), true
+		}
+		return false, false
+	}
+}
+
+func builtinTypeOf(_ *Environment, args []*Object) (*Object, error) {
+	// (type-of obj)
+	return typeOf(args[0]), nil
+}
+
+func builtinTypep(_ *Environment, args []*Object) (*Object, error) {
+	// (typep obj type)
+	ok, known := isOfType(args[0], args[1])
+	if !known {
+		return nil, fmt.Errorf("unknown type specifier: %v", *args[1])
+	}
+
+	if ok {
+		return tObj, nil
+	}
+
+	return nilObj, nil
+}
+
 func initBuiltinFunctions() {
 	installBuiltinFunction("eq", builtinEq, 2, false)
+	installBuiltinFunction("eql", builtinEql, 2, false)
+	installBuiltinFunction("equal", builtinEqual, 2, false)
+	installBuiltinFunction("equalp", builtinEqualp, 2, false)
 	installBuiltinFunction("not", builtinNot, 1, false)
 	installBuiltinFunction("null", builtinNull, 1, false)
 	installBuiltinFunction("atom", builtinAtom, 1, false)
@@ -223,14 +644,26 @@ func initBuiltinFunctions() {
 	installBuiltinFunction("cdr", builtinCdr, 1, false)
 	installBuiltinFunction("rest", builtinCdr, 1, false)
 	installBuiltinFunction("cons", builtinCons, 2, false)
+	installBuiltinFunction("list", builtinList, 0, true)
 	installBuiltinFunction("length", builtinLength, 1, false)
+	installBuiltinFunction("list-length", builtinListLength, 1, false)
+	installBuiltinFunction("rplaca", builtinRplaca, 2, false)
+	installBuiltinFunction("rplacd", builtinRplacd, 2, false)
 
 	// utility
-	installBuiltinFunction("print", builtinPrint, 1, false)
-	installBuiltinFunction("funcall", builtinFuncall, 0, true)
+	installBuiltinFunction("funcall", builtinFuncall, 1, true)
+	installBuiltinFunction("values", builtinValues, 0, true)
+
+	// type functions
+	installBuiltinFunction("type-of", builtinTypeOf, 1, false)
+	installBuiltinFunction("typep", builtinTypep, 2, false)
 
 	// string functions
 	installBuiltinFunction("string-concat", builtinStringConcat, 0, true)
+	installBuiltinFunction("char", builtinChar, 2, false)
+
+	// sequence functions
+	installBuiltinFunction("subseq", builtinSubseq, 2, true)
 
 	// symbol functions
 	installBuiltinFunction("symbol-name", builtinSymbolName, 1, false)
@@ -238,4 +671,5 @@ func initBuiltinFunctions() {
 	installBuiltinFunction("symbol-function", builtinSymbolFunction, 1, false)
 	installBuiltinFunction("symbol-plist", builtinSymbolPlist, 1, false)
 	installBuiltinFunction("symbol-package", builtinSymbolPackage, 1, false)
+	installBuiltinFunction("keywordp", builtinKeywordp, 1, false)
 }
@@ -1,6 +1,7 @@
 package banglisp
 
 import (
+	"io"
 	"math"
 	"strings"
 	"testing"
@@ -96,6 +97,17 @@ func TestBuiltinBuiltinPredicate(t *testing.T) {
 			expr: `(atom 'foo)`,
 			want: tObj,
 		},
+		// keywordp
+		{
+			name: "keywordp keyword",
+			expr: "(keywordp :foo)",
+			want: tObj,
+		},
+		{
+			name: "keywordp symbol",
+			expr: "(keywordp 'foo)",
+			want: nilObj,
+		},
 	}
 
 	for _, tt := range tests {
@@ -251,3 +263,829 @@ func TestBuiltinArithmeticOps(t *testing.T) {
 		})
 	}
 }
+
+func readEvalString(input string) (*Object, error) {
+	r := strings.NewReader(input)
+	br := newSourceReader(r, "")
+
+	ret := nilObj
+	for {
+		expr, err := read1(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		ret, err = Eval(expr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+func TestBuiltinIntegerArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want string
+		kind objectType
+	}{
+		{
+			name: "add overflows to bignum",
+			expr: "(+ 9223372036854775807 1)",
+			want: "9223372036854775808",
+			kind: BignumType,
+		},
+		{
+			name: "minus overflows to bignum",
+			expr: "(- -9223372036854775808 1)",
+			want: "-9223372036854775809",
+			kind: BignumType,
+		},
+		{
+			name: "negate most negative fixnum",
+			expr: "(- -9223372036854775808)",
+			want: "9223372036854775808",
+			kind: BignumType,
+		},
+		{
+			name: "mul overflows to bignum",
+			expr: "(* 4294967296 4294967296)",
+			want: "18446744073709551616",
+			kind: BignumType,
+		},
+		{
+			name: "bignum demoted to fixnum",
+			expr: "(- (+ 9223372036854775807 1) 1)",
+			want: "9223372036854775807",
+			kind: FixnumType,
+		},
+		{
+			name: "bignum division",
+			expr: "(/ 18446744073709551616 4294967296)",
+			want: "4294967296",
+			kind: FixnumType,
+		},
+		{
+			name: "factorial",
+			expr: `(defun bignum-factorial (n) (if (= n 0) 1 (* n (bignum-factorial (- n 1)))))
+(bignum-factorial 30)`,
+			want: "265252859812191058636308480000000",
+			kind: BignumType,
+		},
+		{
+			name: "bignum comparison",
+			expr: "(< 100000000000000000000 100000000000000000001)",
+			want: "t",
+			kind: SymbolType,
+		},
+		{
+			name: "bignum mod",
+			expr: "(mod 100000000000000000001 7)",
+			want: "3",
+			kind: FixnumType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if err != nil {
+				t.Errorf("could not evaluate %s: %v", tt.expr, err)
+				return
+			}
+
+			if got.kind != tt.kind {
+				t.Errorf("%s => got type: %v, expected %v", tt.expr, got.kind, tt.kind)
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("%s => got: %v, expected %s", tt.expr, *got, tt.want)
+				return
+			}
+		})
+	}
+}
+
+func TestBuiltinNumericLibrary(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "variadic comparisons",
+			expr: "(list (< 1 2 3) (< 1 3 2) (<= 1 1 2) (> 3 2 1) (>= 3 3 4) (= 2 2 2))",
+			want: "(t nil t t nil t)",
+		},
+		{
+			name: "less than or equal",
+			expr: "(list (<= 1 1) (<= 2 1) (<= 1/2 0.5))",
+			want: "(t nil t)",
+		},
+		{
+			name: "not equal compares all pairs",
+			expr: "(list (/= 1 2 3) (/= 1 2 1) (/= 1))",
+			want: "(t nil t)",
+		},
+		{
+			name: "min and max",
+			expr: "(list (min 3 1 2) (max 3 1/2 4.5))",
+			want: "(1 4.5E+00)",
+		},
+		{
+			name: "floor",
+			expr: "(multiple-value-list (floor -7 2))",
+			want: "(-4 1)",
+		},
+		{
+			name: "setq returns primary value",
+			expr: "(multiple-value-list (setq mv-yy (floor 7 2)))",
+			want: "(3)",
+		},
+		{
+			name: "setf returns primary value",
+			expr: "(multiple-value-list (setf mv-zz (floor 7 2)))",
+			want: "(3)",
+		},
+		{
+			name: "non-final or clause returns primary value",
+			expr: "(list (multiple-value-list (or (floor 7 2) 1)) (multiple-value-list (or nil (floor 7 2))))",
+			want: "((3) (3 1))",
+		},
+		{
+			name: "non-final and clause returns primary value",
+			expr: "(list (multiple-value-list (and (values nil 2) t)) (multiple-value-list (and t (floor 7 2))))",
+			want: "((nil) (3 1))",
+		},
+		{
+			name: "ceiling",
+			expr: "(multiple-value-list (ceiling 7 2))",
+			want: "(4 -1)",
+		},
+		{
+			name: "truncate",
+			expr: "(multiple-value-list (truncate -7 2))",
+			want: "(-3 -1)",
+		},
+		{
+			name: "round to even",
+			expr: "(list (round 5/2) (round 7/2) (round -2.5))",
+			want: "(2 4 -2)",
+		},
+		{
+			name: "floor of float",
+			expr: "(multiple-value-list (floor 2.5))",
+			want: "(2 5E-01)",
+		},
+		{
+			name: "floor of ratio",
+			expr: "(multiple-value-list (floor 7/2))",
+			want: "(3 1/2)",
+		},
+		{
+			name: "mod uses floor semantics",
+			expr: "(list (mod -7 2) (mod 7 -2) (rem -7 2) (rem 7 -2))",
+			want: "(1 -1 -1 1)",
+		},
+		{
+			name: "gcd and lcm",
+			expr: "(list (gcd) (gcd 12 -18) (lcm) (lcm 4 6) (lcm 4 0))",
+			want: "(0 6 1 12 0)",
+		},
+		{
+			name: "isqrt",
+			expr: "(list (isqrt 24) (isqrt 100000000000000000000))",
+			want: "(4 10000000000)",
+		},
+		{
+			name: "signum",
+			expr: "(list (signum -5) (signum 0) (signum 2/3) (signum -2.5))",
+			want: "(-1 0 1 -1E+00)",
+		},
+		{
+			name: "increment and decrement",
+			expr: "(list (1+ 1) (1- 1/2) (1+ 9223372036854775807))",
+			want: "(2 -1/2 9223372036854775808)",
+		},
+		{
+			name: "sign predicates",
+			expr: "(list (zerop 0.0) (plusp 1/2) (minusp -1) (evenp 10) (oddp 10))",
+			want: "(t t t t nil)",
+		},
+		{
+			name: "type predicates",
+			expr: "(list (numberp 1) (integerp 1/2) (rationalp 1/2) (floatp 1.0) (realp #C(1 1)) (complexp #C(1 1)))",
+			want: "(t nil t t nil t)",
+		},
+		{
+			name: "two argument atan",
+			expr: "(atan 1 -1)",
+			want: "2.356194490192345E+00",
+		},
+		{
+			name: "asin outside real domain",
+			expr: "(complexp (asin 2))",
+			want: "t",
+		},
+		{
+			name: "branch cuts of asin and acos",
+			expr: "(list (asin 2) (acos 2) (asin -2) (acos -2))",
+			want: "(#C(1.5707963267948966E+00 -1.3169578969248164E+00) #C(0E+00 1.3169578969248164E+00) " +
+				"#C(-1.5707963267948966E+00 1.3169578969248164E+00) #C(3.141592653589793E+00 -1.3169578969248164E+00))",
+		},
+		{
+			name: "hyperbolic functions",
+			expr: "(list (sinh 0) (cosh 0) (tanh 0))",
+			want: "(0E+00 1E+00 0E+00)",
+		},
+		{
+			name:    "comparison of non number",
+			expr:    "(< 1 'a)",
+			wantErr: true,
+		},
+		{
+			name:    "evenp of non integer",
+			expr:    "(evenp 1.0)",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("could not evaluate %s: %v", tt.expr, err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("%s => got: %v, expected %s", tt.expr, *got, tt.want)
+				return
+			}
+		})
+	}
+}
+
+func TestBuiltinFloat(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "float equality is exact",
+			expr: "(list (= 1.0 1.000000001) (= 0.5 1/2) (= 0.1 1/10))",
+			want: "(nil t nil)",
+		},
+		{
+			name: "single float arithmetic",
+			expr: "(list (+ 1.5f0 1) (* 2 0.5f0) (+ 1.5f0 1.0d0))",
+			want: "(2.5F+00 1F+00 2.5E+00)",
+		},
+		{
+			name: "float types",
+			expr: "(list (type-of 1.0) (type-of 1.0f0) (typep 1.0f0 'float) (typep 1.0 'single-float))",
+			want: "(double-float single-float t nil)",
+		},
+		{
+			name: "float with prototype",
+			expr: "(list (float 1/2) (float 1/2 1.0f0) (float 0.5f0 1.0d0) (float 0.5f0))",
+			want: "(5E-01 5F-01 5E-01 5F-01)",
+		},
+		{
+			name: "decode-float",
+			expr: "(multiple-value-list (decode-float -6.0))",
+			want: "(7.5E-01 3 -1E+00)",
+		},
+		{
+			name: "integer-decode-float",
+			expr: "(multiple-value-list (integer-decode-float 1.0))",
+			want: "(4503599627370496 -52 1)",
+		},
+		{
+			name: "integer-decode-float of single float",
+			expr: "(multiple-value-list (integer-decode-float -1.0f0))",
+			want: "(8388608 -23 -1)",
+		},
+		{
+			name: "float-sign",
+			expr: "(list (float-sign -2.0) (float-sign 2.0 -3.0) (float-sign -0.0))",
+			want: "(-1E+00 3E+00 -1E+00)",
+		},
+		{
+			name: "float-digits",
+			expr: "(list (float-digits 1.0) (float-digits 1.0f0))",
+			want: "(53 24)",
+		},
+		{
+			name: "scale-float",
+			expr: "(list (scale-float 1.0 10) (scale-float 1.0f0 -1))",
+			want: "(1.024E+03 5F-01)",
+		},
+		{
+			name: "IEEE division by zero",
+			expr: "(let ((*float-traps* nil)) (list (/ 1.0 0.0) (/ -1.0 0.0)))",
+			want: "(+Inf -Inf)",
+		},
+		{
+			name: "IEEE overflow",
+			expr: "(let ((*float-traps* nil)) (* 1d300 1d300))",
+			want: "+Inf",
+		},
+		{
+			name: "NaN is not equal to itself",
+			expr: "(let ((*float-traps* nil)) (let ((nan (/ 0.0 0.0))) (list (= nan nan) (/= nan nan) (< nan 1.0))))",
+			want: "(nil t nil)",
+		},
+		{
+			name: "bignum overflows to infinity without traps",
+			expr: "(let ((*float-traps* nil)) (list (+ 1.0 (expt 10 400)) (- 1.0 (expt 10 400))))",
+			want: "(+Inf -Inf)",
+		},
+		{
+			name: "bignum beyond float range is compared exactly",
+			expr: "(list (= (expt 10 400) (let ((*float-traps* nil)) (* 1.0 (expt 10 400)))) (< 1d308 (expt 10 400)) (let ((*float-traps* nil)) (< (expt 10 400) (/ 1.0 0.0))))",
+			want: "(nil t t)",
+		},
+		{
+			name: "only enabled traps signal",
+			expr: "(let ((*float-traps* '(:overflow))) (/ 1.0 0.0))",
+			want: "+Inf",
+		},
+		{
+			name:    "float division by zero",
+			expr:    "(/ 1.0 0.0)",
+			wantErr: true,
+		},
+		{
+			name:    "float overflow",
+			expr:    "(* 1d300 1d300)",
+			wantErr: true,
+		},
+		{
+			name:    "single float overflow",
+			expr:    "(* 1f30 1f30)",
+			wantErr: true,
+		},
+		{
+			name:    "invalid operation",
+			expr:    "(/ 0.0 0.0)",
+			wantErr: true,
+		},
+		{
+			name:    "exp overflow",
+			expr:    "(exp 1000)",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("could not evaluate %s: %v", tt.expr, err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("%s => got: %v, expected %s", tt.expr, *got, tt.want)
+				return
+			}
+		})
+	}
+}
+
+func TestBuiltinFloatTraps(t *testing.T) {
+	_, err := readEvalString("(* 1d300 1d300)")
+	if _, ok := err.(*ErrFloatingPointOverflow); !ok {
+		t.Errorf("could not get floating point overflow error: %v", err)
+	}
+
+	_, err = readEvalString("(/ 0.0 0.0)")
+	if _, ok := err.(*ErrFloatingPointInvalidOperation); !ok {
+		t.Errorf("could not get floating point invalid operation error: %v", err)
+	}
+
+	_, err = readEvalString("(/ 1.0 0.0)")
+	if _, ok := err.(*ErrDivisionByZero); !ok {
+		t.Errorf("could not get division by zero error: %v", err)
+	}
+
+	for _, expr := range []string{
+		"(+ 1.0 (expt 10 400))",
+		"(* 1f0 (expt 10 100))",
+		"(sqrt (expt 10 400))",
+		"(exp (expt 10 400))",
+		"(sin (expt 10 400))",
+		"(log (expt 10 400))",
+		"(atan (expt 10 400) 1.0)",
+		"(expt (expt 10 400) 0.5)",
+	} {
+		_, err = readEvalString(expr)
+		if _, ok := err.(*ErrFloatingPointOverflow); !ok {
+			t.Errorf("%s => could not get floating point overflow error: %v", expr, err)
+		}
+	}
+}
+
+func TestBuiltinDivisionByZero(t *testing.T) {
+	for _, expr := range []string{"(/ 1 0)", "(mod 10 0)"} {
+		_, err := readEvalString(expr)
+		if _, ok := err.(*ErrDivisionByZero); !ok {
+			t.Errorf("%s => could not get division by zero error: %v", expr, err)
+		}
+	}
+}
+
+func TestBuiltinRationalArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{
+			name: "integer division yields ratio",
+			expr: "(/ 1 3)",
+			want: "1/3",
+		},
+		{
+			name: "ratio is normalized",
+			expr: "(/ 6 -4)",
+			want: "-3/2",
+		},
+		{
+			name: "ratio demoted to integer",
+			expr: "(+ 1/3 2/3)",
+			want: "1",
+		},
+		{
+			name: "reciprocal",
+			expr: "(/ 4)",
+			want: "1/4",
+		},
+		{
+			name: "ratio and float contagion",
+			expr: "(+ 1/2 0.25)",
+			want: "7.5E-01",
+		},
+		{
+			name: "numerator and denominator",
+			expr: "(list (numerator 6/4) (denominator 6/4) (denominator 7))",
+			want: "(3 2 1)",
+		},
+		{
+			name: "rational is exact",
+			expr: "(rational 0.1)",
+			want: "3602879701896397/36028797018963968",
+		},
+		{
+			name: "rationalize",
+			expr: "(rationalize 0.1)",
+			want: "1/10",
+		},
+		{
+			name: "compare ratio and float exactly",
+			expr: "(list (< 1/3 0.3333) (= 1/2 0.5) (> 1/3 0.3333))",
+			want: "(nil t t)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if err != nil {
+				t.Errorf("could not evaluate %s: %v", tt.expr, err)
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("%s => got: %v, expected %s", tt.expr, *got, tt.want)
+				return
+			}
+		})
+	}
+}
+
+func TestBuiltinComplex(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "addition",
+			expr: "(+ #C(1 2) #C(3 -1))",
+			want: "#C(4E+00 1E+00)",
+		},
+		{
+			name: "multiplication",
+			expr: "(* #C(0 1) #C(0 1))",
+			want: "#C(-1E+00 0E+00)",
+		},
+		{
+			name: "division",
+			expr: "(/ #C(4 2) 2)",
+			want: "#C(2E+00 1E+00)",
+		},
+		{
+			name: "equality with real",
+			expr: "(list (= #C(2 0) 2) (= #C(1 1) 1))",
+			want: "(t nil)",
+		},
+		{
+			name: "complex constructor",
+			expr: "(list (complex 1) (complex 1 0) (complex 1 2))",
+			want: "(1 1 #C(1E+00 2E+00))",
+		},
+		{
+			name: "parts",
+			expr: "(list (realpart #C(1 2)) (imagpart #C(1 2)) (imagpart 5))",
+			want: "(1E+00 2E+00 0)",
+		},
+		{
+			name: "conjugate",
+			expr: "(conjugate #C(1 2))",
+			want: "#C(1E+00 -2E+00)",
+		},
+		{
+			name: "phase",
+			expr: "(phase #C(0 1))",
+			want: "1.5707963267948966E+00",
+		},
+		{
+			name: "cis",
+			expr: "(cis 0)",
+			want: "#C(1E+00 0E+00)",
+		},
+		{
+			name: "abs",
+			expr: "(list (abs -3) (abs -1/2) (abs #C(3 4)))",
+			want: "(3 1/2 5E+00)",
+		},
+		{
+			name: "sqrt of negative number",
+			expr: "(sqrt -4)",
+			want: "#C(0E+00 2E+00)",
+		},
+		{
+			name: "sqrt",
+			expr: "(sqrt 4)",
+			want: "2E+00",
+		},
+		{
+			name: "exp",
+			expr: "(exp 0)",
+			want: "1E+00",
+		},
+		{
+			name: "log of negative number",
+			expr: "(realpart (log -1))",
+			want: "0E+00",
+		},
+		{
+			name: "log with base",
+			expr: "(log 8 2)",
+			want: "3E+00",
+		},
+		{
+			name: "exact expt",
+			expr: "(list (expt 2 100) (expt 2/3 2) (expt 2 -2))",
+			want: "(1267650600228229401496703205376 4/9 1/4)",
+		},
+		{
+			name: "complex expt",
+			expr: "(expt #C(0 1) 2)",
+			want: "#C(-1E+00 0E+00)",
+		},
+		{
+			name:    "complex numbers are not ordered",
+			expr:    "(< #C(1 1) 2)",
+			wantErr: true,
+		},
+		{
+			name:    "division by complex zero",
+			expr:    "(/ #C(1 1) #C(0 0))",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("could not evaluate %s: %v", tt.expr, err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("%s => got: %v, expected %s", tt.expr, *got, tt.want)
+				return
+			}
+		})
+	}
+}
+
+func TestBuiltinKeyword(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "keyword is self-evaluating",
+			expr: "(list :foo (eq :foo ':foo) (eq :foo (symbol-value :foo)))",
+			want: "(:foo t t)",
+		},
+		{
+			name: "keyword package",
+			expr: "(list (symbol-name :bar) (symbol-package :bar) (eq (symbol-package :bar) (symbol-package 'bar)))",
+			want: `("bar" KEYWORD nil)`,
+		},
+		{
+			name: "keyword differs from symbol",
+			expr: "(list (eq :baz 'baz) (type-of :baz) (type-of 'baz))",
+			want: "(nil keyword symbol)",
+		},
+		{
+			name:    "setq keyword",
+			expr:    "(setq :foo 1)",
+			wantErr: true,
+		},
+		{
+			name:    "bind keyword",
+			expr:    "(let ((:foo 1)) :foo)",
+			wantErr: true,
+		},
+		{
+			name:    "keyword parameter",
+			expr:    "(lambda (:foo) 1)",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("readEvalString(%s) error = %v", tt.expr, err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("got: %v, expected: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuiltinString(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "length counts characters",
+			expr: `(list (length "日本語") (length "café") (length ""))`,
+			want: "(3 4 0)",
+		},
+		{
+			name: "char",
+			expr: `(list (char "日本語" 0) (char "café" 3))`,
+			want: `(#\日 #\é)`,
+		},
+		{
+			name: "subseq of string",
+			expr: `(list (subseq "日本語です" 1 3) (subseq "日本語" 1) (subseq "abc" 3))`,
+			want: `("本語" "本語" "")`,
+		},
+		{
+			name: "subseq of list",
+			expr: `(list (subseq '(1 2 3 4) 1 3) (subseq '(1 2) 2))`,
+			want: "((2 3) nil)",
+		},
+		{
+			name:    "char out of range",
+			expr:    `(char "日本" 2)`,
+			wantErr: true,
+		},
+		{
+			name:    "subseq start after end",
+			expr:    `(subseq "abc" 2 1)`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("readEvalString(%s) error = %v", tt.expr, err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("got: %v, expected: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuiltinCircularList(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "length of proper lists",
+			expr: "(list (length '(1 2 3)) (length '(1 2 3 4)) (length nil) (length '()))",
+			want: "(3 4 0 0)",
+		},
+		{
+			name: "list-length",
+			expr: "(list (list-length '(1 2 3)) (list-length '#1=(1 2 . #1#)) (list-length '#2=(1 . #2#)) (list-length nil))",
+			want: "(3 nil nil 0)",
+		},
+		{
+			name: "build circular list with rplacd",
+			expr: "(let ((x (list 1 2 3))) (rplacd (cdr (cdr x)) x) (list-length x))",
+			want: "nil",
+		},
+		{
+			name: "rplaca",
+			expr: "(let ((x (list 1 2))) (rplaca x x) x)",
+			want: "#1=(#1# 2)",
+		},
+		{
+			name: "rplacd with nil",
+			expr: "(let ((x (list 1 2))) (rplacd x nil))",
+			want: "(1)",
+		},
+		{
+			name:    "length of circular list",
+			expr:    "(length '#1=(1 2 3 . #1#))",
+			wantErr: true,
+		},
+		{
+			name:    "list-length of dotted list",
+			expr:    "(list-length '(1 2 . 3))",
+			wantErr: true,
+		},
+		{
+			name:    "rplacd of empty list",
+			expr:    "(rplacd nil 1)",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("readEvalString(%s) error = %v", tt.expr, err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("got: %v, expected: %s", got, tt.want)
+			}
+		})
+	}
+}
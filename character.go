@@ -0,0 +1,128 @@
+package banglisp
+
+import (
+	"io"
+	"strings"
+)
+
+// characterNames are the names of characters which have no printable glyph
+var characterNames = map[rune]string{
+	' ':    "Space",
+	'\n':   "Newline",
+	'\t':   "Tab",
+	'\r':   "Return",
+	'\f':   "Page",
+	'\b':   "Backspace",
+	'\x7f': "Rubout",
+	0:      "Nul",
+}
+
+func formatCharacter(c rune) string {
+	if name, ok := characterNames[c]; ok {
+		return `#\` + name
+	}
+
+	return `#\` + string(c)
+}
+
+func characterFromName(name string) (rune, bool) {
+	for c, n := range characterNames {
+		if strings.EqualFold(n, name) {
+			return c, true
+		}
+	}
+
+	if strings.EqualFold(name, "Linefeed") {
+		return '\n', true
+	}
+
+	return 0, false
+}
+
+func characterValue(function string, obj *Object) (rune, error) {
+	c, ok := obj.value.(rune)
+	if !ok {
+		return 0, &ErrUnsupportedArgumentType{function: function, argument: obj}
+	}
+
+	return c, nil
+}
+
+// readCharacter reads #\ syntax. A single character or a character name
+// follows the backslash.
+func readCharacter(br *sourceReader, _ rune, _ *Object) (*Object, error) {
+	c, err := br.readRune()
+	if err == io.EOF {
+		return nil, &ErrEndOfFile{context: "character"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	sb.WriteRune(c)
+	for {
+		c, err = br.readRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if isDelimiter(c) {
+			if err := br.unreadRune(); err != nil {
+				return nil, err
+			}
+			break
+		}
+		sb.WriteRune(c)
+	}
+
+	token := []rune(sb.String())
+	if len(token) == 1 {
+		return newCharacter(token[0]), nil
+	}
+
+	ch, ok := characterFromName(string(token))
+	if !ok {
+		return nil, &ErrInvalidToken{token: `#\` + string(token), reason: "unknown character name"}
+	}
+
+	return newCharacter(ch), nil
+}
+
+func builtinCharacterp(_ *Environment, args []*Object) (*Object, error) {
+	// (characterp obj)
+	if args[0].kind == CharacterType {
+		return tObj, nil
+	}
+
+	return nilObj, nil
+}
+
+func builtinCharCode(_ *Environment, args []*Object) (*Object, error) {
+	// (char-code char)
+	c, err := characterValue("char-code", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return newFixnum(int64(c)), nil
+}
+
+func builtinCodeChar(_ *Environment, args []*Object) (*Object, error) {
+	// (code-char code)
+	code, ok := args[0].value.(int64)
+	if !ok || code < 0 || code > 0x10ffff {
+		return nil, &ErrUnsupportedArgumentType{function: "code-char", argument: args[0]}
+	}
+
+	return newCharacter(rune(code)), nil
+}
+
+func initCharacterFunctions() {
+	installBuiltinFunction("characterp", builtinCharacterp, 1, false)
+	installBuiltinFunction("char-code", builtinCharCode, 1, false)
+	installBuiltinFunction("code-char", builtinCodeChar, 1, false)
+}
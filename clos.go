@@ -0,0 +1,1208 @@
+package banglisp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type classSlot struct {
+	name     *Object
+	initargs []string
+	initform *Object
+}
+
+type Class struct {
+	name            *Object
+	supers          []*Class
+	cpl             []*Class
+	directSlots     []*classSlot
+	slots           []*classSlot
+	defaultInitargs []*Object
+	builtin         bool
+}
+
+type Instance struct {
+	class *Class
+	slots []*Object
+}
+
+type specializer struct {
+	class *Class
+	eql   *Object
+}
+
+type Method struct {
+	qualifier    string
+	specializers []specializer
+	fn           *Object
+}
+
+type GenericFunction struct {
+	name     *Object
+	required int
+	methods  []*Method
+}
+
+var classes = make(map[*Object]*Class)
+var structureObjectClasses = make(map[*StructureClass]*Class)
+var setfGenerics = make(map[*Object]*Object)
+
+var tClass *Class
+var standardObjectClass *Class
+var structureObjectClass *Class
+
+var initializeInstanceObj *Object
+var printObjectObj *Object
+
+// c3Linearize computes class precedence list by C3 linearization
+func c3Linearize(c *Class) ([]*Class, error) {
+	var lists [][]*Class
+	for _, super := range c.supers {
+		lists = append(lists, append([]*Class{}, super.cpl...))
+	}
+	lists = append(lists, append([]*Class{}, c.supers...))
+
+	ret := []*Class{c}
+	for {
+		empty := true
+		for _, l := range lists {
+			if len(l) > 0 {
+				empty = false
+				break
+			}
+		}
+
+		if empty {
+			return ret, nil
+		}
+
+		var next *Class
+		for _, l := range lists {
+			if len(l) == 0 {
+				continue
+			}
+
+			candidate := l[0]
+			inTail := false
+			for _, other := range lists {
+				if len(other) < 2 {
+					continue
+				}
+
+				for _, tc := range other[1:] {
+					if tc == candidate {
+						inTail = true
+					}
+				}
+			}
+
+			if !inTail {
+				next = candidate
+				break
+			}
+		}
+
+		if next == nil {
+			return nil, fmt.Errorf("inconsistent class precedence list for %v", *c.name)
+		}
+
+		ret = append(ret, next)
+		for i, l := range lists {
+			if len(l) > 0 && l[0] == next {
+				lists[i] = l[1:]
+			}
+		}
+	}
+}
+
+func (c *Class) isSubclassOf(other *Class) bool {
+	for _, cc := range c.cpl {
+		if cc == other {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Class) slotIndex(name *Object) int {
+	for i, slot := range c.slots {
+		if slot.name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (c *Class) computeSlots() {
+	c.slots = nil
+	for i := len(c.cpl) - 1; i >= 0; i-- {
+		for _, direct := range c.cpl[i].directSlots {
+			index := c.slotIndex(direct.name)
+			if index < 0 {
+				copied := *direct
+				copied.initargs = append([]string{}, direct.initargs...)
+				c.slots = append(c.slots, &copied)
+				continue
+			}
+
+			slot := c.slots[index]
+			slot.initargs = append(slot.initargs, direct.initargs...)
+			if direct.initform != nil {
+				slot.initform = direct.initform
+			}
+		}
+	}
+}
+
+func defineBuiltinClass(name string, supers ...*Class) *Class {
+	c := &Class{
+		name:    newSymbol(name),
+		supers:  supers,
+		builtin: true,
+	}
+
+	cpl, err := c3Linearize(c)
+	if err != nil {
+		panic(err)
+	}
+
+	c.cpl = cpl
+	classes[c.name] = c
+	return c
+}
+
+func newClassObject(c *Class) *Object {
+	return newObject(ClassType, c)
+}
+
+func structureObjectClassOf(sc *StructureClass) *Class {
+	if c, ok := structureObjectClasses[sc]; ok {
+		return c
+	}
+
+	super := structureObjectClass
+	if sc.parent != nil {
+		super = structureObjectClassOf(sc.parent)
+	}
+
+	c := &Class{
+		name:    sc.name,
+		supers:  []*Class{super},
+		builtin: true,
+	}
+	c.cpl, _ = c3Linearize(c)
+	structureObjectClasses[sc] = c
+	return c
+}
+
+func findClass(name *Object) (*Class, bool) {
+	if c, ok := classes[name]; ok {
+		return c, true
+	}
+
+	if sc, ok := structureClasses[name]; ok {
+		return structureObjectClassOf(sc), true
+	}
+
+	return nil, false
+}
+
+func mustFindClass(name string) *Class {
+	c, _ := findClass(newSymbol(name))
+	return c
+}
+
+func classOf(obj *Object) *Class {
+	switch obj.kind {
+	case FixnumType:
+		return mustFindClass("fixnum")
+	case BignumType:
+		return mustFindClass("bignum")
+	case RatioType:
+		return mustFindClass("ratio")
+	case ComplexType:
+		return mustFindClass("complex")
+	case FloatType:
+		return mustFindClass("double-float")
+	case SingleFloatType:
+		return mustFindClass("single-float")
+	case DecimalType:
+		return mustFindClass("decimal")
+	case CharacterType:
+		return mustFindClass("character")
+	case StringType:
+		return mustFindClass("string")
+	case SymbolType:
+		if isNull(obj) {
+			return mustFindClass("null")
+		} else if isKeyword(obj) {
+			return mustFindClass("keyword")
+		}
+		return mustFindClass("symbol")
+	case ConsCellType:
+		if obj == emptyList {
+			return mustFindClass("null")
+		}
+		return mustFindClass("cons")
+	case BuiltinFunctionType, ClosureType:
+		return mustFindClass("function")
+	case GenericFunctionType:
+		return mustFindClass("generic-function")
+	case HashTableType:
+		return mustFindClass("hash-table")
+	case PackageType:
+		return mustFindClass("package")
+	case StreamType:
+		return mustFindClass("stream")
+	case RandomStateType:
+		return mustFindClass("random-state")
+	case ReadtableType:
+		return mustFindClass("readtable")
+	case PathnameType:
+		return mustFindClass("pathname")
+	case ClassType:
+		return mustFindClass("class")
+	case StructureType:
+		return structureObjectClassOf(obj.value.(*Structure).class)
+	case InstanceType:
+		return obj.value.(*Instance).class
+	default:
+		return tClass
+	}
+}
+
+func (c *Class) String() string {
+	return fmt.Sprintf("#<class %v>", *c.name)
+}
+
+func (i *Instance) String(obj *Object) string {
+	return fmt.Sprintf("#<%v {%d}>", *i.class.name, obj.id)
+}
+
+// printObject calls print-object generic function to print obj. It returns
+// false if no user defined method is applicable.
+func printObject(obj *Object) (string, bool) {
+	gf, ok := printObjectObj.value.(*Symbol).function.value.(*GenericFunction)
+	if !ok {
+		return "", false
+	}
+
+	stream := newStringOutputStream()
+	args := []*Object{obj, stream}
+	methods := gf.applicableMethods(args)
+	if len(methods) == 0 || methods[0].fn.kind == BuiltinFunctionType {
+		return "", false
+	}
+
+	if _, err := gf.call(defaultEnvironment, args); err != nil {
+		return "", false
+	}
+
+	return stream.value.(*Stream).sb.String(), true
+}
+
+func (s specializer) matches(arg *Object) bool {
+	if s.eql != nil {
+		return isEql(s.eql, arg)
+	}
+
+	return classOf(arg).isSubclassOf(s.class)
+}
+
+func (s specializer) same(other specializer) bool {
+	if s.eql != nil || other.eql != nil {
+		return s.eql != nil && other.eql != nil && isEql(s.eql, other.eql)
+	}
+
+	return s.class == other.class
+}
+
+func (m *Method) applicable(args []*Object) bool {
+	for i, s := range m.specializers {
+		if !s.matches(args[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// moreSpecific returns whether method a is more specific than method b for
+// given arguments
+func moreSpecific(a *Method, b *Method, args []*Object) bool {
+	for i := range a.specializers {
+		sa := a.specializers[i]
+		sb := b.specializers[i]
+		if sa.same(sb) {
+			continue
+		}
+
+		if sa.eql != nil {
+			return true
+		}
+
+		if sb.eql != nil {
+			return false
+		}
+
+		for _, c := range classOf(args[i]).cpl {
+			if c == sa.class {
+				return true
+			}
+
+			if c == sb.class {
+				return false
+			}
+		}
+	}
+
+	return false
+}
+
+func newGenericFunction(name *Object, required int) *Object {
+	gf := &GenericFunction{
+		name:     name,
+		required: required,
+	}
+
+	return newObject(GenericFunctionType, gf)
+}
+
+func (gf *GenericFunction) addMethod(m *Method) error {
+	if len(m.specializers) != gf.required {
+		return fmt.Errorf("method for %v has %d required parameters, but generic function has %d",
+			*gf.name, len(m.specializers), gf.required)
+	}
+
+	for i, old := range gf.methods {
+		if old.qualifier != m.qualifier {
+			continue
+		}
+
+		same := true
+		for j := range old.specializers {
+			if !old.specializers[j].same(m.specializers[j]) {
+				same = false
+				break
+			}
+		}
+
+		if same {
+			gf.methods[i] = m
+			return nil
+		}
+	}
+
+	gf.methods = append(gf.methods, m)
+	return nil
+}
+
+func (gf *GenericFunction) applicableMethods(args []*Object) []*Method {
+	var ret []*Method
+	for _, m := range gf.methods {
+		if m.applicable(args) {
+			ret = append(ret, m)
+		}
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		return moreSpecific(ret[i], ret[j], args)
+	})
+
+	return ret
+}
+
+type nextMethodFunction func(args []*Object) (*Object, error)
+
+var callNextMethodObj *Object
+var nextMethodPObj *Object
+
+func invokeMethod(env *Environment, m *Method, args []*Object, next nextMethodFunction) (*Object, error) {
+	cnm := callNextMethodObj.value.(*Symbol)
+	nmp := nextMethodPObj.value.(*Symbol)
+	savedCnm := cnm.function
+	savedNmp := nmp.function
+	defer func() {
+		cnm.function = savedCnm
+		nmp.function = savedNmp
+	}()
+
+	cnm.function = newBuiltinFunction(func(_ *Environment, nextArgs []*Object) (*Object, error) {
+		if next == nil {
+			return nil, fmt.Errorf("no next method")
+		}
+
+		if len(nextArgs) == 0 {
+			nextArgs = args
+		}
+		return next(nextArgs)
+	}, 0, true)
+
+	nmp.function = newBuiltinFunction(func(_ *Environment, _ []*Object) (*Object, error) {
+		if next == nil {
+			return nilObj, nil
+		}
+		return tObj, nil
+	}, 0, false)
+
+	return funcall(env, m.fn, args)
+}
+
+func (gf *GenericFunction) call(env *Environment, args []*Object) (*Object, error) {
+	if len(args) < gf.required {
+		return nil, &ErrWrongNumberArguments{variadic: true, expected: gf.required, got: len(args)}
+	}
+
+	var arounds, befores, primaries, afters []*Method
+	for _, m := range gf.applicableMethods(args) {
+		switch m.qualifier {
+		case "around":
+			arounds = append(arounds, m)
+		case "before":
+			befores = append(befores, m)
+		case "after":
+			afters = append([]*Method{m}, afters...)
+		default:
+			primaries = append(primaries, m)
+		}
+	}
+
+	if len(primaries) == 0 {
+		var sb strings.Builder
+		for i, arg := range args {
+			if i != 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(arg.String())
+		}
+		return nil, fmt.Errorf("no applicable method for %v with arguments (%s)", *gf.name, sb.String())
+	}
+
+	var callPrimary func(index int, args []*Object) (*Object, error)
+	callPrimary = func(index int, args []*Object) (*Object, error) {
+		var next nextMethodFunction
+		if index+1 < len(primaries) {
+			next = func(args []*Object) (*Object, error) {
+				return callPrimary(index+1, args)
+			}
+		}
+		return invokeMethod(env, primaries[index], args, next)
+	}
+
+	inner := func(args []*Object) (*Object, error) {
+		for _, m := range befores {
+			if _, err := invokeMethod(env, m, args, nil); err != nil {
+				return nil, err
+			}
+		}
+
+		ret, err := callPrimary(0, args)
+		if err != nil {
+			return nil, err
+		}
+
+		values := env.multipleValues(ret)
+		for _, m := range afters {
+			if _, err := invokeMethod(env, m, args, nil); err != nil {
+				return nil, err
+			}
+		}
+
+		return env.setValues(values), nil
+	}
+
+	var callAround func(index int, args []*Object) (*Object, error)
+	callAround = func(index int, args []*Object) (*Object, error) {
+		if index >= len(arounds) {
+			return inner(args)
+		}
+
+		return invokeMethod(env, arounds[index], args, func(args []*Object) (*Object, error) {
+			return callAround(index+1, args)
+		})
+	}
+
+	return callAround(0, args)
+}
+
+// parseFunctionName parses function name, symbol or (setf symbol)
+func parseFunctionName(obj *Object) (*Object, bool, error) {
+	if obj.kind == SymbolType {
+		return obj, false, nil
+	}
+
+	elems := noEvalArguments(obj)
+	if obj.kind == ConsCellType && len(elems) == 2 && elems[0] == newSymbol("setf") && elems[1].kind == SymbolType {
+		return elems[1], true, nil
+	}
+
+	return nil, false, fmt.Errorf("invalid function name: %v", *obj)
+}
+
+func installSetfGeneric(name *Object, gfObj *Object) {
+	setfGenerics[name] = gfObj
+	setfFunctions[name] = func(env *Environment, args []*Object, value *Object) (*Object, error) {
+		return gfObj.value.(*GenericFunction).call(env, append([]*Object{value}, args...))
+	}
+}
+
+// ensureGenericFunction returns generic function for name, creating it if
+// necessary
+func ensureGenericFunction(nameObj *Object, required int) (*GenericFunction, error) {
+	name, isSetf, err := parseFunctionName(nameObj)
+	if err != nil {
+		return nil, err
+	}
+
+	if isSetf {
+		if gfObj, ok := setfGenerics[name]; ok {
+			return gfObj.value.(*GenericFunction), nil
+		}
+
+		gfObj := newGenericFunction(nameObj, required)
+		installSetfGeneric(name, gfObj)
+		return gfObj.value.(*GenericFunction), nil
+	}
+
+	sym := name.value.(*Symbol)
+	if gf, ok := sym.function.value.(*GenericFunction); ok {
+		return gf, nil
+	}
+
+	gfObj := newGenericFunction(name, required)
+	sym.function = gfObj
+	return gfObj.value.(*GenericFunction), nil
+}
+
+func requiredParameterCount(params []*Object) int {
+	n := 0
+	for _, param := range params {
+		if sym, ok := param.value.(*Symbol); ok && strings.HasPrefix(sym.name.value.(string), "&") {
+			break
+		}
+		n++
+	}
+
+	return n
+}
+
+func parseSpecializer(env *Environment, obj *Object) (specializer, error) {
+	if obj.kind == ConsCellType {
+		elems := noEvalArguments(obj)
+		if len(elems) != 2 || elems[0] != newSymbol("eql") {
+			return specializer{}, fmt.Errorf("invalid specializer: %v", *obj)
+		}
+
+		value, err := elems[1].Eval(env)
+		if err != nil {
+			return specializer{}, err
+		}
+
+		return specializer{eql: value}, nil
+	}
+
+	c, ok := findClass(obj)
+	if !ok {
+		return specializer{}, fmt.Errorf("no class named %v", *obj)
+	}
+
+	return specializer{class: c}, nil
+}
+
+func defineMethod(env *Environment, nameObj *Object, args []*Object) (*Object, error) {
+	qualifier := ""
+	if len(args) > 0 {
+		if name, ok := keywordName(args[0]); ok {
+			if name != "before" && name != "after" && name != "around" {
+				return nil, fmt.Errorf("unsupported method qualifier: %v", *args[0])
+			}
+			qualifier = name
+			args = args[1:]
+		}
+	}
+
+	if len(args) == 0 {
+		return nil, fmt.Errorf("defmethod: lambda list is missing")
+	}
+
+	params := noEvalArguments(args[0])
+	required := requiredParameterCount(params)
+
+	m := &Method{qualifier: qualifier}
+	plain := make([]*Object, len(params))
+	copy(plain, params)
+	for i := 0; i < required; i++ {
+		param := params[i]
+		s := specializer{class: tClass}
+		if param.kind == ConsCellType {
+			elems := noEvalArguments(param)
+			if len(elems) != 2 || elems[0].kind != SymbolType {
+				return nil, fmt.Errorf("invalid specialized parameter: %v", *param)
+			}
+
+			var err error
+			s, err = parseSpecializer(env, elems[1])
+			if err != nil {
+				return nil, err
+			}
+			plain[i] = elems[0]
+		}
+		m.specializers = append(m.specializers, s)
+	}
+
+	fn, err := newClosure(nameObj, plain, args[1:], env)
+	if err != nil {
+		return nil, err
+	}
+	m.fn = fn
+
+	gf, err := ensureGenericFunction(nameObj, required)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := gf.addMethod(m); err != nil {
+		return nil, err
+	}
+
+	return fn, nil
+}
+
+func specialDefgeneric(env *Environment, args []*Object) (*Object, error) {
+	// (defgeneric name lambda-list option...)
+	params := noEvalArguments(args[1])
+	gf, err := ensureGenericFunction(args[0], requiredParameterCount(params))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, option := range args[2:] {
+		elems := noEvalArguments(option)
+		if option.kind != ConsCellType || len(elems) == 0 {
+			return nil, fmt.Errorf("defgeneric: invalid option %v", *option)
+		}
+
+		name, _ := keywordName(elems[0])
+		switch name {
+		case "documentation":
+		case "method":
+			if _, err := defineMethod(env, args[0], elems[1:]); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("defgeneric: unsupported option %v", *option)
+		}
+	}
+
+	return newSymbol(gf.name.String()), nil
+}
+
+func specialDefmethod(env *Environment, args []*Object) (*Object, error) {
+	// (defmethod name [qualifier] specialized-lambda-list body...)
+	return defineMethod(env, args[0], args[1:])
+}
+
+func installAccessorMethod(c *Class, nameObj *Object, slotName *Object, writer bool) error {
+	var m *Method
+	if writer {
+		m = &Method{
+			specializers: []specializer{{class: tClass}, {class: c}},
+			fn: newBuiltinFunction(func(_ *Environment, args []*Object) (*Object, error) {
+				return setSlotValue(args[1], slotName, args[0])
+			}, 2, false),
+		}
+	} else {
+		m = &Method{
+			specializers: []specializer{{class: c}},
+			fn: newBuiltinFunction(func(_ *Environment, args []*Object) (*Object, error) {
+				return slotValue(args[0], slotName)
+			}, 1, false),
+		}
+	}
+
+	required := 1
+	if writer {
+		required = 2
+	}
+
+	gf, err := ensureGenericFunction(nameObj, required)
+	if err != nil {
+		return err
+	}
+
+	return gf.addMethod(m)
+}
+
+func parseClassSlot(c *Class, obj *Object) (*classSlot, error) {
+	if obj.kind == SymbolType {
+		return &classSlot{name: obj}, nil
+	}
+
+	elems := noEvalArguments(obj)
+	if obj.kind != ConsCellType || len(elems) == 0 || len(elems)%2 != 1 {
+		return nil, fmt.Errorf("defclass: invalid slot specifier %v", *obj)
+	}
+
+	slot := &classSlot{name: elems[0]}
+	for i := 1; i < len(elems); i += 2 {
+		key, _ := keywordName(elems[i])
+		value := elems[i+1]
+		switch key {
+		case "initarg":
+			name, ok := keywordName(value)
+			if !ok {
+				name, ok = symbolNameString(value)
+			}
+			if !ok {
+				return nil, fmt.Errorf("defclass: invalid initarg %v", *value)
+			}
+			slot.initargs = append(slot.initargs, name)
+		case "initform":
+			slot.initform = value
+		case "reader":
+			if err := installAccessorMethod(c, value, slot.name, false); err != nil {
+				return nil, err
+			}
+		case "writer":
+			if err := installAccessorMethod(c, value, slot.name, true); err != nil {
+				return nil, err
+			}
+		case "accessor":
+			if err := installAccessorMethod(c, value, slot.name, false); err != nil {
+				return nil, err
+			}
+
+			setfName := cons(newSymbol("setf"), cons(value, emptyList))
+			if err := installAccessorMethod(c, setfName, slot.name, true); err != nil {
+				return nil, err
+			}
+		case "type", "documentation", "allocation":
+		default:
+			return nil, fmt.Errorf("defclass: unsupported slot option %v", *elems[i])
+		}
+	}
+
+	return slot, nil
+}
+
+func specialDefclass(_ *Environment, args []*Object) (*Object, error) {
+	// (defclass name (superclass...) (slot-specifier...) class-option...)
+	if args[0].kind != SymbolType || isNull(args[0]) {
+		return nil, &ErrUnsupportedArgumentType{function: "defclass", argument: args[0]}
+	}
+
+	c := &Class{name: args[0]}
+	for _, superName := range noEvalArguments(args[1]) {
+		super, ok := findClass(superName)
+		if !ok {
+			return nil, fmt.Errorf("defclass: no class named %v", *superName)
+		}
+		c.supers = append(c.supers, super)
+	}
+
+	if len(c.supers) == 0 {
+		c.supers = []*Class{standardObjectClass}
+	}
+
+	cpl, err := c3Linearize(c)
+	if err != nil {
+		return nil, err
+	}
+	c.cpl = cpl
+
+	for _, spec := range noEvalArguments(args[2]) {
+		slot, err := parseClassSlot(c, spec)
+		if err != nil {
+			return nil, err
+		}
+		c.directSlots = append(c.directSlots, slot)
+	}
+	c.computeSlots()
+
+	for _, option := range args[3:] {
+		elems := noEvalArguments(option)
+		if option.kind != ConsCellType || len(elems) == 0 {
+			return nil, fmt.Errorf("defclass: invalid option %v", *option)
+		}
+
+		name, _ := keywordName(elems[0])
+		switch name {
+		case "documentation":
+		case "default-initargs":
+			if len(elems)%2 != 1 {
+				return nil, fmt.Errorf("defclass: odd number of default initargs")
+			}
+			c.defaultInitargs = elems[1:]
+		default:
+			return nil, fmt.Errorf("defclass: unsupported option %v", *option)
+		}
+	}
+
+	classes[c.name] = c
+	return newClassObject(c), nil
+}
+
+func instanceSlot(function string, obj *Object, slotName *Object) (*Instance, int, error) {
+	instance, ok := obj.value.(*Instance)
+	if !ok {
+		return nil, -1, &ErrUnsupportedArgumentType{function: function, argument: obj}
+	}
+
+	index := instance.class.slotIndex(slotName)
+	if index < 0 {
+		return nil, -1, fmt.Errorf("%v has no slot named %v", *obj, *slotName)
+	}
+
+	return instance, index, nil
+}
+
+func slotValue(obj *Object, slotName *Object) (*Object, error) {
+	if s, ok := obj.value.(*Structure); ok {
+		index := s.class.slotIndex(slotName.value.(*Symbol).name.value.(string))
+		if index < 0 {
+			return nil, fmt.Errorf("%v has no slot named %v", *obj, *slotName)
+		}
+		return s.slots[index], nil
+	}
+
+	instance, index, err := instanceSlot("slot-value", obj, slotName)
+	if err != nil {
+		return nil, err
+	}
+
+	if instance.slots[index] == nil {
+		return nil, fmt.Errorf("slot %v is unbound in %v", *slotName, *obj)
+	}
+
+	return instance.slots[index], nil
+}
+
+func setSlotValue(obj *Object, slotName *Object, value *Object) (*Object, error) {
+	if s, ok := obj.value.(*Structure); ok {
+		index := s.class.slotIndex(slotName.value.(*Symbol).name.value.(string))
+		if index < 0 {
+			return nil, fmt.Errorf("%v has no slot named %v", *obj, *slotName)
+		}
+		s.slots[index] = value
+		return value, nil
+	}
+
+	instance, index, err := instanceSlot("slot-value", obj, slotName)
+	if err != nil {
+		return nil, err
+	}
+
+	instance.slots[index] = value
+	return value, nil
+}
+
+func builtinSlotValue(_ *Environment, args []*Object) (*Object, error) {
+	// (slot-value instance slot-name)
+	return slotValue(args[0], args[1])
+}
+
+func setfSlotValue(_ *Environment, args []*Object, value *Object) (*Object, error) {
+	// (setf (slot-value instance slot-name) value)
+	if len(args) != 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+	}
+
+	return setSlotValue(args[0], args[1], value)
+}
+
+func builtinSlotBoundp(_ *Environment, args []*Object) (*Object, error) {
+	// (slot-boundp instance slot-name)
+	instance, index, err := instanceSlot("slot-boundp", args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if instance.slots[index] == nil {
+		return nilObj, nil
+	}
+
+	return tObj, nil
+}
+
+func classValue(function string, obj *Object) (*Class, error) {
+	if c, ok := obj.value.(*Class); ok {
+		return c, nil
+	}
+
+	if c, ok := findClass(obj); ok {
+		return c, nil
+	}
+
+	return nil, &ErrUnsupportedArgumentType{function: function, argument: obj}
+}
+
+func builtinMakeInstance(env *Environment, args []*Object) (*Object, error) {
+	// (make-instance class &rest initargs)
+	c, err := classValue("make-instance", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if c.builtin {
+		return nil, fmt.Errorf("make-instance: cannot instantiate built-in class %v", *c.name)
+	}
+
+	initargs := args[1:]
+	if len(initargs)%2 != 0 {
+		return nil, fmt.Errorf("make-instance: odd number of initargs")
+	}
+
+	for _, cc := range c.cpl {
+		for i := 0; i < len(cc.defaultInitargs); i += 2 {
+			key := cc.defaultInitargs[i]
+			found := false
+			for j := 0; j < len(initargs); j += 2 {
+				if initargs[j] == key {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				value, err := cc.defaultInitargs[i+1].Eval(env)
+				if err != nil {
+					return nil, err
+				}
+				initargs = append(initargs, key, value)
+			}
+		}
+	}
+
+	instance := newObject(InstanceType, &Instance{
+		class: c,
+		slots: make([]*Object, len(c.slots)),
+	})
+
+	if err := checkInitargs(c, instance, initargs); err != nil {
+		return nil, err
+	}
+
+	gf := initializeInstanceObj.value.(*Symbol).function.value.(*GenericFunction)
+	if _, err := gf.call(env, append([]*Object{instance}, initargs...)); err != nil {
+		return nil, err
+	}
+
+	return instance, nil
+}
+
+// checkInitargs checks that all initargs are valid. Slot initargs and
+// keyword parameters of applicable initialize-instance methods are valid.
+func checkInitargs(c *Class, instance *Object, initargs []*Object) error {
+	valid := map[string]bool{"allow-other-keys": true}
+	for _, slot := range c.slots {
+		for _, initarg := range slot.initargs {
+			valid[initarg] = true
+		}
+	}
+
+	gf := initializeInstanceObj.value.(*Symbol).function.value.(*GenericFunction)
+	for _, m := range gf.applicableMethods([]*Object{instance}) {
+		closure, ok := m.fn.value.(*Closure)
+		if !ok {
+			continue
+		}
+
+		if closure.params.allowOtherKeys {
+			return nil
+		}
+
+		for _, p := range closure.params.keys {
+			valid[p.key] = true
+		}
+	}
+
+	for i := 0; i < len(initargs); i += 2 {
+		name, ok := keywordName(initargs[i])
+		if !ok {
+			name, ok = symbolNameString(initargs[i])
+		}
+
+		if !ok || !valid[name] {
+			return fmt.Errorf("make-instance: invalid initarg %v for %v", *initargs[i], *c.name)
+		}
+
+		if name == "allow-other-keys" && !isNull(initargs[i+1]) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func initializeInstanceDefault(env *Environment, args []*Object) (*Object, error) {
+	// default primary method of (initialize-instance instance &rest initargs)
+	obj := args[0]
+	instance, ok := obj.value.(*Instance)
+	if !ok {
+		return obj, nil
+	}
+
+	initargs := args[1:]
+	for i, slot := range instance.class.slots {
+		found := false
+		for _, initarg := range slot.initargs {
+			for j := 0; j+1 < len(initargs); j += 2 {
+				name, ok := keywordName(initargs[j])
+				if !ok {
+					name, _ = symbolNameString(initargs[j])
+				}
+
+				if name == initarg {
+					instance.slots[i] = initargs[j+1]
+					found = true
+					break
+				}
+			}
+
+			if found {
+				break
+			}
+		}
+
+		if !found && instance.slots[i] == nil && slot.initform != nil {
+			value, err := slot.initform.Eval(env)
+			if err != nil {
+				return nil, err
+			}
+			instance.slots[i] = value
+		}
+	}
+
+	return obj, nil
+}
+
+func printObjectDefault(_ *Environment, args []*Object) (*Object, error) {
+	// default method of (print-object object stream)
+	s, err := outputStream("print-object", args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	var str string
+	if instance, ok := args[0].value.(*Instance); ok {
+		str = instance.String(args[0])
+	} else {
+		str = args[0].String()
+	}
+
+	if err := s.writeString(str); err != nil {
+		return nil, err
+	}
+
+	return args[0], nil
+}
+
+func builtinClassOf(_ *Environment, args []*Object) (*Object, error) {
+	// (class-of object)
+	return newClassObject(classOf(args[0])), nil
+}
+
+func builtinFindClass(_ *Environment, args []*Object) (*Object, error) {
+	// (find-class name &optional errorp)
+	if len(args) > 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+	}
+
+	c, ok := findClass(args[0])
+	if !ok {
+		if len(args) == 2 && isNull(args[1]) {
+			return nilObj, nil
+		}
+		return nil, fmt.Errorf("no class named %v", *args[0])
+	}
+
+	return newClassObject(c), nil
+}
+
+func builtinClassName(_ *Environment, args []*Object) (*Object, error) {
+	// (class-name class)
+	c, ok := args[0].value.(*Class)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "class-name", argument: args[0]}
+	}
+
+	return c.name, nil
+}
+
+func builtinNoNextMethod(_ *Environment, _ []*Object) (*Object, error) {
+	return nil, fmt.Errorf("call-next-method called outside of method")
+}
+
+func initClassFunctions() {
+	tClass = defineBuiltinClass("t")
+	standardObjectClass = defineBuiltinClass("standard-object", tClass)
+	structureObjectClass = defineBuiltinClass("structure-object", tClass)
+
+	number := defineBuiltinClass("number", tClass)
+	real := defineBuiltinClass("real", number)
+	defineBuiltinClass("complex", number)
+	rational := defineBuiltinClass("rational", real)
+	integer := defineBuiltinClass("integer", rational)
+	defineBuiltinClass("fixnum", integer)
+	defineBuiltinClass("bignum", integer)
+	defineBuiltinClass("ratio", rational)
+	defineBuiltinClass("decimal", real)
+	float := defineBuiltinClass("float", real)
+	defineBuiltinClass("single-float", float)
+	defineBuiltinClass("double-float", float)
+
+	sequence := defineBuiltinClass("sequence", tClass)
+	list := defineBuiltinClass("list", sequence)
+	defineBuiltinClass("cons", list)
+	defineBuiltinClass("string", sequence)
+
+	symbol := defineBuiltinClass("symbol", tClass)
+	defineBuiltinClass("keyword", symbol)
+	defineBuiltinClass("null", symbol, list)
+
+	function := defineBuiltinClass("function", tClass)
+	defineBuiltinClass("generic-function", function)
+	defineBuiltinClass("hash-table", tClass)
+	defineBuiltinClass("package", tClass)
+	defineBuiltinClass("stream", tClass)
+	defineBuiltinClass("random-state", tClass)
+	defineBuiltinClass("readtable", tClass)
+	defineBuiltinClass("pathname", tClass)
+	defineBuiltinClass("character", tClass)
+	defineBuiltinClass("class", standardObjectClass)
+
+	callNextMethodObj = newSymbol("call-next-method")
+	callNextMethodObj.value.(*Symbol).function = newBuiltinFunction(builtinNoNextMethod, 0, true)
+	nextMethodPObj = newSymbol("next-method-p")
+	nextMethodPObj.value.(*Symbol).function = newBuiltinFunction(builtinNoNextMethod, 0, true)
+
+	initializeInstanceObj = newSymbol("initialize-instance")
+	gf, _ := ensureGenericFunction(initializeInstanceObj, 1)
+	_ = gf.addMethod(&Method{
+		specializers: []specializer{{class: tClass}},
+		fn:           newBuiltinFunction(initializeInstanceDefault, 1, true),
+	})
+
+	printObjectObj = newSymbol("print-object")
+	gf, _ = ensureGenericFunction(printObjectObj, 2)
+	_ = gf.addMethod(&Method{
+		specializers: []specializer{{class: tClass}, {class: tClass}},
+		fn:           newBuiltinFunction(printObjectDefault, 2, false),
+	})
+
+	installSpecialForm("defclass", specialDefclass, 3, true)
+	installSpecialForm("defgeneric", specialDefgeneric, 2, true)
+	installSpecialForm("defmethod", specialDefmethod, 2, true)
+
+	installBuiltinFunction("make-instance", builtinMakeInstance, 1, true)
+	installBuiltinFunction("slot-value", builtinSlotValue, 2, false)
+	installBuiltinFunction("slot-boundp", builtinSlotBoundp, 2, false)
+	installBuiltinFunction("class-of", builtinClassOf, 1, false)
+	installBuiltinFunction("find-class", builtinFindClass, 1, true)
+	installBuiltinFunction("class-name", builtinClassName, 1, false)
+
+	installSetfFunction("slot-value", setfSlotValue)
+}
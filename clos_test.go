@@ -0,0 +1,166 @@
+package banglisp
+
+import (
+	"testing"
+)
+
+func TestObjectSystem(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "initarg and initform",
+			expr: `(defclass os-point () ((x :initarg :x) (y :initarg :y :initform 0)))
+(let ((p (make-instance 'os-point :x 1)))
+  (list (slot-value p 'x) (slot-value p 'y)))`,
+			want: "(1 0)",
+		},
+		{
+			name: "accessor and reader",
+			expr: `(defclass os-person () ((name :initarg :name :accessor os-name) (age :initform 20 :reader os-age)))
+(let ((p (make-instance 'os-person :name "taro")))
+  (setf (os-name p) "jiro")
+  (list (os-name p) (os-age p)))`,
+			want: `("jiro" 20)`,
+		},
+		{
+			name: "setf slot-value",
+			expr: `(defclass os-box () ((content)))
+(let ((b (make-instance 'os-box)))
+  (setf (slot-value b 'content) 'thing)
+  (slot-value b 'content))`,
+			want: "thing",
+		},
+		{
+			name: "unbound slot",
+			expr: `(defclass os-empty () ((content)))
+(slot-value (make-instance 'os-empty) 'content)`,
+			wantErr: true,
+		},
+		{
+			name: "invalid initarg",
+			expr: `(defclass os-strict () ((a :initarg :a)))
+(make-instance 'os-strict :b 1)`,
+			wantErr: true,
+		},
+		{
+			name: "inherited slots and initform override",
+			expr: `(defclass os-animal () ((sound :initform "..." :reader os-sound)))
+(defclass os-dog (os-animal) ((sound :initform "woof")))
+(os-sound (make-instance 'os-dog))`,
+			want: `"woof"`,
+		},
+		{
+			name: "C3 linearization",
+			expr: `(defclass os-a () ())
+(defclass os-b (os-a) ())
+(defclass os-c (os-a) ())
+(defclass os-d (os-b os-c) ())
+(defgeneric os-who (x))
+(defmethod os-who ((x os-a)) '(a))
+(defmethod os-who ((x os-b)) (cons 'b (call-next-method)))
+(defmethod os-who ((x os-c)) (cons 'c (call-next-method)))
+(defmethod os-who ((x os-d)) (cons 'd (call-next-method)))
+(os-who (make-instance 'os-d))`,
+			want: "(d b c a)",
+		},
+		{
+			name: "inconsistent precedence",
+			expr: `(defclass os-x () ())
+(defclass os-y (os-x) ())
+(defclass os-z (os-x os-y) ())`,
+			wantErr: true,
+		},
+		{
+			name: "multiple dispatch",
+			expr: `(defclass os-rock () ())
+(defclass os-paper () ())
+(defgeneric os-beats (a b))
+(defmethod os-beats ((a os-rock) (b os-paper)) nil)
+(defmethod os-beats ((a os-paper) (b os-rock)) t)
+(defmethod os-beats (a b) 'draw)
+(list (os-beats (make-instance 'os-paper) (make-instance 'os-rock))
+      (os-beats (make-instance 'os-rock) (make-instance 'os-rock)))`,
+			want: "(t draw)",
+		},
+		{
+			name: "eql specializer and built-in classes",
+			expr: `(defgeneric os-describe (x))
+(defmethod os-describe ((x (eql 0))) 'zero)
+(defmethod os-describe ((x integer)) 'integer)
+(defmethod os-describe ((x string)) 'string)
+(defmethod os-describe (x) 'other)
+(list (os-describe 0) (os-describe 1) (os-describe "s") (os-describe 'sym))`,
+			want: "(zero integer string other)",
+		},
+		{
+			name: "method combination",
+			expr: `(defclass os-base () ())
+(defclass os-derived (os-base) ())
+(setq os-log nil)
+(defgeneric os-run (x))
+(defmethod os-run ((x os-base)) (setq os-log (cons 'primary os-log)) 'result)
+(defmethod os-run :before ((x os-derived)) (setq os-log (cons 'before-derived os-log)))
+(defmethod os-run :before ((x os-base)) (setq os-log (cons 'before-base os-log)))
+(defmethod os-run :after ((x os-derived)) (setq os-log (cons 'after-derived os-log)))
+(defmethod os-run :after ((x os-base)) (setq os-log (cons 'after-base os-log)))
+(defmethod os-run :around ((x os-derived)) (list 'around (call-next-method)))
+(list (os-run (make-instance 'os-derived)) os-log)`,
+			want: "((around result) (after-derived after-base primary before-base before-derived))",
+		},
+		{
+			name: "next-method-p",
+			expr: `(defgeneric os-next (x))
+(defmethod os-next ((x fixnum)) (next-method-p))
+(os-next 1)`,
+			want: "nil",
+		},
+		{
+			name: "initialize-instance after method",
+			expr: `(defclass os-counter () ((n :accessor os-counter-n)))
+(defmethod initialize-instance :after ((c os-counter) &key (start 10))
+  (setf (os-counter-n c) start))
+(list (os-counter-n (make-instance 'os-counter)) (os-counter-n (make-instance 'os-counter :start 5)))`,
+			want: "(10 5)",
+		},
+		{
+			name: "class-of and type-of",
+			expr: `(defclass os-thing () ())
+(let ((x (make-instance 'os-thing)))
+  (list (class-name (class-of x)) (type-of x) (typep x 'os-thing) (typep x 'standard-object)))`,
+			want: "(os-thing os-thing t t)",
+		},
+		{
+			name: "print-object",
+			expr: `(defclass os-printable () ((name :initarg :name)))
+(defmethod print-object ((x os-printable) stream)
+  (write-string "#<printable " stream)
+  (write-string (slot-value x 'name) stream)
+  (write-string ">" stream))
+(make-instance 'os-printable :name "foo")`,
+			want: "#<printable foo>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("could not evaluate %s: %v", tt.expr, err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("%s => got: %v, expected %s", tt.expr, *got, tt.want)
+				return
+			}
+		})
+	}
+}
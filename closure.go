@@ -1,43 +1,325 @@
 package banglisp
 
+import "fmt"
+
+type lambdaParam struct {
+	name     *Object
+	init     *Object
+	supplied *Object
+	key      string
+}
+
+type lambdaList struct {
+	required       []*Object
+	optional       []*lambdaParam
+	rest           *Object
+	hasKeys        bool
+	keys           []*lambdaParam
+	allowOtherKeys bool
+	aux            []*lambdaParam
+}
+
 type Closure struct {
 	name   *Object
-	params []*Object
+	params *lambdaList
 	body   []*Object
 	env    *Environment
 }
 
-func newClosure(name *Object, params []*Object, body []*Object, env *Environment) *Object {
-	c := &Closure{
-		name:   name,
-		params: params,
-		body:   body,
-		env:    env,
+const (
+	lambdaRequired = iota
+	lambdaOptional
+	lambdaRest
+	lambdaKey
+	lambdaAux
+)
+
+func parseLambdaParam(obj *Object, state int) (*lambdaParam, error) {
+	if obj.kind == SymbolType {
+		if isConstant(obj) {
+			return nil, fmt.Errorf("invalid lambda list parameter: %v", *obj)
+		}
+
+		p := &lambdaParam{name: obj, init: nilObj}
+		if state == lambdaKey {
+			p.key = obj.value.(*Symbol).name.value.(string)
+		}
+		return p, nil
+	}
+
+	spec := noEvalArguments(obj)
+	if obj.kind != ConsCellType || len(spec) == 0 || len(spec) > 3 {
+		return nil, fmt.Errorf("invalid lambda list parameter: %v", *obj)
 	}
-	return newObject(ClosureType, c)
+
+	p := &lambdaParam{init: nilObj}
+	if state == lambdaKey && spec[0].kind == ConsCellType {
+		// ((:keyword var) init supplied-p)
+		pair := noEvalArguments(spec[0])
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("invalid keyword parameter: %v", *spec[0])
+		}
+
+		key, ok := keywordName(pair[0])
+		if !ok {
+			return nil, fmt.Errorf("invalid keyword parameter: %v", *spec[0])
+		}
+
+		p.key = key
+		p.name = pair[1]
+	} else {
+		p.name = spec[0]
+		if state == lambdaKey {
+			if sym, ok := spec[0].value.(*Symbol); ok {
+				p.key = sym.name.value.(string)
+			}
+		}
+	}
+
+	if p.name.kind != SymbolType || isConstant(p.name) {
+		return nil, fmt.Errorf("invalid lambda list parameter: %v", *obj)
+	}
+
+	if len(spec) >= 2 {
+		p.init = spec[1]
+	}
+
+	if len(spec) == 3 {
+		if state == lambdaAux {
+			return nil, fmt.Errorf("invalid &aux parameter: %v", *obj)
+		}
+		p.supplied = spec[2]
+	}
+
+	return p, nil
 }
 
-func (c *Closure) apply(env *Environment, actualArgs []*Object) (*Object, error) {
-	if len(c.params) != len(actualArgs) {
-		return nil, &ErrWrongNumberArguments{false, len(c.params), len(actualArgs)}
+func parseLambdaList(params []*Object) (*lambdaList, error) {
+	ll := &lambdaList{}
+	state := lambdaRequired
+	for i := 0; i < len(params); i++ {
+		param := params[i]
+		if sym, ok := param.value.(*Symbol); ok {
+			switch sym.name.value.(string) {
+			case "&optional":
+				if state >= lambdaOptional {
+					return nil, fmt.Errorf("misplaced &optional in lambda list")
+				}
+				state = lambdaOptional
+				continue
+			case "&rest", "&body":
+				if state >= lambdaRest || i+1 >= len(params) {
+					return nil, fmt.Errorf("misplaced &rest in lambda list")
+				}
+				i++
+				ll.rest = params[i]
+				state = lambdaRest
+				continue
+			case "&key":
+				if state >= lambdaKey {
+					return nil, fmt.Errorf("misplaced &key in lambda list")
+				}
+				ll.hasKeys = true
+				state = lambdaKey
+				continue
+			case "&allow-other-keys":
+				if state != lambdaKey {
+					return nil, fmt.Errorf("misplaced &allow-other-keys in lambda list")
+				}
+				ll.allowOtherKeys = true
+				continue
+			case "&aux":
+				if state >= lambdaAux {
+					return nil, fmt.Errorf("misplaced &aux in lambda list")
+				}
+				state = lambdaAux
+				continue
+			}
+		}
+
+		switch state {
+		case lambdaRequired:
+			if param.kind != SymbolType || isConstant(param) {
+				return nil, fmt.Errorf("invalid lambda list parameter: %v", *param)
+			}
+			ll.required = append(ll.required, param)
+		case lambdaRest:
+			return nil, fmt.Errorf("extra parameter after &rest: %v", *param)
+		default:
+			p, err := parseLambdaParam(param, state)
+			if err != nil {
+				return nil, err
+			}
+
+			switch state {
+			case lambdaOptional:
+				ll.optional = append(ll.optional, p)
+			case lambdaKey:
+				ll.keys = append(ll.keys, p)
+			case lambdaAux:
+				ll.aux = append(ll.aux, p)
+			}
+		}
 	}
 
-	frame := &Frame{}
-	for i := 0; i < len(c.params); i++ {
-		frame.addBinding(c.params[i], actualArgs[i])
+	return ll, nil
+}
+
+func (ll *lambdaList) isSimple() bool {
+	return len(ll.optional) == 0 && ll.rest == nil && !ll.hasKeys && len(ll.aux) == 0
+}
+
+// bind pushes a new frame holding the parameters to env. Default values are
+// evaluated from left to right so that they can refer to earlier parameters.
+// Caller must pop the frame if bind succeeds.
+func (ll *lambdaList) bind(env *Environment, args []*Object) error {
+	if ll.isSimple() && len(args) != len(ll.required) {
+		return &ErrWrongNumberArguments{variadic: false, expected: len(ll.required), got: len(args)}
 	}
 
+	if len(args) < len(ll.required) {
+		return &ErrWrongNumberArguments{variadic: true, expected: len(ll.required), got: len(args)}
+	}
+
+	maxArgs := len(ll.required) + len(ll.optional)
+	if ll.rest == nil && !ll.hasKeys && len(args) > maxArgs {
+		return &ErrWrongNumberArguments{variadic: false, expected: maxArgs, got: len(args)}
+	}
+
+	frame := &Frame{call: true}
 	env.pushFrame(frame)
-	defer env.popFrame(1)
 
-	ret := nilObj
-	var err error
-	for _, expr := range c.body {
-		ret, err = expr.Eval(env)
-		if err != nil {
-			return nil, err
+	if err := ll.bindFrame(env, frame, args); err != nil {
+		env.popFrame(1)
+		return err
+	}
+
+	return nil
+}
+
+func (ll *lambdaList) bindFrame(env *Environment, frame *Frame, args []*Object) error {
+	for i, name := range ll.required {
+		frame.addBinding(name, args[i])
+	}
+	args = args[len(ll.required):]
+
+	bindDefault := func(p *lambdaParam, value *Object, supplied bool) error {
+		if !supplied {
+			var err error
+			value, err = p.init.Eval(env)
+			if err != nil {
+				return err
+			}
+		}
+		frame.addBinding(p.name, value)
+
+		if p.supplied != nil {
+			flag := nilObj
+			if supplied {
+				flag = tObj
+			}
+			frame.addBinding(p.supplied, flag)
+		}
+
+		return nil
+	}
+
+	for _, p := range ll.optional {
+		if len(args) > 0 {
+			if err := bindDefault(p, args[0], true); err != nil {
+				return err
+			}
+			args = args[1:]
+		} else if err := bindDefault(p, nil, false); err != nil {
+			return err
+		}
+	}
+
+	if ll.rest != nil {
+		rest := emptyList
+		for i := len(args) - 1; i >= 0; i-- {
+			rest = cons(args[i], rest)
+		}
+		frame.addBinding(ll.rest, rest)
+	}
+
+	if ll.hasKeys {
+		if len(args)%2 != 0 {
+			return fmt.Errorf("odd number of keyword arguments")
+		}
+
+		allowOtherKeys := ll.allowOtherKeys
+		for i := 0; i < len(args); i += 2 {
+			if name, ok := keywordName(args[i]); ok && name == "allow-other-keys" && !isNull(args[i+1]) {
+				allowOtherKeys = true
+			}
+		}
+
+		if !allowOtherKeys {
+			for i := 0; i < len(args); i += 2 {
+				name, ok := keywordName(args[i])
+				if !ok {
+					return &ErrUnsupportedArgumentType{function: "lambda", argument: args[i]}
+				}
+
+				known := name == "allow-other-keys"
+				for _, p := range ll.keys {
+					if p.key == name {
+						known = true
+						break
+					}
+				}
+
+				if !known {
+					return fmt.Errorf("unknown keyword argument %v", *args[i])
+				}
+			}
+		}
+
+		for _, p := range ll.keys {
+			var value *Object
+			for i := 0; i < len(args); i += 2 {
+				if name, ok := keywordName(args[i]); ok && name == p.key {
+					value = args[i+1]
+					break
+				}
+			}
+
+			if err := bindDefault(p, value, value != nil); err != nil {
+				return err
+			}
 		}
 	}
 
-	return ret, nil
+	for _, p := range ll.aux {
+		if err := bindDefault(p, nil, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newClosure(name *Object, params []*Object, body []*Object, env *Environment) (*Object, error) {
+	ll, err := parseLambdaList(params)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Closure{
+		name:   name,
+		params: ll,
+		body:   body,
+		env:    env,
+	}
+	return newObject(ClosureType, c), nil
+}
+
+func (c *Closure) apply(env *Environment, actualArgs []*Object) (*Object, error) {
+	if err := c.params.bind(env, actualArgs); err != nil {
+		return nil, err
+	}
+	defer env.popFrame(1)
+
+	return evalBody(env, c.body)
 }
@@ -2,34 +2,96 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/syohex/banglisp"
 )
 
-func showPrompt(bw *bufio.Writer) {
-	p := banglisp.CurrentPackage()
-	prompt := fmt.Sprintf("%v> ", *p)
+// promptReader shows a prompt before reading each line from the terminal.
+// The prompt is indented while a form spans lines.
+type promptReader struct {
+	br        *bufio.Reader
+	bw        *bufio.Writer
+	continued bool
+	// pending is the rest of the line which did not fit in the buffer
+	pending string
+	eof     bool
+}
+
+func (p *promptReader) showPrompt() {
+	prompt := fmt.Sprintf("%v> ", *banglisp.CurrentPackage())
+	if p.continued {
+		prompt = strings.Repeat(" ", len(prompt))
+	}
 
-	if _, err := bw.WriteString(prompt); err != nil {
+	if _, err := p.bw.WriteString(prompt); err != nil {
 		fmt.Println(err)
 	}
 
-	if err := bw.Flush(); err != nil {
+	if err := p.bw.Flush(); err != nil {
 		fmt.Println(err)
 	}
 }
 
+func (p *promptReader) Read(b []byte) (int, error) {
+	if p.pending == "" {
+		if p.eof {
+			return 0, io.EOF
+		}
+
+		p.showPrompt()
+
+		line, err := p.br.ReadString('\n')
+		if err == io.EOF {
+			p.eof = true
+		} else if err != nil {
+			return 0, err
+		}
+
+		if line == "" {
+			return 0, io.EOF
+		}
+
+		if strings.TrimSpace(line) != "" {
+			p.continued = true
+		}
+		p.pending = line
+	}
+
+	n := copy(b, p.pending)
+	p.pending = p.pending[n:]
+	return n, nil
+}
+
 func runREPL() {
-	bw := bufio.NewWriter(os.Stdout)
+	pr := &promptReader{br: bufio.NewReader(os.Stdin), bw: bufio.NewWriter(os.Stdout)}
+	r := banglisp.NewReader(pr, nil)
 	for {
-		showPrompt(bw)
+		pr.continued = false
+		exp, err := r.ReadForm()
+		if err == io.EOF {
+			fmt.Println()
+			return
+		}
+
+		var eof *banglisp.ErrEndOfFile
+		if errors.As(err, &eof) {
+			fmt.Println()
+			fmt.Println(err)
+			return
+		}
 
-		exp, err := banglisp.Read(os.Stdin)
 		if err != nil {
 			fmt.Println(err)
-			os.Exit(1) // XXX
+			if err := r.DiscardLine(); err != nil {
+				fmt.Println(err)
+				return
+			}
+			continue
 		}
 
 		val, err := banglisp.Eval(exp)
@@ -0,0 +1,409 @@
+package banglisp
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Decimal is an arbitrary precision decimal number whose value is
+// unscaled * 10^-scale
+type Decimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+const (
+	defaultDecimalPrecision = 28
+)
+
+var decimalPrecisionObj *Object
+var decimalRoundingModeObj *Object
+
+var decimalRoundingModes = map[string]roundingMode{
+	"half-even": roundNearest,
+	"half-up":   roundHalfUp,
+	"half-down": roundHalfDown,
+	"floor":     roundFloor,
+	"ceiling":   roundCeiling,
+	"truncate":  roundTruncate,
+	"up":        roundUp,
+}
+
+func newDecimal(unscaled *big.Int, scale int) *Object {
+	return newObject(DecimalType, &Decimal{unscaled, scale})
+}
+
+var bigTen = big.NewInt(10)
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// numDigits returns the number of decimal digits of |v|
+func numDigits(v *big.Int) int {
+	if v.Sign() == 0 {
+		return 1
+	}
+
+	return len(new(big.Int).Abs(v).String())
+}
+
+// roundQuotient divides n by d and rounds the result to an integer
+func roundQuotient(n *big.Int, d *big.Int, mode roundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int)) // truncated
+	if r.Sign() == 0 {
+		return q
+	}
+
+	sign := int64(n.Sign() * d.Sign())
+	away := false
+	switch mode {
+	case roundFloor:
+		away = sign < 0
+	case roundCeiling:
+		away = sign > 0
+	case roundUp:
+		away = true
+	case roundTruncate:
+		away = false
+	default:
+		// compare remainder with the half of divisor
+		c := new(big.Int).Lsh(new(big.Int).Abs(r), 1).Cmp(new(big.Int).Abs(d))
+		switch mode {
+		case roundHalfUp:
+			away = c >= 0
+		case roundHalfDown:
+			away = c > 0
+		default:
+			away = c > 0 || (c == 0 && q.Bit(0) == 1)
+		}
+	}
+
+	if away {
+		q.Add(q, big.NewInt(sign))
+	}
+
+	return q
+}
+
+// decimalContext returns precision and rounding mode specified by special
+// variables
+func decimalContext() (int, roundingMode, error) {
+	precisionObj := specialValue(decimalPrecisionObj)
+	precision, ok := precisionObj.value.(int64)
+	if !ok || precision <= 0 {
+		return 0, 0, fmt.Errorf("*decimal-precision* must be positive fixnum: %v", *precisionObj)
+	}
+
+	modeObj := specialValue(decimalRoundingModeObj)
+	name, _ := keywordName(modeObj)
+	mode, ok := decimalRoundingModes[name]
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid *decimal-rounding-mode*: %v", *modeObj)
+	}
+
+	return int(precision), mode, nil
+}
+
+// roundToScale rounds d so that it has at most scale fractional digits
+func (d *Decimal) roundToScale(scale int, mode roundingMode) *Decimal {
+	if d.scale <= scale {
+		return d
+	}
+
+	unscaled := roundQuotient(d.unscaled, pow10(d.scale-scale), mode)
+	return &Decimal{unscaled, scale}
+}
+
+// roundToPrecision rounds d to precision significant digits
+func (d *Decimal) roundToPrecision(precision int, mode roundingMode) *Decimal {
+	digits := numDigits(d.unscaled)
+	if digits <= precision {
+		return d
+	}
+
+	ret := d.roundToScale(d.scale-(digits-precision), mode)
+	if numDigits(ret.unscaled) > precision {
+		// carry such as 999 -> 1000. Dropped digit is always zero
+		ret = &Decimal{new(big.Int).Quo(ret.unscaled, bigTen), ret.scale - 1}
+	}
+
+	return ret
+}
+
+// trimZeros removes trailing zeros of the fraction part not to go below
+// the minimum scale
+func (d *Decimal) trimZeros(minScale int) *Decimal {
+	if d.unscaled.Sign() == 0 && d.scale > minScale {
+		return &Decimal{new(big.Int), minScale}
+	}
+
+	unscaled := new(big.Int).Set(d.unscaled)
+	scale := d.scale
+	r := new(big.Int)
+	for scale > minScale {
+		q, m := new(big.Int).QuoRem(unscaled, bigTen, r)
+		if m.Sign() != 0 {
+			break
+		}
+		unscaled = q
+		scale--
+	}
+
+	return &Decimal{unscaled, scale}
+}
+
+func (d *Decimal) rat() *big.Rat {
+	if d.scale <= 0 {
+		return new(big.Rat).SetInt(new(big.Int).Mul(d.unscaled, pow10(-d.scale)))
+	}
+
+	return new(big.Rat).SetFrac(d.unscaled, pow10(d.scale))
+}
+
+func (d *Decimal) cmp(other *Decimal) int {
+	return d.rat().Cmp(other.rat())
+}
+
+// decimalFromRat rounds r to precision significant digits
+func decimalFromRat(r *big.Rat, precision int, mode roundingMode) *Decimal {
+	if r.IsInt() {
+		d := &Decimal{new(big.Int).Set(r.Num()), 0}
+		return d.roundToPrecision(precision, mode)
+	}
+
+	// find e such that 10^(e-1) <= |r| < 10^e
+	num := new(big.Int).Abs(r.Num())
+	den := r.Denom()
+	e := numDigits(num) - numDigits(den)
+	lhs, rhs := num, den
+	if e >= 0 {
+		rhs = new(big.Int).Mul(den, pow10(e))
+	} else {
+		lhs = new(big.Int).Mul(num, pow10(-e))
+	}
+	if lhs.Cmp(rhs) >= 0 {
+		e++
+	}
+
+	scale := precision - e
+	n := new(big.Int).Set(r.Num())
+	d := new(big.Int).Set(den)
+	if scale >= 0 {
+		n.Mul(n, pow10(scale))
+	} else {
+		d.Mul(d, pow10(-scale))
+	}
+
+	return (&Decimal{roundQuotient(n, d, mode), scale}).roundToPrecision(precision, mode)
+}
+
+func decimalValue(obj *Object, precision int, mode roundingMode) (*Decimal, bool) {
+	switch v := obj.value.(type) {
+	case *Decimal:
+		return v, true
+	case int64:
+		return &Decimal{big.NewInt(v), 0}, true
+	case *big.Int:
+		return &Decimal{v, 0}, true
+	case *big.Rat:
+		return decimalFromRat(v, precision, mode).trimZeros(0), true
+	default:
+		return nil, false
+	}
+}
+
+func decimalArithmetic(name string, op arithmeticOp, a *Object, b *Object) (*Object, error) {
+	precision, mode, err := decimalContext()
+	if err != nil {
+		return nil, err
+	}
+
+	x, _ := decimalValue(a, precision, mode)
+	y, _ := decimalValue(b, precision, mode)
+
+	// align scales for addition and subtraction
+	align := func() (*big.Int, *big.Int, int) {
+		xs, ys := x.unscaled, y.unscaled
+		scale := x.scale
+		if x.scale < y.scale {
+			xs = new(big.Int).Mul(xs, pow10(y.scale-x.scale))
+			scale = y.scale
+		} else if y.scale < x.scale {
+			ys = new(big.Int).Mul(ys, pow10(x.scale-y.scale))
+		}
+		return xs, ys, scale
+	}
+
+	var ret *Decimal
+	switch op {
+	case opAdd:
+		xs, ys, scale := align()
+		ret = &Decimal{new(big.Int).Add(xs, ys), scale}
+	case opSub:
+		xs, ys, scale := align()
+		ret = &Decimal{new(big.Int).Sub(xs, ys), scale}
+	case opMul:
+		ret = &Decimal{new(big.Int).Mul(x.unscaled, y.unscaled), x.scale + y.scale}
+	default:
+		if y.unscaled.Sign() == 0 {
+			return nil, &ErrDivisionByZero{function: name}
+		}
+
+		q := new(big.Rat).Quo(x.rat(), y.rat())
+		idealScale := x.scale - y.scale
+		return newObject(DecimalType, decimalFromRat(q, precision, mode).trimZeros(idealScale)), nil
+	}
+
+	return newObject(DecimalType, ret.roundToPrecision(precision, mode)), nil
+}
+
+// decimalPower computes base**power exactly for non negative power and
+// rounds the result
+func decimalPower(base *Object, power *big.Int) (*Object, error) {
+	if power.Sign() < 0 {
+		p, err := decimalPower(base, new(big.Int).Neg(power))
+		if err != nil {
+			return nil, err
+		}
+		return decimalArithmetic("expt", opDiv, newDecimal(big.NewInt(1), 0), p)
+	}
+
+	if !power.IsInt64() {
+		return nil, &ErrUnsupportedArgumentType{function: "expt", argument: newInteger(power)}
+	}
+
+	precision, mode, err := decimalContext()
+	if err != nil {
+		return nil, err
+	}
+
+	d := base.value.(*Decimal)
+	n := power.Int64()
+	ret := &Decimal{new(big.Int).Exp(d.unscaled, power, nil), d.scale * int(n)}
+	return newObject(DecimalType, ret.roundToPrecision(precision, mode)), nil
+}
+
+func (d *Decimal) String() string {
+	var sb strings.Builder
+	sb.WriteString("#m")
+	if d.unscaled.Sign() < 0 {
+		sb.WriteByte('-')
+	}
+
+	digits := new(big.Int).Abs(d.unscaled).String()
+	if d.scale <= 0 {
+		sb.WriteString(digits)
+		if d.unscaled.Sign() != 0 {
+			sb.WriteString(strings.Repeat("0", -d.scale))
+		}
+		return sb.String()
+	}
+
+	if len(digits) <= d.scale {
+		digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+	}
+
+	point := len(digits) - d.scale
+	sb.WriteString(digits[:point])
+	sb.WriteByte('.')
+	sb.WriteString(digits[point:])
+	return sb.String()
+}
+
+// parseDecimal parses [+-]digits[.digits]
+func parseDecimal(s string) (*Decimal, error) {
+	body := s
+	if strings.HasPrefix(body, "-") || strings.HasPrefix(body, "+") {
+		body = body[1:]
+	}
+
+	intPart := body
+	fracPart := ""
+	if i := strings.IndexByte(body, '.'); i >= 0 {
+		intPart = body[:i]
+		fracPart = body[i+1:]
+	}
+
+	if intPart == "" && fracPart == "" {
+		return nil, &ErrInvalidToken{token: s, reason: "invalid decimal syntax"}
+	}
+
+	for _, c := range intPart + fracPart {
+		if !isDigit(c) {
+			return nil, &ErrInvalidToken{token: s, reason: "invalid decimal syntax"}
+		}
+	}
+
+	unscaled, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok {
+		return nil, &ErrInvalidToken{token: s, reason: "invalid decimal syntax"}
+	}
+
+	if strings.HasPrefix(s, "-") {
+		unscaled.Neg(unscaled)
+	}
+
+	return &Decimal{unscaled, len(fracPart)}, nil
+}
+
+func builtinDecimal(_ *Environment, args []*Object) (*Object, error) {
+	// (decimal real &optional scale)
+	if len(args) > 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+	}
+
+	precision, mode, err := decimalContext()
+	if err != nil {
+		return nil, err
+	}
+
+	var d *Decimal
+	if f, isFloat, _ := floatValue(args[0]); isFloat {
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, &ErrUnsupportedArgumentType{function: "decimal", argument: args[0]}
+		}
+		d = decimalFromRat(new(big.Rat).SetFloat64(f), precision, mode).trimZeros(0)
+	} else if v, ok := decimalValue(args[0], precision, mode); ok {
+		d = v
+	} else {
+		return nil, &ErrUnsupportedArgumentType{function: "decimal", argument: args[0]}
+	}
+
+	if len(args) == 2 {
+		scale, ok := args[1].value.(int64)
+		if !ok {
+			return nil, &ErrUnsupportedArgumentType{function: "decimal", argument: args[1]}
+		}
+
+		d = d.roundToScale(int(scale), mode)
+	}
+
+	return newObject(DecimalType, d), nil
+}
+
+func builtinDecimalScale(_ *Environment, args []*Object) (*Object, error) {
+	// (decimal-scale decimal)
+	d, ok := args[0].value.(*Decimal)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "decimal-scale", argument: args[0]}
+	}
+
+	return newFixnum(int64(d.scale)), nil
+}
+
+func initDecimalFunctions() {
+	decimalPrecisionObj = newSymbol("*decimal-precision*")
+	decimalPrecisionObj.value.(*Symbol).value = newFixnum(defaultDecimalPrecision)
+
+	decimalRoundingModeObj = newSymbol("*decimal-rounding-mode*")
+	decimalRoundingModeObj.value.(*Symbol).value = newKeyword("half-even")
+
+	installBuiltinFunction("decimal", builtinDecimal, 1, true)
+	installBuiltinFunction("decimal-scale", builtinDecimalScale, 1, false)
+	installBuiltinFunction("decimalp", typePredicate(func(obj *Object) bool {
+		return obj.kind == DecimalType
+	}), 1, false)
+}
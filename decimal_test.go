@@ -0,0 +1,133 @@
+package banglisp
+
+import (
+	"testing"
+)
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "addition is exact",
+			expr: "(+ #m0.1 #m0.2)",
+			want: "#m0.3",
+		},
+		{
+			name: "scale of sum",
+			expr: "(list (+ #m1.5 #m2.25) (- #m10 #m0.01))",
+			want: "(#m3.75 #m9.99)",
+		},
+		{
+			name: "multiplication",
+			expr: "(* #m1.10 #m3)",
+			want: "#m3.30",
+		},
+		{
+			name: "integer contagion",
+			expr: "(list (+ #m1.5 1) (* 2 #m0.25))",
+			want: "(#m2.5 #m0.50)",
+		},
+		{
+			name: "float contagion",
+			expr: "(+ #m0.5 0.25)",
+			want: "7.5E-01",
+		},
+		{
+			name: "exact division",
+			expr: "(list (/ #m1 #m4) (/ #m10.00 4))",
+			want: "(#m0.25 #m2.50)",
+		},
+		{
+			name: "inexact division is rounded to precision",
+			expr: "(/ #m1 #m3)",
+			want: "#m0.3333333333333333333333333333",
+		},
+		{
+			name: "precision",
+			expr: "(let ((*decimal-precision* 5)) (list (/ #m2 #m3) (* #m123.45 #m10)))",
+			want: "(#m0.66667 #m1234.5)",
+		},
+		{
+			name: "rounding mode",
+			expr: `(let ((*decimal-precision* 2))
+  (list (/ #m5 #m4)
+        (let ((*decimal-rounding-mode* :half-up)) (/ #m5 #m4))
+        (let ((*decimal-rounding-mode* :floor)) (/ #m-2 #m3))
+        (let ((*decimal-rounding-mode* :truncate)) (/ #m-2 #m3))))`,
+			want: "(#m1.2 #m1.3 #m-0.67 #m-0.66)",
+		},
+		{
+			name: "comparison",
+			expr: "(list (= #m1.0 #m1.00) (= #m0.5 1/2) (< #m0.1 #m0.2 1) (> #m-1 -2))",
+			want: "(t t t t)",
+		},
+		{
+			name: "eql ignores trailing zeros",
+			expr: "(list (eql #m1.0 #m1.00) (eql #m1 1))",
+			want: "(t nil)",
+		},
+		{
+			name: "hash table key",
+			expr: "(let ((h (make-hash-table))) (setf (gethash #m1.50 h) 'found) (gethash #m1.5 h))",
+			want: "found",
+		},
+		{
+			name: "decimal conversion",
+			expr: "(list (decimal 1/4) (decimal 0.5) (decimal 1/3 2) (decimal #m2.675 2))",
+			want: "(#m0.25 #m0.5 #m0.33 #m2.68)",
+		},
+		{
+			name: "expt",
+			expr: "(list (expt #m1.1 2) (expt #m2 -2))",
+			want: "(#m1.21 #m0.25)",
+		},
+		{
+			name: "floor",
+			expr: "(multiple-value-list (floor #m7.5 2))",
+			want: "(3 #m1.5)",
+		},
+		{
+			name: "type",
+			expr: "(list (type-of #m1) (decimalp #m1) (typep #m1 'real) (decimal-scale #m1.230) (rational #m0.25))",
+			want: "(decimal t t 3 1/4)",
+		},
+		{
+			name: "negative and abs",
+			expr: "(list (- #m0.05) (abs #m-0.05) (signum #m-3))",
+			want: "(#m-0.05 #m0.05 #m-1)",
+		},
+		{
+			name:    "division by zero",
+			expr:    "(/ #m1 #m0.00)",
+			wantErr: true,
+		},
+		{
+			name:    "invalid rounding mode",
+			expr:    "(let ((*decimal-rounding-mode* :bogus)) (/ #m1 #m3))",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("could not evaluate %s: %v", tt.expr, err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("%s => got: %v, expected %s", tt.expr, *got, tt.want)
+				return
+			}
+		})
+	}
+}
@@ -7,14 +7,35 @@ type bindPair struct {
 
 type Frame struct {
 	bindings []bindPair
+	// functions holds local function bindings. call marks the frame of a
+	// function call, through which local functions of the caller are not
+	// visible.
+	functions []bindPair
+	call      bool
 }
 
 func (f *Frame) addBinding(name *Object, value *Object) {
 	f.bindings = append(f.bindings, bindPair{name, value})
 }
 
+func (f *Frame) addFunctionBinding(name *Object, fn *Object) {
+	f.functions = append(f.functions, bindPair{name, fn})
+}
+
+func (f *Frame) lookup(name *Object) (*Object, bool) {
+	for _, b := range f.bindings {
+		if objectEqual(name, b.name) {
+			return b.value, true
+		}
+	}
+
+	return nil, false
+}
+
 type Environment struct {
-	frames []*Frame
+	frames    []*Frame
+	values    []*Object
+	hasValues bool
 }
 
 func newEmptyEnvironment() *Environment {
@@ -43,12 +64,62 @@ func (e *Environment) lookupSymbol(obj *Object) (*Object, bool) {
 	return nil, false
 }
 
+// lookupFunction returns the local function named obj. Only frames up to
+// the innermost function call are searched since local functions are not
+// visible from called functions.
+func (e *Environment) lookupFunction(obj *Object) (*Object, bool) {
+	for _, f := range e.frames {
+		for _, b := range f.functions {
+			if objectEqual(obj, b.name) {
+				return b.value, true
+			}
+		}
+
+		if f.call {
+			break
+		}
+	}
+
+	return nil, false
+}
+
 func (e *Environment) updateValue(variable *Object, value *Object) {
 	for _, f := range e.frames {
-		for _, b := range f.bindings {
-			if objectEqual(variable, b.name) {
-				b.value = value
+		for i := range f.bindings {
+			if objectEqual(variable, f.bindings[i].name) {
+				f.bindings[i].value = value
+				return
 			}
 		}
 	}
 }
+
+func (e *Environment) setValues(values []*Object) *Object {
+	e.values = values
+	e.hasValues = true
+
+	if len(values) == 0 {
+		return nilObj
+	}
+
+	return values[0]
+}
+
+func (e *Environment) clearValues() {
+	e.values = nil
+	e.hasValues = false
+}
+
+func (e *Environment) multipleValues(primary *Object) []*Object {
+	if e.hasValues {
+		if len(e.values) == 0 && isNull(primary) {
+			return e.values
+		}
+
+		if len(e.values) != 0 && e.values[0] == primary {
+			return e.values
+		}
+	}
+
+	return []*Object{primary}
+}
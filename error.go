@@ -2,15 +2,37 @@ package banglisp
 
 import "fmt"
 
+// errorLocation is embedded in typed errors to hold the source location of
+// the form which caused the error
+type errorLocation struct {
+	location *sourceLocation
+}
+
+func (e *errorLocation) setLocation(loc *sourceLocation) {
+	if e.location == nil {
+		e.location = loc
+	}
+}
+
+func (e *errorLocation) withLocation(msg string) string {
+	if e.location == nil {
+		return msg
+	}
+
+	return fmt.Sprintf("%v: %s", e.location, msg)
+}
+
 type ErrUnboundVariable struct {
+	errorLocation
 	name string
 }
 
 func (e ErrUnboundVariable) Error() string {
-	return fmt.Sprintf("unbound variable: %s", e.name)
+	return e.withLocation(fmt.Sprintf("unbound variable: %s", e.name))
 }
 
 type ErrWrongNumberArguments struct {
+	errorLocation
 	variadic bool
 	expected int
 	got      int
@@ -18,17 +40,122 @@ type ErrWrongNumberArguments struct {
 
 func (e ErrWrongNumberArguments) Error() string {
 	if e.variadic {
-		return fmt.Sprintf("expected more than %d arguments, but got %d arguments", e.expected, e.got)
+		return e.withLocation(fmt.Sprintf("expected more than %d arguments, but got %d arguments", e.expected, e.got))
 	} else {
-		return fmt.Sprintf("expected %d arguments, but got %d arguments", e.expected, e.got)
+		return e.withLocation(fmt.Sprintf("expected %d arguments, but got %d arguments", e.expected, e.got))
 	}
 }
 
 type ErrUnsupportedArgumentType struct {
+	errorLocation
 	function string
 	argument *Object
 }
 
 func (e ErrUnsupportedArgumentType) Error() string {
-	return fmt.Sprintf("%s does not accept %v", e.function, *e.argument)
+	return e.withLocation(fmt.Sprintf("%s does not accept %v", e.function, *e.argument))
+}
+
+type ErrDivisionByZero struct {
+	errorLocation
+	function string
+}
+
+func (e ErrDivisionByZero) Error() string {
+	return e.withLocation(fmt.Sprintf("%s: division by zero", e.function))
+}
+
+type ErrFloatingPointOverflow struct {
+	errorLocation
+	function string
+}
+
+func (e ErrFloatingPointOverflow) Error() string {
+	return e.withLocation(fmt.Sprintf("%s: floating point overflow", e.function))
+}
+
+type ErrFloatingPointInvalidOperation struct {
+	errorLocation
+	function string
+}
+
+func (e ErrFloatingPointInvalidOperation) Error() string {
+	return e.withLocation(fmt.Sprintf("%s: floating point invalid operation", e.function))
+}
+
+// ErrEndOfFile is returned when input ends in the middle of an object, or
+// when a stream function reaches the end of input with eof-error-p. The
+// REPL reads more input on this error.
+type ErrEndOfFile struct {
+	errorLocation
+	context string
+}
+
+func (e ErrEndOfFile) Error() string {
+	return e.withLocation(fmt.Sprintf("end of file in %s", e.context))
+}
+
+type ErrUnbalancedParen struct {
+	errorLocation
+}
+
+func (e ErrUnbalancedParen) Error() string {
+	return e.withLocation("unbalanced close parenthesis")
+}
+
+// ErrInvalidToken is returned for a token which is neither a valid number
+// nor a valid symbol
+type ErrInvalidToken struct {
+	errorLocation
+	token  string
+	reason string
+}
+
+func (e ErrInvalidToken) Error() string {
+	return e.withLocation(fmt.Sprintf("%s: %s", e.reason, e.token))
+}
+
+// ErrInvalidSyntax is returned for malformed syntax of reader macros. text
+// is the offending text if any.
+type ErrInvalidSyntax struct {
+	errorLocation
+	message string
+	text    string
+}
+
+func (e ErrInvalidSyntax) Error() string {
+	if e.text == "" {
+		return e.withLocation(e.message)
+	}
+
+	return e.withLocation(fmt.Sprintf("%s: %s", e.message, e.text))
+}
+
+// ErrFormat is returned for malformed format control strings and for
+// directives which cannot consume their arguments. index is the position
+// of the offending directive in control.
+type ErrFormat struct {
+	errorLocation
+	control string
+	index   int
+	message string
+}
+
+func (e ErrFormat) Error() string {
+	return e.withLocation(fmt.Sprintf("format: %s at index %d of %q", e.message, e.index, e.control))
+}
+
+// ErrAtLocation wraps an error with the source location of the form which
+// caused it
+type ErrAtLocation struct {
+	location *sourceLocation
+	err      error
+}
+
+func (e ErrAtLocation) Error() string {
+	return fmt.Sprintf("%v: %v", e.location, e.err)
+}
+
+func (e ErrAtLocation) Unwrap() error {
+	return e.err
 }
@@ -0,0 +1,210 @@
+package banglisp
+
+import (
+	"math"
+	"math/big"
+)
+
+var floatTrapsObj *Object
+
+// specialValue returns the current value of special variable sym. Bindings
+// established by let take precedence over the global value.
+func specialValue(sym *Object) *Object {
+	if v, ok := defaultEnvironment.lookupSymbol(sym); ok {
+		return v
+	}
+
+	return sym.value.(*Symbol).value
+}
+
+// floatTrapEnabled reports whether trap is a member of *float-traps*.
+// Disabled traps produce IEEE infinities and NaN instead of errors.
+func floatTrapEnabled(trap string) bool {
+	for next := specialValue(floatTrapsObj); next.kind == ConsCellType && !isEmptyList(next); {
+		cell := next.value.(*ConsCell)
+		if name, ok := keywordName(cell.car); ok && name == trap {
+			return true
+		}
+		next = cell.cdr
+	}
+
+	return false
+}
+
+func checkFloatResult(name string, v float64, operands ...float64) error {
+	for _, operand := range operands {
+		if math.IsInf(operand, 0) || math.IsNaN(operand) {
+			return nil
+		}
+	}
+
+	if math.IsInf(v, 0) && floatTrapEnabled("overflow") {
+		return &ErrFloatingPointOverflow{function: name}
+	}
+
+	if math.IsNaN(v) && floatTrapEnabled("invalid") {
+		return &ErrFloatingPointInvalidOperation{function: name}
+	}
+
+	return nil
+}
+
+func floatArgument(function string, obj *Object) (float64, error) {
+	f, isFloat, _ := floatValue(obj)
+	if !isFloat {
+		return 0, &ErrUnsupportedArgumentType{function: function, argument: obj}
+	}
+
+	return f, nil
+}
+
+// finiteFloatArgument accepts floats other than infinity and NaN
+func finiteFloatArgument(function string, obj *Object) (float64, error) {
+	f, err := floatArgument(function, obj)
+	if err != nil {
+		return 0, err
+	}
+
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return 0, &ErrUnsupportedArgumentType{function: function, argument: obj}
+	}
+
+	return f, nil
+}
+
+func floatDigits(obj *Object) int {
+	if obj.kind == SingleFloatType {
+		return 24
+	}
+
+	return 53
+}
+
+func builtinFloat(_ *Environment, args []*Object) (*Object, error) {
+	// (float number &optional prototype)
+	if len(args) > 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+	}
+
+	if !isReal(args[0]) {
+		return nil, &ErrUnsupportedArgumentType{function: "float", argument: args[0]}
+	}
+
+	kind := FloatType
+	if len(args) == 2 {
+		if !isFloat(args[1]) {
+			return nil, &ErrUnsupportedArgumentType{function: "float", argument: args[1]}
+		}
+		kind = args[1].kind
+	} else if isFloat(args[0]) {
+		return args[0], nil
+	}
+
+	v, _, _ := floatValue(args[0])
+	return floatResult("float", kind, v)
+}
+
+func builtinDecodeFloat(env *Environment, args []*Object) (*Object, error) {
+	// (decode-float float)
+	f, err := finiteFloatArgument("decode-float", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	kind := args[0].kind
+	frac, exp := math.Frexp(math.Abs(f))
+	sign := math.Copysign(1, f)
+
+	significand, _ := floatResult("decode-float", kind, frac)
+	signObj, _ := floatResult("decode-float", kind, sign)
+	return env.setValues([]*Object{significand, newFixnum(int64(exp)), signObj}), nil
+}
+
+func builtinIntegerDecodeFloat(env *Environment, args []*Object) (*Object, error) {
+	// (integer-decode-float float)
+	f, err := finiteFloatArgument("integer-decode-float", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	sign := int64(1)
+	if math.Signbit(f) {
+		sign = -1
+	}
+
+	if f == 0 {
+		return env.setValues([]*Object{newFixnum(0), newFixnum(0), newFixnum(sign)}), nil
+	}
+
+	digits := floatDigits(args[0])
+	frac, exp := math.Frexp(math.Abs(f))
+	mantissa, _ := new(big.Float).SetMantExp(big.NewFloat(frac), digits).Int(nil)
+	return env.setValues([]*Object{newInteger(mantissa), newFixnum(int64(exp - digits)), newFixnum(sign)}), nil
+}
+
+func builtinFloatSign(_ *Environment, args []*Object) (*Object, error) {
+	// (float-sign float1 &optional float2)
+	if len(args) > 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+	}
+
+	f, err := floatArgument("float-sign", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	magnitude := 1.0
+	kind := args[0].kind
+	if len(args) == 2 {
+		magnitude, err = floatArgument("float-sign", args[1])
+		if err != nil {
+			return nil, err
+		}
+		kind = args[1].kind
+	}
+
+	return floatResult("float-sign", kind, math.Copysign(magnitude, f))
+}
+
+func builtinFloatDigits(_ *Environment, args []*Object) (*Object, error) {
+	// (float-digits float)
+	if !isFloat(args[0]) {
+		return nil, &ErrUnsupportedArgumentType{function: "float-digits", argument: args[0]}
+	}
+
+	return newFixnum(int64(floatDigits(args[0]))), nil
+}
+
+func builtinScaleFloat(_ *Environment, args []*Object) (*Object, error) {
+	// (scale-float float integer)
+	f, err := floatArgument("scale-float", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	k, ok := args[1].value.(int64)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "scale-float", argument: args[1]}
+	}
+
+	if k > math.MaxInt32 {
+		k = math.MaxInt32
+	} else if k < math.MinInt32 {
+		k = math.MinInt32
+	}
+
+	return floatResult("scale-float", args[0].kind, math.Ldexp(f, int(k)), f)
+}
+
+func initFloatFunctions() {
+	floatTrapsObj = newSymbol("*float-traps*")
+	floatTrapsObj.value.(*Symbol).value = cons(newKeyword("overflow"),
+		cons(newKeyword("invalid"), cons(newKeyword("division-by-zero"), emptyList)))
+
+	installBuiltinFunction("float", builtinFloat, 1, true)
+	installBuiltinFunction("decode-float", builtinDecodeFloat, 1, false)
+	installBuiltinFunction("integer-decode-float", builtinIntegerDecodeFloat, 1, false)
+	installBuiltinFunction("float-sign", builtinFloatSign, 1, true)
+	installBuiltinFunction("float-digits", builtinFloatDigits, 1, false)
+	installBuiltinFunction("scale-float", builtinScaleFloat, 2, false)
+}
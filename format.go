@@ -0,0 +1,1382 @@
+package banglisp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type formatParamKind int
+
+const (
+	paramNone formatParamKind = iota
+	paramValue
+	// paramArgument is V which takes the parameter from the arguments
+	paramArgument
+	// paramCount is # which is the number of remaining arguments
+	paramCount
+)
+
+type formatParam struct {
+	kind  formatParamKind
+	value *Object
+}
+
+// formatDirective is a directive of a control string, or literal text if
+// char is 0. index is the position of the directive for error messages.
+type formatDirective struct {
+	char   rune
+	text   string
+	index  int
+	params []formatParam
+	colon  bool
+	at     bool
+
+	// body of ~{ and ~(
+	body []*formatDirective
+	// clauses of ~[. If hasDefault is true, the last clause is the default
+	// clause which follows ~:;
+	clauses    [][]*formatDirective
+	hasDefault bool
+	// atLeastOnce is true if ~{ is closed by ~:}
+	atLeastOnce bool
+}
+
+// formatParamLimits is the number of parameters which each directive accepts
+var formatParamLimits = map[rune]int{
+	'A': 4, 'S': 4, 'D': 4, 'B': 4, 'O': 4, 'X': 4, 'R': 5, 'F': 5, 'E': 7, '$': 4,
+	'%': 1, '&': 1, '~': 1, '*': 1, '{': 1, '[': 1, '^': 1,
+	'?': 0, '(': 0, ')': 0, ']': 0, '}': 0, ';': 0, '\n': 0,
+}
+
+type formatParser struct {
+	control string
+	runes   []rune
+	pos     int
+}
+
+func (p *formatParser) errorf(index int, format string, a ...interface{}) error {
+	return &ErrFormat{control: p.control, index: index, message: fmt.Sprintf(format, a...)}
+}
+
+func (p *formatParser) peek() rune {
+	if p.pos >= len(p.runes) {
+		return 0
+	}
+
+	return p.runes[p.pos]
+}
+
+// parseControl parses control string into directives
+func parseControl(control string) ([]*formatDirective, error) {
+	p := &formatParser{control: control, runes: []rune(control)}
+	ds, _, err := p.parse("", nil)
+	return ds, err
+}
+
+// parse parses directives until one of closers. It returns the closing
+// directive too. open is the directive which the closer closes.
+func (p *formatParser) parse(closers string, open *formatDirective) ([]*formatDirective, *formatDirective, error) {
+	var ds []*formatDirective
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			ds = append(ds, &formatDirective{text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for p.pos < len(p.runes) {
+		c := p.runes[p.pos]
+		if c != '~' {
+			text.WriteRune(c)
+			p.pos++
+			continue
+		}
+
+		d, err := p.parseDirective()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch d.char {
+		case '\n':
+			// ~newline ignores the newline and the following whitespace.
+			// ~@newline keeps the newline and ~:newline keeps the whitespace.
+			if d.at {
+				text.WriteByte('\n')
+			}
+
+			if !d.colon {
+				for c := p.peek(); c == ' ' || c == '\t'; c = p.peek() {
+					p.pos++
+				}
+			}
+			continue
+		case '}', ')', ']', ';':
+			if !strings.ContainsRune(closers, d.char) {
+				return nil, nil, p.errorf(d.index, "unmatched ~%c", d.char)
+			}
+
+			flush()
+			return ds, d, nil
+		}
+
+		flush()
+		if err := p.parseBody(d); err != nil {
+			return nil, nil, err
+		}
+		ds = append(ds, d)
+	}
+
+	if open != nil {
+		return nil, nil, p.errorf(open.index, "~%c is not closed", open.char)
+	}
+
+	flush()
+	return ds, nil, nil
+}
+
+// parseBody parses the directives enclosed by ~{, ~[ and ~(
+func (p *formatParser) parseBody(d *formatDirective) error {
+	switch d.char {
+	case '{':
+		body, closer, err := p.parse("}", d)
+		if err != nil {
+			return err
+		}
+
+		d.body = body
+		d.atLeastOnce = closer.colon
+	case '(':
+		body, _, err := p.parse(")", d)
+		if err != nil {
+			return err
+		}
+
+		d.body = body
+	case '[':
+		for {
+			clause, closer, err := p.parse("];", d)
+			if err != nil {
+				return err
+			}
+
+			d.clauses = append(d.clauses, clause)
+			if closer.char == ']' {
+				break
+			}
+
+			if d.hasDefault {
+				return p.errorf(closer.index, "~:; must precede the last clause")
+			}
+			d.hasDefault = closer.colon
+		}
+
+		switch {
+		case d.colon && d.at:
+			return p.errorf(d.index, "~:@[ is invalid")
+		case d.colon && len(d.clauses) != 2:
+			return p.errorf(d.index, "~:[ needs two clauses")
+		case d.at && len(d.clauses) != 1:
+			return p.errorf(d.index, "~@[ needs one clause")
+		}
+	}
+
+	return nil
+}
+
+func (p *formatParser) parseDirective() (*formatDirective, error) {
+	d := &formatDirective{index: p.pos}
+	p.pos++
+
+	for {
+		param, err := p.parseParam(d.index)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.peek() != ',' {
+			if param.kind != paramNone {
+				d.params = append(d.params, param)
+			}
+			break
+		}
+
+		d.params = append(d.params, param)
+		p.pos++
+	}
+
+	for c := p.peek(); c == ':' || c == '@'; c = p.peek() {
+		if (c == ':' && d.colon) || (c == '@' && d.at) {
+			return nil, p.errorf(d.index, "duplicate modifier %c", c)
+		}
+
+		d.colon = d.colon || c == ':'
+		d.at = d.at || c == '@'
+		p.pos++
+	}
+
+	if p.pos >= len(p.runes) {
+		return nil, p.errorf(d.index, "missing directive character")
+	}
+
+	d.char = unicode.ToUpper(p.runes[p.pos])
+	p.pos++
+
+	limit, ok := formatParamLimits[d.char]
+	if !ok {
+		return nil, p.errorf(d.index, "unknown directive ~%c", p.runes[p.pos-1])
+	}
+
+	if len(d.params) > limit {
+		return nil, p.errorf(d.index, "too many parameters for ~%c", d.char)
+	}
+
+	return d, nil
+}
+
+// parseParam parses a prefix parameter which is an integer, 'c, V or #
+func (p *formatParser) parseParam(index int) (formatParam, error) {
+	c := p.peek()
+	switch {
+	case c == '\'':
+		if p.pos+1 >= len(p.runes) {
+			return formatParam{}, p.errorf(index, "missing character parameter")
+		}
+
+		p.pos += 2
+		return formatParam{kind: paramValue, value: newCharacter(p.runes[p.pos-1])}, nil
+	case c == 'v' || c == 'V':
+		p.pos++
+		return formatParam{kind: paramArgument}, nil
+	case c == '#':
+		p.pos++
+		return formatParam{kind: paramCount}, nil
+	case c == '+' || c == '-' || unicode.IsDigit(c):
+		start := p.pos
+		p.pos++
+		for unicode.IsDigit(p.peek()) {
+			p.pos++
+		}
+
+		n, err := strconv.ParseInt(string(p.runes[start:p.pos]), 10, 64)
+		if err != nil {
+			return formatParam{}, p.errorf(index, "invalid parameter %s", string(p.runes[start:p.pos]))
+		}
+
+		return formatParam{kind: paramValue, value: newFixnum(n)}, nil
+	}
+
+	return formatParam{}, nil
+}
+
+// errFormatEscape is returned by ~^ to escape from the enclosing ~{ or
+// from the whole format
+var errFormatEscape = errors.New("format escape")
+
+type formatArgs struct {
+	list []*Object
+	pos  int
+}
+
+func (a *formatArgs) remaining() int {
+	return len(a.list) - a.pos
+}
+
+// formatter runs directives. Formatters for ~? and ~{ with control string
+// arguments share sb with their parent.
+type formatter struct {
+	control string
+	sb      *strings.Builder
+	// fresh is true if the output starts at the beginning of line
+	fresh bool
+}
+
+func (f *formatter) errorf(d *formatDirective, format string, a ...interface{}) error {
+	return &ErrFormat{control: f.control, index: d.index, message: fmt.Sprintf(format, a...)}
+}
+
+func (f *formatter) atLineStart() bool {
+	s := f.sb.String()
+	if s == "" {
+		return f.fresh
+	}
+
+	return s[len(s)-1] == '\n'
+}
+
+func (f *formatter) next(d *formatDirective, args *formatArgs) (*Object, error) {
+	if args.remaining() <= 0 {
+		return nil, f.errorf(d, "no more arguments for ~%c", d.char)
+	}
+
+	args.pos++
+	return args.list[args.pos-1], nil
+}
+
+// listArguments returns the elements of list argument
+func (f *formatter) listArguments(d *formatDirective, list *Object) (*formatArgs, error) {
+	n, proper, err := listLength("format", list)
+	if err != nil || !proper {
+		return nil, f.errorf(d, "~%c requires proper list but got %v", d.char, *list)
+	}
+
+	ret := &formatArgs{}
+	for i := int64(0); i < n; i++ {
+		cell := list.value.(*ConsCell)
+		ret.list = append(ret.list, cell.car)
+		list = cell.cdr
+	}
+
+	return ret, nil
+}
+
+// params resolves the parameters of d. Missing parameters are nil.
+func (f *formatter) params(d *formatDirective, args *formatArgs) ([]*Object, error) {
+	var ret []*Object
+	for _, param := range d.params {
+		switch param.kind {
+		case paramNone:
+			ret = append(ret, nil)
+		case paramValue:
+			ret = append(ret, param.value)
+		case paramArgument:
+			v, err := f.next(d, args)
+			if err != nil {
+				return nil, err
+			}
+
+			if isNull(v) {
+				v = nil
+			}
+			ret = append(ret, v)
+		case paramCount:
+			ret = append(ret, newFixnum(int64(args.remaining())))
+		}
+	}
+
+	return ret, nil
+}
+
+func (f *formatter) intParam(d *formatDirective, params []*Object, i int, def int) (int, error) {
+	if i >= len(params) || params[i] == nil {
+		return def, nil
+	}
+
+	v, ok := params[i].value.(int64)
+	if !ok {
+		return 0, f.errorf(d, "parameter %d of ~%c must be integer", i+1, d.char)
+	}
+
+	return int(v), nil
+}
+
+func (f *formatter) charParam(d *formatDirective, params []*Object, i int, def rune) (rune, error) {
+	if i >= len(params) || params[i] == nil {
+		return def, nil
+	}
+
+	v, ok := params[i].value.(rune)
+	if !ok {
+		return 0, f.errorf(d, "parameter %d of ~%c must be character", i+1, d.char)
+	}
+
+	return v, nil
+}
+
+// padString pads s to mincol columns. The padding is minpad characters and
+// then colinc characters at a time.
+func padString(s string, mincol, colinc, minpad int, padchar rune, left bool) string {
+	padding := minpad
+	for n := utf8.RuneCountInString(s) + minpad; n < mincol; n += colinc {
+		padding += colinc
+	}
+
+	fill := strings.Repeat(string(padchar), padding)
+	if left {
+		return fill + s
+	}
+
+	return s + fill
+}
+
+// decimalString prints obj as ~A does in decimal base. Integer and float
+// directives print their non-numeric arguments with it.
+func decimalString(obj *Object) (string, error) {
+	p := newPrinter()
+	p.escape = false
+	p.readably = false
+	p.base = 10
+	p.radix = false
+	return p.format(obj)
+}
+
+func (f *formatter) run(ds []*formatDirective, args *formatArgs) error {
+	for _, d := range ds {
+		if d.char == 0 {
+			f.sb.WriteString(d.text)
+			continue
+		}
+
+		params, err := f.params(d, args)
+		if err != nil {
+			return err
+		}
+
+		if err := f.runDirective(d, params, args); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *formatter) runDirective(d *formatDirective, params []*Object, args *formatArgs) error {
+	switch d.char {
+	case 'A', 'S':
+		return f.formatObject(d, params, args)
+	case 'D', 'B', 'O', 'X':
+		radix := map[rune]int{'D': 10, 'B': 2, 'O': 8, 'X': 16}[d.char]
+		arg, err := f.next(d, args)
+		if err != nil {
+			return err
+		}
+
+		s, err := f.formatInteger(d, arg, radix, params)
+		if err != nil {
+			return err
+		}
+		f.sb.WriteString(s)
+	case 'R':
+		return f.formatRadix(d, params, args)
+	case 'F':
+		return f.formatFixed(d, params, args)
+	case 'E':
+		return f.formatExponential(d, params, args)
+	case '$':
+		return f.formatMonetary(d, params, args)
+	case '%', '~':
+		n, err := f.intParam(d, params, 0, 1)
+		if err != nil {
+			return err
+		}
+
+		s := "\n"
+		if d.char == '~' {
+			s = "~"
+		}
+		for i := 0; i < n; i++ {
+			f.sb.WriteString(s)
+		}
+	case '&':
+		n, err := f.intParam(d, params, 0, 1)
+		if err != nil {
+			return err
+		}
+
+		if n > 0 && !f.atLineStart() {
+			f.sb.WriteByte('\n')
+		}
+		for i := 1; i < n; i++ {
+			f.sb.WriteByte('\n')
+		}
+	case '*':
+		return f.formatGoto(d, params, args)
+	case '?':
+		return f.formatIndirect(d, args)
+	case '{':
+		return f.formatIteration(d, params, args)
+	case '[':
+		return f.formatConditional(d, params, args)
+	case '(':
+		return f.formatCase(d, args)
+	case '^':
+		if d.colon {
+			return f.errorf(d, "~:^ is not supported")
+		}
+
+		if len(params) > 0 && params[0] != nil {
+			n, err := f.intParam(d, params, 0, 0)
+			if err != nil {
+				return err
+			}
+
+			if n == 0 {
+				return errFormatEscape
+			}
+			return nil
+		}
+
+		if args.remaining() == 0 {
+			return errFormatEscape
+		}
+	}
+
+	return nil
+}
+
+// formatObject runs ~mincol,colinc,minpad,padcharA and ~S
+func (f *formatter) formatObject(d *formatDirective, params []*Object, args *formatArgs) error {
+	arg, err := f.next(d, args)
+	if err != nil {
+		return err
+	}
+
+	var s string
+	switch {
+	case d.colon && isNull(arg):
+		s = "()"
+	case d.char == 'A':
+		s, err = princString(arg)
+	default:
+		s, err = prin1String(arg)
+	}
+	if err != nil {
+		return err
+	}
+
+	mincol, err := f.intParam(d, params, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	colinc, err := f.intParam(d, params, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	minpad, err := f.intParam(d, params, 2, 0)
+	if err != nil {
+		return err
+	}
+
+	padchar, err := f.charParam(d, params, 3, ' ')
+	if err != nil {
+		return err
+	}
+
+	if colinc < 1 || minpad < 0 {
+		return f.errorf(d, "invalid padding parameters")
+	}
+
+	f.sb.WriteString(padString(s, mincol, colinc, minpad, padchar, d.at))
+	return nil
+}
+
+// formatInteger prints arg for ~mincol,padchar,commachar,comma-intervalD.
+// ~@D prints the sign of positive integers and ~:D groups digits.
+func (f *formatter) formatInteger(d *formatDirective, arg *Object, radix int, params []*Object) (string, error) {
+	mincol, err := f.intParam(d, params, 0, 0)
+	if err != nil {
+		return "", err
+	}
+
+	padchar, err := f.charParam(d, params, 1, ' ')
+	if err != nil {
+		return "", err
+	}
+
+	commachar, err := f.charParam(d, params, 2, ',')
+	if err != nil {
+		return "", err
+	}
+
+	interval, err := f.intParam(d, params, 3, 3)
+	if err != nil {
+		return "", err
+	}
+
+	if interval < 1 {
+		return "", f.errorf(d, "comma interval must be positive")
+	}
+
+	v, ok := bigIntValue(arg)
+	if !ok {
+		s, err := decimalString(arg)
+		if err != nil {
+			return "", err
+		}
+		return padString(s, mincol, 1, 0, padchar, true), nil
+	}
+
+	digits := []rune(strings.ToUpper(v.Text(radix)))
+	sign := ""
+	if v.Sign() < 0 {
+		sign = "-"
+		digits = digits[1:]
+	} else if d.at {
+		sign = "+"
+	}
+
+	if d.colon {
+		var grouped []rune
+		for i, c := range digits {
+			if i > 0 && (len(digits)-i)%interval == 0 {
+				grouped = append(grouped, commachar)
+			}
+			grouped = append(grouped, c)
+		}
+		digits = grouped
+	}
+
+	return padString(sign+string(digits), mincol, 1, 0, padchar, true), nil
+}
+
+var englishOnes = []string{
+	"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten",
+	"eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen",
+}
+
+var englishTens = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+
+var englishScales = []string{"", "thousand", "million", "billion", "trillion", "quadrillion", "quintillion"}
+
+func englishBelowThousand(n uint64) string {
+	var words []string
+	if n >= 100 {
+		words = append(words, englishOnes[n/100]+" hundred")
+		n %= 100
+	}
+
+	if n >= 20 {
+		w := englishTens[n/10]
+		if n%10 != 0 {
+			w += "-" + englishOnes[n%10]
+		}
+		words = append(words, w)
+	} else if n > 0 {
+		words = append(words, englishOnes[n])
+	}
+
+	return strings.Join(words, " ")
+}
+
+// englishCardinal spells n in English such as "one hundred twenty-three"
+func englishCardinal(n int64) string {
+	if n == 0 {
+		return "zero"
+	}
+
+	u := uint64(n)
+	if n < 0 {
+		u = uint64(-(n + 1)) + 1
+	}
+
+	var groups []string
+	for scale := 0; u > 0; scale++ {
+		if g := u % 1000; g != 0 {
+			w := englishBelowThousand(g)
+			if englishScales[scale] != "" {
+				w += " " + englishScales[scale]
+			}
+			groups = append([]string{w}, groups...)
+		}
+		u /= 1000
+	}
+
+	ret := strings.Join(groups, " ")
+	if n < 0 {
+		return "negative " + ret
+	}
+
+	return ret
+}
+
+var englishIrregularOrdinals = map[string]string{
+	"zero": "zeroth", "one": "first", "two": "second", "three": "third", "five": "fifth",
+	"eight": "eighth", "nine": "ninth", "twelve": "twelfth",
+}
+
+// englishOrdinal spells n in English as ordinal such as "twenty-first"
+func englishOrdinal(n int64) string {
+	s := englishCardinal(n)
+	i := strings.LastIndexAny(s, " -") + 1
+	last := s[i:]
+	switch {
+	case englishIrregularOrdinals[last] != "":
+		last = englishIrregularOrdinals[last]
+	case strings.HasSuffix(last, "y"):
+		last = strings.TrimSuffix(last, "y") + "ieth"
+	default:
+		last += "th"
+	}
+
+	return s[:i] + last
+}
+
+type romanNumeral struct {
+	value   int64
+	numeral string
+}
+
+var romanNumerals = []romanNumeral{
+	{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"}, {100, "C"}, {90, "XC"},
+	{50, "L"}, {40, "XL"}, {10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+}
+
+// romanNumber prints n in Roman numerals. Old Roman numerals do not use
+// subtractive notation such as IV.
+func romanNumber(n int64, old bool) string {
+	var sb strings.Builder
+	for _, r := range romanNumerals {
+		if old && len(r.numeral) == 2 {
+			continue
+		}
+
+		for ; n >= r.value; n -= r.value {
+			sb.WriteString(r.numeral)
+		}
+	}
+
+	return sb.String()
+}
+
+// formatRadix runs ~radix,mincol,padchar,commachar,comma-intervalR. Without
+// parameters, it prints English words or Roman numerals.
+func (f *formatter) formatRadix(d *formatDirective, params []*Object, args *formatArgs) error {
+	arg, err := f.next(d, args)
+	if err != nil {
+		return err
+	}
+
+	if len(params) > 0 && params[0] != nil {
+		radix, err := f.intParam(d, params, 0, 10)
+		if err != nil {
+			return err
+		}
+
+		if radix < 2 || radix > 36 {
+			return f.errorf(d, "radix %d is out of range", radix)
+		}
+
+		s, err := f.formatInteger(d, arg, radix, params[1:])
+		if err != nil {
+			return err
+		}
+
+		f.sb.WriteString(s)
+		return nil
+	}
+
+	n, ok := arg.value.(int64)
+	if !ok {
+		return f.errorf(d, "~R requires fixnum but got %v", *arg)
+	}
+
+	switch {
+	case d.at:
+		limit := int64(3999)
+		if d.colon {
+			limit = 4999
+		}
+
+		if n < 1 || n > limit {
+			return f.errorf(d, "Roman numeral requires integer between 1 and %d but got %d", limit, n)
+		}
+		f.sb.WriteString(romanNumber(n, d.colon))
+	case d.colon:
+		f.sb.WriteString(englishOrdinal(n))
+	default:
+		f.sb.WriteString(englishCardinal(n))
+	}
+
+	return nil
+}
+
+// floatArgument returns arg as float. If arg is not real, it writes arg
+// padded to w columns as ~wD does and returns false.
+func (f *formatter) floatArgument(d *formatDirective, arg *Object, w int) (float64, bool, error) {
+	if isReal(arg) {
+		v, _, err := floatValue(arg)
+		return v, err == nil, err
+	}
+
+	s, err := decimalString(arg)
+	if err != nil {
+		return 0, false, err
+	}
+
+	f.sb.WriteString(padString(s, w, 1, 0, ' ', true))
+	return 0, false, nil
+}
+
+// fitWidth pads s to w columns, or fills w columns with overflow character
+// if s does not fit
+func fitWidth(s string, w int, overflow rune, padchar rune) string {
+	if w < 0 {
+		return s
+	}
+
+	if utf8.RuneCountInString(s) > w && overflow != 0 {
+		return strings.Repeat(string(overflow), w)
+	}
+
+	return padString(s, w, 1, 0, padchar, true)
+}
+
+func floatSign(v float64, at bool) string {
+	if math.Signbit(v) {
+		return "-"
+	}
+
+	if at {
+		return "+"
+	}
+
+	return ""
+}
+
+// fixedDigits prints non-negative v with digits fraction digits. Negative
+// digits means the shortest representation.
+func fixedDigits(v float64, digits int) string {
+	s := strconv.FormatFloat(v, 'f', digits, 64)
+	if !strings.Contains(s, ".") {
+		if digits == 0 {
+			return s + "."
+		}
+		return s + ".0"
+	}
+
+	return s
+}
+
+// formatFixed runs ~w,d,k,overflowchar,padcharF
+func (f *formatter) formatFixed(d *formatDirective, params []*Object, args *formatArgs) error {
+	arg, err := f.next(d, args)
+	if err != nil {
+		return err
+	}
+
+	w, err := f.intParam(d, params, 0, -1)
+	if err != nil {
+		return err
+	}
+
+	digits, err := f.intParam(d, params, 1, -1)
+	if err != nil {
+		return err
+	}
+
+	k, err := f.intParam(d, params, 2, 0)
+	if err != nil {
+		return err
+	}
+
+	overflow, err := f.charParam(d, params, 3, 0)
+	if err != nil {
+		return err
+	}
+
+	padchar, err := f.charParam(d, params, 4, ' ')
+	if err != nil {
+		return err
+	}
+
+	v, ok, err := f.floatArgument(d, arg, w)
+	if err != nil || !ok {
+		return err
+	}
+
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		f.sb.WriteString(fitWidth(formatFloat(v), w, overflow, padchar))
+		return nil
+	}
+
+	v *= math.Pow10(k)
+	sign := floatSign(v, d.at)
+	abs := math.Abs(v)
+	s := fixedDigits(abs, digits)
+	if w >= 0 && digits < 0 && len(sign)+len(s) > w {
+		// drop fraction digits to fit the width
+		intLen := len(strconv.FormatFloat(abs, 'f', 0, 64))
+		avail := w - len(sign) - intLen - 1
+		if avail < 0 {
+			avail = 0
+		}
+		s = fixedDigits(abs, avail)
+	}
+
+	if w >= 0 && len(sign)+len(s) > w && strings.HasPrefix(s, "0.") {
+		s = s[1:]
+	}
+
+	f.sb.WriteString(fitWidth(sign+s, w, overflow, padchar))
+	return nil
+}
+
+// exponentialDigits prints non-negative v as mantissa and exponent. The
+// mantissa has one digit before the point.
+func exponentialDigits(v float64, digits int, expDigits int, marker rune) string {
+	s := strconv.FormatFloat(v, 'e', digits, 64)
+	i := strings.IndexByte(s, 'e')
+	mantissa := s[:i]
+	if !strings.Contains(mantissa, ".") {
+		if digits == 0 {
+			mantissa += "."
+		} else {
+			mantissa += ".0"
+		}
+	}
+
+	exp, _ := strconv.Atoi(s[i+1:])
+	expSign := "+"
+	if exp < 0 {
+		expSign = "-"
+		exp = -exp
+	}
+
+	expStr := strconv.Itoa(exp)
+	if len(expStr) < expDigits {
+		expStr = strings.Repeat("0", expDigits-len(expStr)) + expStr
+	}
+
+	return mantissa + string(marker) + expSign + expStr
+}
+
+// formatExponential runs ~w,d,e,k,overflowchar,padchar,exptcharE. Only the
+// default scale factor 1 is supported.
+func (f *formatter) formatExponential(d *formatDirective, params []*Object, args *formatArgs) error {
+	arg, err := f.next(d, args)
+	if err != nil {
+		return err
+	}
+
+	w, err := f.intParam(d, params, 0, -1)
+	if err != nil {
+		return err
+	}
+
+	digits, err := f.intParam(d, params, 1, -1)
+	if err != nil {
+		return err
+	}
+
+	expDigits, err := f.intParam(d, params, 2, 0)
+	if err != nil {
+		return err
+	}
+
+	k, err := f.intParam(d, params, 3, 1)
+	if err != nil {
+		return err
+	}
+
+	if k != 1 {
+		return f.errorf(d, "scale factor %d of ~E is not supported", k)
+	}
+
+	overflow, err := f.charParam(d, params, 4, 0)
+	if err != nil {
+		return err
+	}
+
+	padchar, err := f.charParam(d, params, 5, ' ')
+	if err != nil {
+		return err
+	}
+
+	// the exponent marker of the float printer
+	defaultMarker := 'E'
+	if arg.kind == SingleFloatType {
+		defaultMarker = 'F'
+	}
+
+	marker, err := f.charParam(d, params, 6, defaultMarker)
+	if err != nil {
+		return err
+	}
+
+	v, ok, err := f.floatArgument(d, arg, w)
+	if err != nil || !ok {
+		return err
+	}
+
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		f.sb.WriteString(fitWidth(formatFloat(v), w, overflow, padchar))
+		return nil
+	}
+
+	sign := floatSign(v, d.at)
+	abs := math.Abs(v)
+	s := exponentialDigits(abs, digits, expDigits, marker)
+	if w >= 0 && digits < 0 && len(sign)+len(s) > w {
+		// drop fraction digits to fit the width
+		exp := s[strings.IndexRune(s, marker):]
+		avail := w - len(sign) - len(exp) - 2
+		if avail < 0 {
+			avail = 0
+		}
+		s = exponentialDigits(abs, avail, expDigits, marker)
+	}
+
+	f.sb.WriteString(fitWidth(sign+s, w, overflow, padchar))
+	return nil
+}
+
+// formatMonetary runs ~d,n,w,padchar$. ~:$ prints the sign before the
+// padding.
+func (f *formatter) formatMonetary(d *formatDirective, params []*Object, args *formatArgs) error {
+	arg, err := f.next(d, args)
+	if err != nil {
+		return err
+	}
+
+	digits, err := f.intParam(d, params, 0, 2)
+	if err != nil {
+		return err
+	}
+
+	n, err := f.intParam(d, params, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	w, err := f.intParam(d, params, 2, 0)
+	if err != nil {
+		return err
+	}
+
+	padchar, err := f.charParam(d, params, 3, ' ')
+	if err != nil {
+		return err
+	}
+
+	if digits < 0 {
+		return f.errorf(d, "number of digits must not be negative")
+	}
+
+	v, ok, err := f.floatArgument(d, arg, w)
+	if err != nil || !ok {
+		return err
+	}
+
+	sign := floatSign(v, d.at)
+	s := fixedDigits(math.Abs(v), digits)
+	if intLen := strings.IndexByte(s, '.'); intLen < n {
+		s = strings.Repeat("0", n-intLen) + s
+	}
+
+	padding := ""
+	if l := len(sign) + len(s); l < w {
+		padding = strings.Repeat(string(padchar), w-l)
+	}
+
+	if d.colon {
+		f.sb.WriteString(sign + padding + s)
+	} else {
+		f.sb.WriteString(padding + sign + s)
+	}
+
+	return nil
+}
+
+// formatGoto runs ~n* which skips arguments. ~n:* backs up and ~n@* goes to
+// the nth argument.
+func (f *formatter) formatGoto(d *formatDirective, params []*Object, args *formatArgs) error {
+	def := 1
+	if d.at {
+		def = 0
+	}
+
+	n, err := f.intParam(d, params, 0, def)
+	if err != nil {
+		return err
+	}
+
+	pos := args.pos + n
+	switch {
+	case d.at:
+		pos = n
+	case d.colon:
+		pos = args.pos - n
+	}
+
+	if pos < 0 || pos > len(args.list) {
+		return f.errorf(d, "argument index %d is out of range", pos)
+	}
+
+	args.pos = pos
+	return nil
+}
+
+// formatIndirect runs ~? which processes control string argument with list
+// argument. ~@? uses the remaining arguments instead.
+func (f *formatter) formatIndirect(d *formatDirective, args *formatArgs) error {
+	arg, err := f.next(d, args)
+	if err != nil {
+		return err
+	}
+
+	control, ok := arg.value.(string)
+	if !ok {
+		return f.errorf(d, "~? requires control string but got %v", *arg)
+	}
+
+	ds, err := parseControl(control)
+	if err != nil {
+		return err
+	}
+
+	subArgs := args
+	if !d.at {
+		list, err := f.next(d, args)
+		if err != nil {
+			return err
+		}
+
+		subArgs, err = f.listArguments(d, list)
+		if err != nil {
+			return err
+		}
+	}
+
+	sub := &formatter{control: control, sb: f.sb, fresh: f.fresh}
+	err = sub.run(ds, subArgs)
+	if err == errFormatEscape {
+		return nil
+	}
+
+	return err
+}
+
+// formatIteration runs ~n{ which processes its body for each element of
+// list argument. ~:{ takes list of sublists, ~@{ uses the remaining
+// arguments and ~:@{ uses the remaining arguments as sublists. If the body
+// is empty, it is taken from control string argument.
+func (f *formatter) formatIteration(d *formatDirective, params []*Object, args *formatArgs) error {
+	limit, err := f.intParam(d, params, 0, -1)
+	if err != nil {
+		return err
+	}
+
+	runner := f
+	body := d.body
+	if len(body) == 0 {
+		arg, err := f.next(d, args)
+		if err != nil {
+			return err
+		}
+
+		control, ok := arg.value.(string)
+		if !ok {
+			return f.errorf(d, "~{~} requires control string but got %v", *arg)
+		}
+
+		body, err = parseControl(control)
+		if err != nil {
+			return err
+		}
+		runner = &formatter{control: control, sb: f.sb, fresh: f.fresh}
+	}
+
+	source := args
+	if !d.at {
+		list, err := f.next(d, args)
+		if err != nil {
+			return err
+		}
+
+		source, err = f.listArguments(d, list)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := 0; limit < 0 || i < limit; i++ {
+		if source.remaining() == 0 && !(i == 0 && d.atLeastOnce) {
+			break
+		}
+
+		if !d.colon {
+			pos := source.pos
+			err := runner.run(body, source)
+			if err == errFormatEscape {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			if source.pos == pos && source.remaining() > 0 && limit < 0 {
+				return f.errorf(d, "~{ body does not consume arguments")
+			}
+			continue
+		}
+
+		subArgs := &formatArgs{}
+		if source.remaining() > 0 {
+			sublist, err := f.next(d, source)
+			if err != nil {
+				return err
+			}
+
+			subArgs, err = f.listArguments(d, sublist)
+			if err != nil {
+				return err
+			}
+		}
+
+		// ~^ terminates only the iteration for the current sublist
+		if err := runner.run(body, subArgs); err != nil && err != errFormatEscape {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// formatConditional runs ~n[ which selects the nth clause. ~:[ selects the
+// first clause for nil and the second clause otherwise. ~@[ processes its
+// clause with the argument if the argument is non-nil.
+func (f *formatter) formatConditional(d *formatDirective, params []*Object, args *formatArgs) error {
+	var clause []*formatDirective
+	switch {
+	case d.colon:
+		arg, err := f.next(d, args)
+		if err != nil {
+			return err
+		}
+
+		if isNull(arg) {
+			clause = d.clauses[0]
+		} else {
+			clause = d.clauses[1]
+		}
+	case d.at:
+		arg, err := f.next(d, args)
+		if err != nil {
+			return err
+		}
+
+		if !isNull(arg) {
+			args.pos--
+			clause = d.clauses[0]
+		}
+	default:
+		var n int
+		if len(params) > 0 && params[0] != nil {
+			v, err := f.intParam(d, params, 0, 0)
+			if err != nil {
+				return err
+			}
+			n = v
+		} else {
+			arg, err := f.next(d, args)
+			if err != nil {
+				return err
+			}
+
+			v, ok := arg.value.(int64)
+			if !ok {
+				return f.errorf(d, "~[ requires integer but got %v", *arg)
+			}
+			n = int(v)
+		}
+
+		choices := len(d.clauses)
+		if d.hasDefault {
+			choices--
+		}
+
+		if n >= 0 && n < choices {
+			clause = d.clauses[n]
+		} else if d.hasDefault {
+			clause = d.clauses[choices]
+		}
+	}
+
+	return f.run(clause, args)
+}
+
+// formatCase runs ~( which downcases the output of its body. ~:( capitalizes
+// each word, ~@( capitalizes the first word and ~:@( upcases.
+func (f *formatter) formatCase(d *formatDirective, args *formatArgs) error {
+	sub := &formatter{control: f.control, sb: &strings.Builder{}, fresh: f.atLineStart()}
+	err := sub.run(d.body, args)
+	if err != nil && err != errFormatEscape {
+		return err
+	}
+
+	s := sub.sb.String()
+	switch {
+	case d.colon && d.at:
+		s = strings.ToUpper(s)
+	case d.colon:
+		s = capitalize(s)
+	case d.at:
+		s = strings.ToLower(s)
+		if i := strings.IndexFunc(s, unicode.IsLetter); i >= 0 {
+			c, size := utf8.DecodeRuneInString(s[i:])
+			s = s[:i] + string(unicode.ToUpper(c)) + s[i+size:]
+		}
+	default:
+		s = strings.ToLower(s)
+	}
+
+	f.sb.WriteString(s)
+	return err
+}
+
+// formatControl processes control string with args. fresh tells whether
+// the output starts at the beginning of line for ~&.
+func formatControl(control string, args []*Object, fresh bool) (string, error) {
+	ds, err := parseControl(control)
+	if err != nil {
+		return "", err
+	}
+
+	f := &formatter{control: control, sb: &strings.Builder{}, fresh: fresh}
+	if err := f.run(ds, &formatArgs{list: args}); err != nil && err != errFormatEscape {
+		return "", err
+	}
+
+	return f.sb.String(), nil
+}
+
+func builtinFormat(_ *Environment, args []*Object) (*Object, error) {
+	// (format destination control-string &rest args)
+	control, ok := args[1].value.(string)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "format", argument: args[1]}
+	}
+
+	if isNull(args[0]) {
+		str, err := formatControl(control, args[2:], true)
+		if err != nil {
+			return nil, err
+		}
+
+		return newString(str), nil
+	}
+
+	// t means *standard-output* for format
+	dest := args[0]
+	if dest == tObj {
+		dest = nilObj
+	}
+
+	s, err := outputStream("format", dest)
+	if err != nil {
+		return nil, err
+	}
+
+	str, err := formatControl(control, args[2:], !s.midLine)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.writeString(str); err != nil {
+		return nil, err
+	}
+
+	return nilObj, nil
+}
+
+func initFormatFunctions() {
+	installBuiltinFunction("format", builtinFormat, 2, true)
+}
@@ -0,0 +1,146 @@
+package banglisp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{name: "literal", expr: `(format nil "hello")`, want: `"hello"`},
+		{name: "aesthetic", expr: `(format nil "~a and ~a" "x" 'y)`, want: `"x and y"`},
+		{name: "standard", expr: `(format nil "~s ~s" "x" #\a)`, want: `"\"x\" #\\a"`},
+		{name: "colon aesthetic nil", expr: `(format nil "~:a" nil)`, want: `"()"`},
+		{name: "pad right", expr: `(format nil "~5a|" 'ab)`, want: `"ab   |"`},
+		{name: "pad left", expr: `(format nil "~5@a|" 'ab)`, want: `"   ab|"`},
+		{name: "pad with colinc", expr: `(format nil "~6,4,1,'*a|" 'ab)`, want: `"ab*****|"`},
+		{name: "decimal", expr: `(format nil "~d" 42)`, want: `"42"`},
+		{name: "decimal sign", expr: `(format nil "~@d ~@d" 42 -1)`, want: `"+42 -1"`},
+		{name: "decimal commas", expr: `(format nil "~:d" 1234567)`, want: `"1,234,567"`},
+		{name: "decimal padding", expr: `(format nil "~5,'0d" 42)`, want: `"00042"`},
+		{name: "decimal comma parameters", expr: `(format nil "~,,'.,4:d" 123456789)`, want: `"1.2345.6789"`},
+		{name: "decimal bignum", expr: `(format nil "~:d" 123456789012345678901234)`, want: `"123,456,789,012,345,678,901,234"`},
+		{name: "decimal non-integer", expr: `(format nil "~5d|" 'a)`, want: `"    a|"`},
+		{name: "binary", expr: `(format nil "~b" 5)`, want: `"101"`},
+		{name: "octal", expr: `(format nil "~o" 8)`, want: `"10"`},
+		{name: "hexadecimal", expr: `(format nil "~x" 255)`, want: `"FF"`},
+		{name: "radix", expr: `(format nil "~3r" 10)`, want: `"101"`},
+		{name: "cardinal", expr: `(format nil "~r" 1234)`, want: `"one thousand two hundred thirty-four"`},
+		{name: "negative cardinal", expr: `(format nil "~r" -15)`, want: `"negative fifteen"`},
+		{name: "ordinal", expr: `(format nil "~:r ~:r ~:r" 1 22 40)`, want: `"first twenty-second fortieth"`},
+		{name: "roman", expr: `(format nil "~@r" 1994)`, want: `"MCMXCIV"`},
+		{name: "old roman", expr: `(format nil "~:@r" 4)`, want: `"IIII"`},
+		{name: "fixed", expr: `(format nil "~,2f" 3.14159)`, want: `"3.14"`},
+		{name: "fixed shortest", expr: `(format nil "~f" 1.5)`, want: `"1.5"`},
+		{name: "fixed integer", expr: `(format nil "~f" 2)`, want: `"2.0"`},
+		{name: "fixed width", expr: `(format nil "~6,2f" 3.14159)`, want: `"  3.14"`},
+		{name: "fixed width only", expr: `(format nil "~4f" 3.14159)`, want: `"3.14"`},
+		{name: "fixed drops leading zero", expr: `(format nil "~3,2f" 0.5)`, want: `".50"`},
+		{name: "fixed overflow", expr: `(format nil "~3,2,,'*f" 123.0)`, want: `"***"`},
+		{name: "fixed sign", expr: `(format nil "~@f" 1.5)`, want: `"+1.5"`},
+		{name: "fixed scale", expr: `(format nil "~,1,2f" 1.5)`, want: `"150.0"`},
+		{name: "exponential", expr: `(format nil "~e" 123456.0)`, want: `"1.23456E+5"`},
+		{name: "exponential digits", expr: `(format nil "~,2e" 0.001234)`, want: `"1.23E-3"`},
+		{name: "exponential exponent digits", expr: `(format nil "~,2,2e" 1234.0)`, want: `"1.23E+03"`},
+		{name: "monetary", expr: `(format nil "~$" 3.14159)`, want: `"3.14"`},
+		{name: "monetary integer digits", expr: `(format nil "~,3$" 1.5)`, want: `"001.50"`},
+		{name: "monetary width", expr: `(format nil "~,,8$|~,,8:@$" -1.5 1.5)`, want: `"   -1.50|+   1.50"`},
+		{name: "newline", expr: `(format nil "a~%b~2%c")`, want: "\"a\nb\n\nc\""},
+		{name: "fresh line", expr: `(format nil "~&a~&~&b")`, want: "\"a\nb\""},
+		{name: "fresh line count", expr: `(format nil "a~2&b")`, want: "\"a\n\nb\""},
+		{name: "tilde", expr: `(format nil "~~ ~3~")`, want: `"~ ~~~"`},
+		{name: "ignored newline", expr: "(format nil \"a~\n   b\")", want: `"ab"`},
+		{name: "iteration", expr: `(format nil "~{~a~^, ~}" '(1 2 3))`, want: `"1, 2, 3"`},
+		{name: "iteration limit", expr: `(format nil "~2{~a~}" '(1 2 3))`, want: `"12"`},
+		{name: "iteration empty", expr: `(format nil "[~{~a~}]" nil)`, want: `"[]"`},
+		{name: "iteration at least once", expr: `(format nil "[~{x~:}]" nil)`, want: `"[x]"`},
+		{name: "iteration sublists", expr: `(format nil "~:{~a=~a;~}" '((a 1) (b 2)))`, want: `"a=1;b=2;"`},
+		{name: "escape in sublist", expr: `(format nil "~:{~a~^=~a ~}" '((a 1) (b)))`, want: `"a=1 b"`},
+		{name: "iteration remaining", expr: `(format nil "~@{~a~^-~}" 1 2 3)`, want: `"1-2-3"`},
+		{name: "iteration remaining sublists", expr: `(format nil "~:@{<~a ~a>~}" '(a 1) '(b 2))`, want: `"<a 1><b 2>"`},
+		{name: "iteration control argument", expr: `(format nil "~{~}" "<~a>" '(1 2))`, want: `"<1><2>"`},
+		{name: "nested iteration", expr: `(format nil "~{(~{~a~})~}" '((1 2) (3)))`, want: `"(12)(3)"`},
+		{name: "conditional", expr: `(format nil "~[zero~;one~;two~]" 1)`, want: `"one"`},
+		{name: "conditional out of range", expr: `(format nil "~[zero~;one~]" 5)`, want: `""`},
+		{name: "conditional default", expr: `(format nil "~[zero~;one~:;many~]" 5)`, want: `"many"`},
+		{name: "conditional parameter", expr: `(format nil "~1[a~;b~]")`, want: `"b"`},
+		{name: "conditional count parameter", expr: `(format nil "~#[none~;one~;two~]" 'x 'y)`, want: `"two"`},
+		{name: "boolean conditional", expr: `(format nil "~:[no~;yes~] ~:[no~;yes~]" nil t)`, want: `"no yes"`},
+		{name: "at conditional", expr: `(format nil "~@[x=~a ~]~a" 1 2)`, want: `"x=1 2"`},
+		{name: "at conditional nil", expr: `(format nil "~@[x=~a ~]~a" nil 2)`, want: `"2"`},
+		{name: "downcase", expr: `(format nil "~(~a~)" "HeLLo WORLD")`, want: `"hello world"`},
+		{name: "capitalize", expr: `(format nil "~:(~a~)" "hello world")`, want: `"Hello World"`},
+		{name: "capitalize first", expr: `(format nil "~@(~a~)" "hello WORLD")`, want: `"Hello world"`},
+		{name: "upcase", expr: `(format nil "~:@(~a~)" "hello")`, want: `"HELLO"`},
+		{name: "skip argument", expr: `(format nil "~a ~*~a" 1 2 3)`, want: `"1 3"`},
+		{name: "back up argument", expr: `(format nil "~a ~:*~a" 1)`, want: `"1 1"`},
+		{name: "goto argument", expr: `(format nil "~a ~a ~@*~a" 1 2)`, want: `"1 2 1"`},
+		{name: "indirect", expr: `(format nil "~? ~a" "<~a ~a>" '(1 2) 3)`, want: `"<1 2> 3"`},
+		{name: "indirect remaining", expr: `(format nil "~@? ~a" "<~a>" 1 2)`, want: `"<1> 2"`},
+		{name: "variable parameter", expr: `(format nil "~va|" 4 'x)`, want: `"x   |"`},
+		{name: "variable nil parameter", expr: `(format nil "~v,'0d" nil 7)`, want: `"7"`},
+		{name: "escape at top level", expr: `(format nil "~a~^ ~a" 1)`, want: `"1"`},
+		{name: "upper case directive", expr: `(format nil "~A~D" 'a 1)`, want: `"a1"`},
+		{name: "format to stream", expr: `(let ((s (make-string-output-stream))) (format s "~a-~a" 1 2) (get-output-stream-string s))`, want: `"1-2"`},
+		{name: "format to stream returns nil", expr: `(format (make-string-output-stream) "x")`, want: "nil"},
+		{name: "fresh line on stream", expr: `(let ((s (make-string-output-stream))) (format s "a") (format s "~&b~&") (get-output-stream-string s))`, want: "\"a\nb\n\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("got: %s, expected: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		expr  string
+		index int
+	}{
+		{name: "unknown directive", expr: `(format nil "ab~q")`, index: 2},
+		{name: "missing directive character", expr: `(format nil "ab~")`, index: 2},
+		{name: "missing directive after parameters", expr: `(format nil "~3,'")`, index: 0},
+		{name: "unclosed iteration", expr: `(format nil "x~{~a")`, index: 1},
+		{name: "unmatched close", expr: `(format nil "~a~}")`, index: 2},
+		{name: "unclosed conditional", expr: `(format nil "~[a~;b")`, index: 0},
+		{name: "separator outside conditional", expr: `(format nil "a~;b")`, index: 1},
+		{name: "boolean conditional clauses", expr: `(format nil "~:[a~]")`, index: 0},
+		{name: "default clause not last", expr: `(format nil "~[a~:;b~;c~]" 0)`, index: 7},
+		{name: "too many parameters", expr: `(format nil "~1,2%")`, index: 0},
+		{name: "duplicate modifier", expr: `(format nil "~::a")`, index: 0},
+		{name: "no more arguments", expr: `(format nil "~a ~a" 1)`, index: 3},
+		{name: "parameter type", expr: `(format nil "~'xa" 1)`, index: 0},
+		{name: "roman out of range", expr: `(format nil "~@r" 0)`, index: 0},
+		{name: "iteration requires list", expr: `(format nil "~{~a~}" 1)`, index: 0},
+		{name: "conditional requires integer", expr: `(format nil "~[a~]" 'x)`, index: 0},
+		{name: "goto out of range", expr: `(format nil "~2@*" 1)`, index: 0},
+		{name: "error in indirect control", expr: `(format nil "~?" "~(" nil)`, index: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readEvalString(tt.expr)
+			var formatErr *ErrFormat
+			if !errors.As(err, &formatErr) {
+				t.Fatalf("error = %v, expected format error", err)
+			}
+
+			if formatErr.index != tt.index {
+				t.Errorf("index = %d, expected %d (%v)", formatErr.index, tt.index, err)
+			}
+		})
+	}
+}
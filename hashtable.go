@@ -0,0 +1,461 @@
+package banglisp
+
+import (
+	"hash/fnv"
+	"math"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+type hashTableTest struct {
+	name  string
+	equal func(a *Object, b *Object) bool
+	hash  func(obj *Object) uint64
+}
+
+type hashEntry struct {
+	key   *Object
+	value *Object
+}
+
+type HashTable struct {
+	test    *hashTableTest
+	buckets map[uint64][]*hashEntry
+	entries []*hashEntry
+}
+
+// structural hashing stops descending at this depth so that deep or
+// circular lists can still be used as keys. equal and equalp detect cycles
+// by themselves.
+const hashDepthLimit = 4
+
+var hashTableTests = map[string]*hashTableTest{
+	"eq":     {"eq", objectEqual, hashEq},
+	"eql":    {"eql", isEql, hashEql},
+	"equal":  {"equal", isEqual, func(obj *Object) uint64 { return hashEqual(obj, 0) }},
+	"equalp": {"equalp", isEqualp, func(obj *Object) uint64 { return hashEqualp(obj, 0) }},
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum64()
+}
+
+func hashCombine(h uint64, v uint64) uint64 {
+	return h*31 + v
+}
+
+func hashEq(obj *Object) uint64 {
+	return uint64(obj.id)
+}
+
+func hashEql(obj *Object) uint64 {
+	switch v := obj.value.(type) {
+	case int64:
+		return uint64(v)
+	case *big.Int:
+		return hashString(v.String())
+	case *big.Rat:
+		return hashString(v.String())
+	case float64:
+		return math.Float64bits(v)
+	case float32:
+		return uint64(math.Float32bits(v))
+	case *Decimal:
+		// eql decimals may differ in trailing zeros
+		d := v.trimZeros(math.MinInt32)
+		return hashCombine(hashString(d.unscaled.String()), uint64(d.scale))
+	case complex128:
+		return hashCombine(math.Float64bits(real(v)), math.Float64bits(imag(v)))
+	case rune:
+		return uint64(v)
+	default:
+		return hashEq(obj)
+	}
+}
+
+func hashEqual(obj *Object, depth int) uint64 {
+	if isEmptyList(obj) {
+		return hashEq(nilObj)
+	}
+
+	switch obj.kind {
+	case StringType:
+		return hashString(obj.value.(string))
+	case PathnameType:
+		return hashString(obj.value.(*Pathname).namestring())
+	case ConsCellType:
+		if depth >= hashDepthLimit {
+			return uint64(ConsCellType)
+		}
+
+		c := obj.value.(*ConsCell)
+		return hashCombine(hashEqual(c.car, depth+1), hashEqual(c.cdr, depth+1))
+	default:
+		return hashEql(obj)
+	}
+}
+
+func hashEqualp(obj *Object, depth int) uint64 {
+	if isEmptyList(obj) {
+		return hashEq(nilObj)
+	}
+
+	if isNumber(obj) {
+		v, _ := complexValue(obj)
+		re, im := real(v), imag(v)
+		if re == 0 {
+			re = 0 // merge -0.0 and 0.0
+		}
+		if im == 0 {
+			return math.Float64bits(re)
+		}
+		return hashCombine(math.Float64bits(re), math.Float64bits(im))
+	}
+
+	switch obj.kind {
+	case CharacterType:
+		return uint64(unicode.ToLower(obj.value.(rune)))
+	case StringType:
+		return hashString(strings.ToLower(obj.value.(string)))
+	case PathnameType:
+		// equalp compares pathnames as equal does
+		return hashString(obj.value.(*Pathname).namestring())
+	case ConsCellType:
+		if depth >= hashDepthLimit {
+			return uint64(ConsCellType)
+		}
+
+		c := obj.value.(*ConsCell)
+		return hashCombine(hashEqualp(c.car, depth+1), hashEqualp(c.cdr, depth+1))
+	case HashTableType:
+		return uint64(len(obj.value.(*HashTable).entries))
+	case StructureType:
+		if depth >= hashDepthLimit {
+			return uint64(StructureType)
+		}
+
+		s := obj.value.(*Structure)
+		h := hashEq(s.class.name)
+		for _, slot := range s.slots {
+			h = hashCombine(h, hashEqualp(slot, depth+1))
+		}
+		return h
+	default:
+		return hashEql(obj)
+	}
+}
+
+func newHashTable(test *hashTableTest) *Object {
+	h := &HashTable{
+		test:    test,
+		buckets: make(map[uint64][]*hashEntry),
+	}
+
+	return newObject(HashTableType, h)
+}
+
+func (h *HashTable) lookup(key *Object) (uint64, int) {
+	hv := h.test.hash(key)
+	for i, e := range h.buckets[hv] {
+		if h.test.equal(e.key, key) {
+			return hv, i
+		}
+	}
+
+	return hv, -1
+}
+
+func (h *HashTable) get(key *Object) (*Object, bool) {
+	hv, i := h.lookup(key)
+	if i < 0 {
+		return nil, false
+	}
+
+	return h.buckets[hv][i].value, true
+}
+
+func (h *HashTable) put(key *Object, value *Object) {
+	hv, i := h.lookup(key)
+	if i >= 0 {
+		h.buckets[hv][i].value = value
+		return
+	}
+
+	e := &hashEntry{key, value}
+	h.buckets[hv] = append(h.buckets[hv], e)
+	h.entries = append(h.entries, e)
+}
+
+func (h *HashTable) remove(key *Object) bool {
+	hv, i := h.lookup(key)
+	if i < 0 {
+		return false
+	}
+
+	e := h.buckets[hv][i]
+	bucket := append(h.buckets[hv][:i], h.buckets[hv][i+1:]...)
+	if len(bucket) == 0 {
+		delete(h.buckets, hv)
+	} else {
+		h.buckets[hv] = bucket
+	}
+
+	for j, entry := range h.entries {
+		if entry == e {
+			h.entries = append(h.entries[:j], h.entries[j+1:]...)
+			break
+		}
+	}
+
+	return true
+}
+
+func (h *HashTable) clear() {
+	h.buckets = make(map[uint64][]*hashEntry)
+	h.entries = nil
+}
+
+// rehash rebuilds the buckets after keys are modified in place
+func (h *HashTable) rehash() {
+	entries := h.entries
+	h.clear()
+	for _, e := range entries {
+		h.put(e.key, e.value)
+	}
+}
+
+// snapshot returns the current entries so that callers can iterate while
+// the table is modified
+func (h *HashTable) snapshot() []hashEntry {
+	ret := make([]hashEntry, 0, len(h.entries))
+	for _, e := range h.entries {
+		ret = append(ret, *e)
+	}
+
+	return ret
+}
+
+func (h *HashTable) equalp(other *HashTable, pairs comparedPairs) bool {
+	if h.test != other.test || len(h.entries) != len(other.entries) {
+		return false
+	}
+
+	for _, e := range h.entries {
+		v, ok := other.get(e.key)
+		if !ok || !equalpObjects(e.value, v, pairs) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func hashTableTestFromDesignator(obj *Object) (*hashTableTest, bool) {
+	if sym, ok := obj.value.(*Symbol); ok {
+		test, ok := hashTableTests[sym.name.value.(string)]
+		return test, ok
+	}
+
+	for name, test := range hashTableTests {
+		sym := newSymbol(name).value.(*Symbol)
+		if sym.function == obj {
+			return test, true
+		}
+	}
+
+	return nil, false
+}
+
+func hashTableValue(function string, obj *Object) (*HashTable, error) {
+	h, ok := obj.value.(*HashTable)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: function, argument: obj}
+	}
+
+	return h, nil
+}
+
+func builtinMakeHashTable(_ *Environment, args []*Object) (*Object, error) {
+	// (make-hash-table &key test size rehash-size rehash-threshold)
+	keys, err := parseKeywordArguments("make-hash-table", args, "test", "size", "rehash-size", "rehash-threshold")
+	if err != nil {
+		return nil, err
+	}
+
+	test := hashTableTests["eql"]
+	if v, ok := keys["test"]; ok {
+		test, ok = hashTableTestFromDesignator(v)
+		if !ok {
+			return nil, &ErrUnsupportedArgumentType{function: "make-hash-table", argument: v}
+		}
+	}
+
+	return newHashTable(test), nil
+}
+
+func builtinGethash(env *Environment, args []*Object) (*Object, error) {
+	// (gethash key hash-table &optional default)
+	if len(args) > 3 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 3, got: len(args)}
+	}
+
+	h, err := hashTableValue("gethash", args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if v, ok := h.get(args[0]); ok {
+		return env.setValues([]*Object{v, tObj}), nil
+	}
+
+	def := nilObj
+	if len(args) == 3 {
+		def = args[2]
+	}
+
+	return env.setValues([]*Object{def, nilObj}), nil
+}
+
+func setfGethash(_ *Environment, args []*Object, value *Object) (*Object, error) {
+	// (setf (gethash key hash-table &optional default) value)
+	if len(args) < 2 || len(args) > 3 {
+		return nil, &ErrWrongNumberArguments{variadic: true, expected: 2, got: len(args)}
+	}
+
+	h, err := hashTableValue("gethash", args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	h.put(args[0], value)
+	return value, nil
+}
+
+func builtinRemhash(_ *Environment, args []*Object) (*Object, error) {
+	// (remhash key hash-table)
+	h, err := hashTableValue("remhash", args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if h.remove(args[0]) {
+		return tObj, nil
+	}
+
+	return nilObj, nil
+}
+
+func builtinClrhash(_ *Environment, args []*Object) (*Object, error) {
+	// (clrhash hash-table)
+	h, err := hashTableValue("clrhash", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	h.clear()
+	return args[0], nil
+}
+
+func builtinMaphash(env *Environment, args []*Object) (*Object, error) {
+	// (maphash function hash-table)
+	h, err := hashTableValue("maphash", args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range h.snapshot() {
+		if _, err := funcall(env, args[0], []*Object{e.key, e.value}); err != nil {
+			return nil, err
+		}
+	}
+
+	return nilObj, nil
+}
+
+func builtinHashTableCount(_ *Environment, args []*Object) (*Object, error) {
+	// (hash-table-count hash-table)
+	h, err := hashTableValue("hash-table-count", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return newFixnum(int64(len(h.entries))), nil
+}
+
+func builtinHashTableTest(_ *Environment, args []*Object) (*Object, error) {
+	// (hash-table-test hash-table)
+	h, err := hashTableValue("hash-table-test", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return newSymbol(h.test.name), nil
+}
+
+func builtinHashTableP(_ *Environment, args []*Object) (*Object, error) {
+	// (hash-table-p obj)
+	if args[0].kind == HashTableType {
+		return tObj, nil
+	}
+
+	return nilObj, nil
+}
+
+func specialWithHashTableIterator(env *Environment, args []*Object) (*Object, error) {
+	// (with-hash-table-iterator (name hash-table) body...)
+	spec := noEvalArguments(args[0])
+	if len(spec) != 2 {
+		return nil, &ErrUnsupportedArgumentType{function: "with-hash-table-iterator", argument: args[0]}
+	}
+
+	if _, ok := spec[0].value.(*Symbol); !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "with-hash-table-iterator", argument: spec[0]}
+	}
+
+	table, err := spec[1].Eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := hashTableValue("with-hash-table-iterator", table)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := h.snapshot()
+	index := 0
+	next := func(env *Environment, _ []*Object) (*Object, error) {
+		if index >= len(entries) {
+			return env.setValues([]*Object{nilObj}), nil
+		}
+
+		e := entries[index]
+		index++
+		return env.setValues([]*Object{tObj, e.key, e.value}), nil
+	}
+
+	frame := &Frame{}
+	frame.addFunctionBinding(spec[0], newBuiltinFunction(next, 0, false))
+	env.pushFrame(frame)
+	defer env.popFrame(1)
+
+	return evalBody(env, args[1:])
+}
+
+func initHashTableFunctions() {
+	installBuiltinFunction("make-hash-table", builtinMakeHashTable, 0, true)
+	installBuiltinFunction("gethash", builtinGethash, 2, true)
+	installBuiltinFunction("remhash", builtinRemhash, 2, false)
+	installBuiltinFunction("clrhash", builtinClrhash, 1, false)
+	installBuiltinFunction("maphash", builtinMaphash, 2, false)
+	installBuiltinFunction("hash-table-count", builtinHashTableCount, 1, false)
+	installBuiltinFunction("hash-table-test", builtinHashTableTest, 1, false)
+	installBuiltinFunction("hash-table-p", builtinHashTableP, 1, false)
+
+	installSetfFunction("gethash", setfGethash)
+
+	installSpecialForm("with-hash-table-iterator", specialWithHashTableIterator, 1, true)
+}
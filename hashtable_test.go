@@ -0,0 +1,169 @@
+package banglisp
+
+import (
+	"testing"
+)
+
+func TestHashTable(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{
+			name: "gethash eql",
+			expr: `(let ((h (make-hash-table)))
+  (setf (gethash 'foo h) 10)
+  (gethash 'foo h))`,
+			want: "10",
+		},
+		{
+			name: "gethash missing with default",
+			expr: `(multiple-value-list (gethash 'foo (make-hash-table) 42))`,
+			want: "(42 nil)",
+		},
+		{
+			name: "gethash presence",
+			expr: `(let ((h (make-hash-table)))
+  (setf (gethash 'foo h) nil)
+  (multiple-value-list (gethash 'foo h)))`,
+			want: "(nil t)",
+		},
+		{
+			name: "equal test hashes on structure",
+			expr: `(let ((h (make-hash-table :test 'equal)))
+  (setf (gethash '(1 "two" 3) h) 'found)
+  (gethash (cons 1 (cons "two" (cons 3 nil))) h))`,
+			want: "found",
+		},
+		{
+			name: "equal test hashes on pathname",
+			expr: `(let ((h (make-hash-table :test 'equal)))
+  (setf (gethash #P"/a/b" h) 1)
+  (gethash (pathname "/a/b") h))`,
+			want: "1",
+		},
+		{
+			name: "equalp test hashes on pathname",
+			expr: `(let ((h (make-hash-table :test 'equalp)))
+  (setf (gethash '(#P"/a/b.lisp") h) 1)
+  (gethash (list #P"/a/b.lisp") h))`,
+			want: "1",
+		},
+		{
+			name: "equal test with circular key",
+			expr: `(let ((h (make-hash-table :test 'equal)))
+  (setf (gethash '#1=(1 . #1#) h) 1)
+  (list (gethash '#2=(1 . #2#) h) (gethash '#3=(1 2 . #3#) h)))`,
+			want: "(1 nil)",
+		},
+		{
+			name: "equalp test with circular key",
+			expr: `(let ((h (make-hash-table :test 'equalp)))
+  (setf (gethash '#1=("a" . #1#) h) 1)
+  (gethash '#2=("A" "a" . #2#) h))`,
+			want: "1",
+		},
+		{
+			name: "equalp of circular structures",
+			expr: `(defstruct hnode next)
+(let ((a (make-hnode)) (b (make-hnode)))
+  (setf (hnode-next a) a (hnode-next b) b)
+  (equalp a b))`,
+			want: "t",
+		},
+		{
+			name: "eql test does not hash on structure",
+			expr: `(let ((h (make-hash-table :test 'eql)))
+  (setf (gethash "foo" h) 'found)
+  (gethash "foo" h))`,
+			want: "nil",
+		},
+		{
+			name: "equalp test ignores case and number type",
+			expr: `(let ((h (make-hash-table :test (function equalp))))
+  (setf (gethash "Foo" h) 1)
+  (setf (gethash 2 h) 2)
+  (+ (gethash "FOO" h) (gethash 2.0 h)))`,
+			want: "3",
+		},
+		{
+			name: "remhash",
+			expr: `(let ((h (make-hash-table)))
+  (setf (gethash 1 h) 1 (gethash 2 h) 2)
+  (remhash 1 h)
+  (list (hash-table-count h) (remhash 1 h)))`,
+			want: "(1 nil)",
+		},
+		{
+			name: "clrhash",
+			expr: `(let ((h (make-hash-table)))
+  (setf (gethash 1 h) 1 (gethash 2 h) 2)
+  (hash-table-count (clrhash h)))`,
+			want: "0",
+		},
+		{
+			name: "maphash",
+			expr: `(let ((h (make-hash-table)) (sum 0))
+  (setf (gethash 1 h) 10 (gethash 2 h) 20)
+  (maphash (lambda (k v) (setq sum (+ sum k v))) h)
+  sum)`,
+			want: "33",
+		},
+		{
+			name: "with-hash-table-iterator",
+			expr: `(let ((h (make-hash-table)))
+  (setf (gethash 'a h) 1)
+  (with-hash-table-iterator (next h)
+    (list (multiple-value-list (next)) (multiple-value-list (next)))))`,
+			want: "((t a 1) (nil))",
+		},
+		{
+			name: "with-hash-table-iterator binds name locally",
+			expr: `(defun hti-first (x) (car x))
+(let ((h (make-hash-table)))
+  (setf (gethash 'a h) 1)
+  (with-hash-table-iterator (car h)
+    (let ((entry (multiple-value-list (car))))
+      (list entry (hti-first '(x y)) (car)))))`,
+			want: "((t a 1) x nil)",
+		},
+		{
+			name: "with-hash-table-iterator is not visible from called functions",
+			expr: `(defun hti-next () 'global)
+(defun hti-call () (hti-next))
+(let ((h (make-hash-table)))
+  (setf (gethash 'a h) 1)
+  (with-hash-table-iterator (hti-next h)
+    (list (hti-call) (hti-next))))`,
+			want: "(global t)",
+		},
+		{
+			name: "print form",
+			expr: `(let ((h (make-hash-table :test 'equal)))
+  (setf (gethash "a" h) 1 (gethash 'b h) '(2 3))
+  h)`,
+			want: `#H(equal ("a" . 1) (b 2 3))`,
+		},
+		{
+			name: "read print form",
+			expr: `(gethash "a" #H(equal ("a" . 1) (b 2 3)))`,
+			want: "1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if err != nil {
+				t.Errorf("could not evaluate %s: %v", tt.expr, err)
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("%s => got: %v, expected %s", tt.expr, *got, tt.want)
+				return
+			}
+		})
+	}
+}
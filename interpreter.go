@@ -3,6 +3,7 @@ package banglisp
 import "math"
 
 var defaultPackage *Object
+var keywordPackage *Object
 var tObj *Object
 var nilObj *Object
 var emptyList *Object
@@ -24,6 +25,8 @@ func init() {
 
 	v.package_ = defaultPackage
 
+	keywordPackage = newPackage("KEYWORD")
+
 	tObj = newSymbol("t")
 	tv := tObj.value.(*Symbol)
 	tv.value = tObj
@@ -37,6 +40,20 @@ func init() {
 	initSpecialForm()
 	initBuiltinFunctions()
 	initNumberFunctions()
+	initBitwiseFunctions()
+	initFloatFunctions()
+	initDecimalFunctions()
+	initRandomFunctions()
+	initHashTableFunctions()
+	initStructureFunctions()
+	initStreamFunctions()
+	initCharacterFunctions()
+	initReadtableFunctions()
+	initPrintFunctions()
+	initFormatFunctions()
+	initPathnameFunctions()
+	initPackageFunctions()
+	initClassFunctions()
 }
 
 func CurrentPackage() *Object {
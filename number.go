@@ -3,285 +3,1441 @@ package banglisp
 import (
 	"fmt"
 	"math"
+	"math/big"
+	"math/cmplx"
 )
 
+type arithmeticOp int
+
+const (
+	opAdd arithmeticOp = iota
+	opSub
+	opMul
+	opDiv
+)
+
+// numeric contagion ranks. An operation on two numbers is performed in the
+// higher rank of them.
+const (
+	rankInteger = iota
+	rankRatio
+	rankDecimal
+	rankFloat
+	rankComplex
+)
+
+func numberRank(obj *Object) (int, bool) {
+	switch obj.kind {
+	case FixnumType, BignumType:
+		return rankInteger, true
+	case RatioType:
+		return rankRatio, true
+	case DecimalType:
+		return rankDecimal, true
+	case FloatType, SingleFloatType:
+		return rankFloat, true
+	case ComplexType:
+		return rankComplex, true
+	default:
+		return 0, false
+	}
+}
+
+func isReal(obj *Object) bool {
+	rank, ok := numberRank(obj)
+	return ok && rank != rankComplex
+}
+
+func complexValue(obj *Object) (complex128, bool) {
+	if v, ok := obj.value.(complex128); ok {
+		return v, true
+	}
+
+	f, _, err := floatValue(obj)
+	if err != nil {
+		return 0, false
+	}
+
+	return complex(f, 0), true
+}
+
+func isFloat(obj *Object) bool {
+	return obj.kind == FloatType || obj.kind == SingleFloatType
+}
+
+// isFiniteFloat reports whether obj is not an IEEE infinity or NaN float
+func isFiniteFloat(obj *Object) bool {
+	f, isFloat, _ := floatValue(obj)
+	return !isFloat || !math.IsInf(f, 0) && !math.IsNaN(f)
+}
+
+func isNaN(obj *Object) bool {
+	f, isFloat, _ := floatValue(obj)
+	return isFloat && math.IsNaN(f)
+}
+
+// floatFormat returns the float type of the result of an operation on
+// args. Double float is contagious and rationals are converted to double
+// float unless a single float is involved.
+func floatFormat(args ...*Object) objectType {
+	ret := FloatType
+	for _, arg := range args {
+		switch arg.kind {
+		case FloatType:
+			return FloatType
+		case SingleFloatType:
+			ret = SingleFloatType
+		}
+	}
+
+	return ret
+}
+
+// floatResult makes a float of kind from v and checks it against enabled
+// floating point traps. operands are used to tell overflow or invalid
+// operation from infinity or NaN propagation.
+func floatResult(name string, kind objectType, v float64, operands ...float64) (*Object, error) {
+	if kind == SingleFloatType {
+		v = float64(float32(v))
+	}
+
+	if err := checkFloatResult(name, v, operands...); err != nil {
+		return nil, err
+	}
+
+	if kind == SingleFloatType {
+		return newSingleFloat(float32(v)), nil
+	}
+
+	return newFloat(v), nil
+}
+
+func isInteger(obj *Object) bool {
+	return obj.kind == FixnumType || obj.kind == BignumType
+}
+
 func floatValue(obj *Object) (float64, bool, error) {
 	switch v := obj.value.(type) {
 	case int64:
 		return float64(v), false, nil
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f, false, nil
+	case *big.Rat:
+		f, _ := v.Float64()
+		return f, false, nil
+	case *Decimal:
+		f, _ := v.rat().Float64()
+		return f, false, nil
 	case float64:
 		return v, true, nil
+	case float32:
+		return float64(v), true, nil
 	default:
 		return 0, false, fmt.Errorf("unsupported type")
 	}
 }
 
-func builtinAdd(_ *Environment, args []*Object) (*Object, error) {
-	// (+ n1 n2 ....)
-	if len(args) == 0 {
-		return newFixnum(0), nil
+// floatOperand converts real number obj to float for float contagion in
+// function name. An exact number beyond the float range signals
+// floating-point-overflow when the trap is enabled.
+func floatOperand(name string, obj *Object) (float64, error) {
+	f, isFloat, err := floatValue(obj)
+	if err != nil {
+		return 0, err
 	}
 
-	hasFloat := false
-	ret := 0.0
+	if !isFloat && math.IsInf(f, 0) && floatTrapEnabled("overflow") {
+		return 0, &ErrFloatingPointOverflow{function: name}
+	}
 
-	for _, arg := range args {
-		f, isFloat, err := floatValue(arg)
-		if err != nil {
-			return nil, &ErrUnsupportedArgumentType{"+", arg}
+	return f, nil
+}
+
+func isRational(obj *Object) bool {
+	return isInteger(obj) || obj.kind == RatioType
+}
+
+func ratValue(obj *Object) (*big.Rat, bool) {
+	switch v := obj.value.(type) {
+	case int64:
+		return new(big.Rat).SetInt64(v), true
+	case *big.Int:
+		return new(big.Rat).SetInt(v), true
+	case *big.Rat:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// newRational returns integer if v is an integer, otherwise ratio
+func newRational(v *big.Rat) *Object {
+	if v.IsInt() {
+		return newInteger(new(big.Int).Set(v.Num()))
+	}
+
+	return newRatio(v)
+}
+
+func bigIntValue(obj *Object) (*big.Int, bool) {
+	switch v := obj.value.(type) {
+	case int64:
+		return big.NewInt(v), true
+	case *big.Int:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// newInteger returns fixnum if v fits in it, otherwise bignum
+func newInteger(v *big.Int) *Object {
+	if v.IsInt64() {
+		return newFixnum(v.Int64())
+	}
+
+	return newBignum(v)
+}
+
+func fixnumArithmetic(op arithmeticOp, a int64, b int64) (int64, bool) {
+	switch op {
+	case opAdd:
+		ret := a + b
+		return ret, (a^ret)&(b^ret) >= 0
+	case opSub:
+		ret := a - b
+		return ret, (a^b)&(a^ret) >= 0
+	case opMul:
+		if a == 0 || b == 0 {
+			return 0, true
 		}
 
-		if !hasFloat && isFloat {
-			hasFloat = true
+		ret := a * b
+		if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) || ret/b != a {
+			return 0, false
+		}
+		return ret, true
+	default:
+		if (a == math.MinInt64 && b == -1) || a%b != 0 {
+			return 0, false
 		}
+		return a / b, true
+	}
+}
 
-		ret += f
+func integerArithmetic(op arithmeticOp, a *Object, b *Object) (*Object, error) {
+	if op == opDiv {
+		if isZero(b) {
+			return nil, &ErrDivisionByZero{function: "/"}
+		}
 	}
 
-	if hasFloat {
-		return newFloat(ret), nil
+	if x, ok := a.value.(int64); ok {
+		if y, ok := b.value.(int64); ok {
+			if ret, ok := fixnumArithmetic(op, x, y); ok {
+				return newFixnum(ret), nil
+			}
+		}
 	}
 
-	return newFixnum(int64(ret)), nil
+	if op == opDiv {
+		return ratioArithmetic(op, a, b)
+	}
+
+	x, _ := bigIntValue(a)
+	y, _ := bigIntValue(b)
+	ret := new(big.Int)
+	switch op {
+	case opAdd:
+		ret.Add(x, y)
+	case opSub:
+		ret.Sub(x, y)
+	default:
+		ret.Mul(x, y)
+	}
+
+	return newInteger(ret), nil
 }
 
-func builtinMinus(_ *Environment, args []*Object) (*Object, error) {
-	// (- n1 n2 ....)
-	ret, hasFloat, err := floatValue(args[0])
+func ratioArithmetic(op arithmeticOp, a *Object, b *Object) (*Object, error) {
+	if op == opDiv && isZero(b) {
+		return nil, &ErrDivisionByZero{function: "/"}
+	}
+
+	x, _ := ratValue(a)
+	y, _ := ratValue(b)
+	ret := new(big.Rat)
+	switch op {
+	case opAdd:
+		ret.Add(x, y)
+	case opSub:
+		ret.Sub(x, y)
+	case opMul:
+		ret.Mul(x, y)
+	default:
+		ret.Quo(x, y)
+	}
+
+	return newRational(ret), nil
+}
+
+func floatArithmetic(name string, op arithmeticOp, a *Object, b *Object) (*Object, error) {
+	x, err := floatOperand(name, a)
 	if err != nil {
-		return nil, &ErrUnsupportedArgumentType{"-", args[0]}
+		return nil, err
 	}
 
-	if len(args) == 1 {
-		if hasFloat {
-			return newFloat(ret * -1), nil
+	y, err := floatOperand(name, b)
+	if err != nil {
+		return nil, err
+	}
+
+	kind := floatFormat(a, b)
+	switch op {
+	case opAdd:
+		return floatResult(name, kind, x+y, x, y)
+	case opSub:
+		return floatResult(name, kind, x-y, x, y)
+	case opMul:
+		return floatResult(name, kind, x*y, x, y)
+	default:
+		if y == 0 && x != 0 && !math.IsNaN(x) {
+			if floatTrapEnabled("division-by-zero") {
+				return nil, &ErrDivisionByZero{function: name}
+			}
+
+			v := math.Inf(1)
+			if math.Signbit(x) != math.Signbit(y) {
+				v = math.Inf(-1)
+			}
+			return floatResult(name, kind, v, v)
 		}
+		return floatResult(name, kind, x/y, x, y)
+	}
+}
 
-		return newFixnum(int64(ret) * -1), nil
+func arithmetic(name string, op arithmeticOp, a *Object, b *Object) (*Object, error) {
+	ra, ok := numberRank(a)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: name, argument: a}
 	}
 
-	for _, arg := range args[1:] {
-		f, isFloat, err := floatValue(arg)
-		if err != nil {
-			return nil, &ErrUnsupportedArgumentType{"-", arg}
+	rb, ok := numberRank(b)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: name, argument: b}
+	}
+
+	rank := ra
+	if rb > rank {
+		rank = rb
+	}
+
+	switch rank {
+	case rankInteger:
+		return integerArithmetic(op, a, b)
+	case rankRatio:
+		return ratioArithmetic(op, a, b)
+	case rankDecimal:
+		return decimalArithmetic(name, op, a, b)
+	case rankFloat:
+		return floatArithmetic(name, op, a, b)
+	default:
+		return complexArithmetic(op, a, b)
+	}
+}
+
+func complexArithmetic(op arithmeticOp, a *Object, b *Object) (*Object, error) {
+	x, _ := complexValue(a)
+	y, _ := complexValue(b)
+	switch op {
+	case opAdd:
+		return newComplex(x + y), nil
+	case opSub:
+		return newComplex(x - y), nil
+	case opMul:
+		return newComplex(x * y), nil
+	default:
+		if y == 0 {
+			return nil, &ErrDivisionByZero{function: "/"}
 		}
+		return newComplex(x / y), nil
+	}
+}
 
-		if !hasFloat && isFloat {
-			hasFloat = true
+func isZero(obj *Object) bool {
+	switch v := obj.value.(type) {
+	case int64:
+		return v == 0
+	case *big.Int:
+		return v.Sign() == 0
+	case *big.Rat:
+		return v.Sign() == 0
+	case *Decimal:
+		return v.unscaled.Sign() == 0
+	case float64:
+		return v == 0
+	case float32:
+		return v == 0
+	case complex128:
+		return v == 0
+	default:
+		return false
+	}
+}
+
+func numberEqual(name string, a *Object, b *Object) (bool, error) {
+	if a.kind == ComplexType || b.kind == ComplexType {
+		x, ok := complexValue(a)
+		if !ok {
+			return false, &ErrUnsupportedArgumentType{function: name, argument: a}
 		}
 
-		ret -= f
+		y, ok := complexValue(b)
+		if !ok {
+			return false, &ErrUnsupportedArgumentType{function: name, argument: b}
+		}
+
+		return x == y, nil
 	}
 
-	if hasFloat {
-		return newFloat(ret), nil
+	if isNaN(a) || isNaN(b) {
+		if !isReal(a) {
+			return false, &ErrUnsupportedArgumentType{function: name, argument: a}
+		}
+		if !isReal(b) {
+			return false, &ErrUnsupportedArgumentType{function: name, argument: b}
+		}
+		return false, nil
 	}
 
-	return newFixnum(int64(ret)), nil
+	c, err := compareNumbers(name, a, b)
+	if err != nil {
+		return false, err
+	}
+
+	return c == 0, nil
 }
 
-func builtinMul(_ *Environment, args []*Object) (*Object, error) {
-	// (* n1 n2 ....)
-	if len(args) == 0 {
-		return newFixnum(1), nil
+// compareNumbers returns -1, 0 or 1 as a is less than, equal to or greater
+// than b
+func compareNumbers(name string, a *Object, b *Object) (int, error) {
+	ra, ok := numberRank(a)
+	if !ok || ra == rankComplex {
+		return 0, &ErrUnsupportedArgumentType{function: name, argument: a}
+	}
+
+	rb, ok := numberRank(b)
+	if !ok || rb == rankComplex {
+		return 0, &ErrUnsupportedArgumentType{function: name, argument: b}
+	}
+
+	if ra == rankInteger && rb == rankInteger {
+		if x, ok := a.value.(int64); ok {
+			if y, ok := b.value.(int64); ok {
+				switch {
+				case x < y:
+					return -1, nil
+				case x > y:
+					return 1, nil
+				default:
+					return 0, nil
+				}
+			}
+		}
+
+		x, _ := bigIntValue(a)
+		y, _ := bigIntValue(b)
+		return x.Cmp(y), nil
+	}
+
+	if ra == rankFloat && rb == rankFloat || !isFiniteFloat(a) || !isFiniteFloat(b) {
+		x, y := comparisonFloat(a), comparisonFloat(b)
+		switch {
+		case x < y:
+			return -1, nil
+		case x > y:
+			return 1, nil
+		default:
+			return 0, nil
+		}
 	}
 
-	hasFloat := false
-	ret := 1.0
+	// compare exactly by converting float to rational
+	return exactRational(a).Cmp(exactRational(b)), nil
+}
 
+// comparisonFloat converts real number to float for comparison with an
+// infinity or NaN. Exact numbers beyond the float range stay finite so that
+// they are still less than infinity.
+func comparisonFloat(obj *Object) float64 {
+	f, isFloat, _ := floatValue(obj)
+	if !isFloat && math.IsInf(f, 0) {
+		return math.Copysign(math.MaxFloat64, f)
+	}
+
+	return f
+}
+
+// exactRational converts real number to rational without loss
+func exactRational(obj *Object) *big.Rat {
+	if f, isFloat, _ := floatValue(obj); isFloat {
+		return new(big.Rat).SetFloat64(f)
+	}
+
+	if d, ok := obj.value.(*Decimal); ok {
+		return d.rat()
+	}
+
+	r, _ := ratValue(obj)
+	return r
+}
+
+func builtinAdd(_ *Environment, args []*Object) (*Object, error) {
+	// (+ n1 n2 ....)
+	var ret = newFixnum(0)
+	var err error
 	for _, arg := range args {
-		f, isFloat, err := floatValue(arg)
+		ret, err = arithmetic("+", opAdd, ret, arg)
 		if err != nil {
-			return nil, &ErrUnsupportedArgumentType{"*", arg}
+			return nil, err
 		}
+	}
 
-		if !hasFloat && isFloat {
-			hasFloat = true
-		}
+	return ret, nil
+}
 
-		ret *= f
+func builtinMinus(_ *Environment, args []*Object) (*Object, error) {
+	// (- n1 n2 ....)
+	if len(args) == 1 {
+		return arithmetic("-", opSub, newFixnum(0), args[0])
+	}
+
+	ret := args[0]
+	var err error
+	for _, arg := range args[1:] {
+		ret, err = arithmetic("-", opSub, ret, arg)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	if hasFloat {
-		return newFloat(ret), nil
+	return ret, nil
+}
+
+func builtinMul(_ *Environment, args []*Object) (*Object, error) {
+	// (* n1 n2 ....)
+	var ret = newFixnum(1)
+	var err error
+	for _, arg := range args {
+		ret, err = arithmetic("*", opMul, ret, arg)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return newFixnum(int64(ret)), nil
+	return ret, nil
 }
 
 func builtinDiv(_ *Environment, args []*Object) (*Object, error) {
 	// (/ n1 n2 ....)
-	ret, hasFloat, err := floatValue(args[0])
-	if err != nil {
-		return nil, &ErrUnsupportedArgumentType{"/", args[0]}
+	if len(args) == 1 {
+		return arithmetic("/", opDiv, newFixnum(1), args[0])
 	}
 
+	ret := args[0]
+	var err error
 	for _, arg := range args[1:] {
-		f, isFloat, err := floatValue(arg)
+		ret, err = arithmetic("/", opDiv, ret, arg)
 		if err != nil {
-			return nil, &ErrUnsupportedArgumentType{"/", arg}
+			return nil, err
 		}
+	}
+
+	return ret, nil
+}
+
+type roundingMode int
+
+const (
+	roundFloor roundingMode = iota
+	roundCeiling
+	roundTruncate
+	roundNearest // half to even
+
+	// used only by decimal arithmetic
+	roundUp
+	roundHalfUp
+	roundHalfDown
+)
 
-		if !hasFloat && isFloat {
-			hasFloat = true
+// roundRational rounds r to an integer. roundNearest rounds to even on ties.
+func roundRational(mode roundingMode, r *big.Rat) *big.Int {
+	return roundQuotient(r.Num(), r.Denom(), mode)
+}
+
+func roundFloat(mode roundingMode, v float64) float64 {
+	switch mode {
+	case roundFloor:
+		return math.Floor(v)
+	case roundCeiling:
+		return math.Ceil(v)
+	case roundTruncate:
+		return math.Trunc(v)
+	default:
+		return math.RoundToEven(v)
+	}
+}
+
+// divide returns quotient and remainder of n / d. Quotient is always integer
+// and remainder is n - quotient * d.
+func divide(name string, mode roundingMode, n *Object, d *Object) (*Object, *Object, error) {
+	if !isReal(n) {
+		return nil, nil, &ErrUnsupportedArgumentType{function: name, argument: n}
+	}
+
+	if !isReal(d) {
+		return nil, nil, &ErrUnsupportedArgumentType{function: name, argument: d}
+	}
+
+	if isZero(d) {
+		return nil, nil, &ErrDivisionByZero{function: name}
+	}
+
+	var q *Object
+	if !isFloat(n) && !isFloat(d) {
+		x := exactRational(n)
+		y := exactRational(d)
+		q = newInteger(roundRational(mode, new(big.Rat).Quo(x, y)))
+	} else {
+		x, _, _ := floatValue(n)
+		y, _, _ := floatValue(d)
+		f := roundFloat(mode, x/y)
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, nil, &ErrUnsupportedArgumentType{function: name, argument: n}
 		}
 
-		ret /= f
+		i, _ := big.NewFloat(f).Int(nil)
+		q = newInteger(i)
+	}
+
+	p, err := arithmetic(name, opMul, q, d)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if hasFloat {
-		return newFloat(ret), nil
+	r, err := arithmetic(name, opSub, n, p)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return newFixnum(int64(ret)), nil
+	return q, r, nil
+}
+
+func divisionFunction(name string, mode roundingMode) builtinFunctionType {
+	return func(env *Environment, args []*Object) (*Object, error) {
+		// (floor number &optional divisor)
+		if len(args) > 2 {
+			return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+		}
+
+		d := newFixnum(1)
+		if len(args) == 2 {
+			d = args[1]
+		}
+
+		q, r, err := divide(name, mode, args[0], d)
+		if err != nil {
+			return nil, err
+		}
+
+		return env.setValues([]*Object{q, r}), nil
+	}
 }
 
 func builtinMod(_ *Environment, args []*Object) (*Object, error) {
-	// (% n1 n2 ....)
-	var ret int64
-	switch v := args[0].value.(type) {
-	case int64:
-		ret = v
-	default:
-		return nil, &ErrUnsupportedArgumentType{"mod", args[0]}
+	// (mod number divisor)
+	_, r, err := divide("mod", roundFloor, args[0], args[1])
+	return r, err
+}
+
+func builtinRem(_ *Environment, args []*Object) (*Object, error) {
+	// (rem number divisor)
+	_, r, err := divide("rem", roundTruncate, args[0], args[1])
+	return r, err
+}
+
+func integerArguments(name string, args []*Object) ([]*big.Int, error) {
+	ret := make([]*big.Int, len(args))
+	for i, arg := range args {
+		v, ok := bigIntValue(arg)
+		if !ok {
+			return nil, &ErrUnsupportedArgumentType{function: name, argument: arg}
+		}
+		ret[i] = new(big.Int).Abs(v)
+	}
+
+	return ret, nil
+}
+
+func builtinGcd(_ *Environment, args []*Object) (*Object, error) {
+	// (gcd &rest integers)
+	ints, err := integerArguments("gcd", args)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := new(big.Int)
+	for _, v := range ints {
+		ret.GCD(nil, nil, ret, v)
+	}
+
+	return newInteger(ret), nil
+}
+
+func builtinLcm(_ *Environment, args []*Object) (*Object, error) {
+	// (lcm &rest integers)
+	ints, err := integerArguments("lcm", args)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := big.NewInt(1)
+	for _, v := range ints {
+		if v.Sign() == 0 {
+			return newFixnum(0), nil
+		}
+
+		g := new(big.Int).GCD(nil, nil, ret, v)
+		ret.Mul(ret, new(big.Int).Quo(v, g))
+	}
+
+	return newInteger(ret), nil
+}
+
+// compareResult checks that pred holds for every adjacent pair of args
+func compareResult(name string, args []*Object, pred func(int) bool) (*Object, error) {
+	for _, arg := range args {
+		if !isReal(arg) {
+			return nil, &ErrUnsupportedArgumentType{function: name, argument: arg}
+		}
+	}
+
+	for _, arg := range args {
+		if isNaN(arg) {
+			return nilObj, nil
+		}
+	}
+
+	for i := 1; i < len(args); i++ {
+		c, err := compareNumbers(name, args[i-1], args[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if !pred(c) {
+			return nilObj, nil
+		}
+	}
+
+	return tObj, nil
+}
+
+func builtinNumberEqual(_ *Environment, args []*Object) (*Object, error) {
+	// (= number &rest more-numbers)
+	for _, arg := range args {
+		if !isNumber(arg) {
+			return nil, &ErrUnsupportedArgumentType{function: "=", argument: arg}
+		}
+	}
+
+	for i := 1; i < len(args); i++ {
+		eq, err := numberEqual("=", args[i-1], args[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if !eq {
+			return nilObj, nil
+		}
+	}
+
+	return tObj, nil
+}
+
+func builtinNumberNotEqual(_ *Environment, args []*Object) (*Object, error) {
+	// (/= number &rest more-numbers)
+	for _, arg := range args {
+		if !isNumber(arg) {
+			return nil, &ErrUnsupportedArgumentType{function: "/=", argument: arg}
+		}
+	}
+
+	for i := 0; i < len(args); i++ {
+		for j := i + 1; j < len(args); j++ {
+			eq, err := numberEqual("/=", args[i], args[j])
+			if err != nil {
+				return nil, err
+			}
+
+			if eq {
+				return nilObj, nil
+			}
+		}
+	}
+
+	return tObj, nil
+}
+
+func builtinLessThan(_ *Environment, args []*Object) (*Object, error) {
+	// (< real &rest more-reals)
+	return compareResult("<", args, func(c int) bool { return c < 0 })
+}
+
+func builtinLessThanEqual(_ *Environment, args []*Object) (*Object, error) {
+	// (<= real &rest more-reals)
+	return compareResult("<=", args, func(c int) bool { return c <= 0 })
+}
+
+func builtinGreaterThan(_ *Environment, args []*Object) (*Object, error) {
+	// (> real &rest more-reals)
+	return compareResult(">", args, func(c int) bool { return c > 0 })
+}
+
+func builtinGreaterThanEqual(_ *Environment, args []*Object) (*Object, error) {
+	// (>= real &rest more-reals)
+	return compareResult(">=", args, func(c int) bool { return c >= 0 })
+}
+
+func extremum(name string, args []*Object, pred func(int) bool) (*Object, error) {
+	ret := args[0]
+	if !isReal(ret) {
+		return nil, &ErrUnsupportedArgumentType{function: name, argument: ret}
 	}
 
 	for _, arg := range args[1:] {
-		switch v := arg.value.(type) {
-		case int64:
-			ret %= v
-		default:
-			return nil, &ErrUnsupportedArgumentType{"mod", arg}
+		c, err := compareNumbers(name, arg, ret)
+		if err != nil {
+			return nil, err
+		}
+
+		if pred(c) {
+			ret = arg
 		}
 	}
 
-	return newFixnum(ret), nil
+	return ret, nil
+}
+
+func builtinMin(_ *Environment, args []*Object) (*Object, error) {
+	// (min real &rest more-reals)
+	return extremum("min", args, func(c int) bool { return c < 0 })
+}
+
+func builtinMax(_ *Environment, args []*Object) (*Object, error) {
+	// (max real &rest more-reals)
+	return extremum("max", args, func(c int) bool { return c > 0 })
 }
 
-func builtinEqual(_ *Environment, args []*Object) (*Object, error) {
-	v1, t1, err1 := floatValue(args[0])
-	if err1 != nil {
-		return nil, err1
+func builtinOnePlus(_ *Environment, args []*Object) (*Object, error) {
+	// (1+ number)
+	return arithmetic("1+", opAdd, args[0], newFixnum(1))
+}
+
+func builtinOneMinus(_ *Environment, args []*Object) (*Object, error) {
+	// (1- number)
+	return arithmetic("1-", opSub, args[0], newFixnum(1))
+}
+
+func builtinSignum(_ *Environment, args []*Object) (*Object, error) {
+	// (signum number)
+	if f, isFloat, _ := floatValue(args[0]); isFloat {
+		if f == 0 || math.IsNaN(f) {
+			return args[0], nil
+		}
+		return floatResult("signum", args[0].kind, math.Copysign(1, f))
+	}
+
+	switch v := args[0].value.(type) {
+	case complex128:
+		if v == 0 {
+			return args[0], nil
+		}
+		return newComplex(v / complex(cmplx.Abs(v), 0)), nil
+	case *Decimal:
+		return newDecimal(big.NewInt(int64(v.unscaled.Sign())), 0), nil
 	}
 
-	v2, t2, err2 := floatValue(args[1])
-	if err2 != nil {
-		return nil, err2
+	r, ok := ratValue(args[0])
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "signum", argument: args[0]}
 	}
 
-	const epsilon = 0.00000001
-	if t1 || t2 {
-		if math.Abs(v1-v2) < epsilon {
+	return newFixnum(int64(r.Sign())), nil
+}
+
+func numberPredicate(name string, accept func(*Object) bool, pred func(*Object) bool) builtinFunctionType {
+	return func(_ *Environment, args []*Object) (*Object, error) {
+		if !accept(args[0]) {
+			return nil, &ErrUnsupportedArgumentType{function: name, argument: args[0]}
+		}
+
+		if pred(args[0]) {
 			return tObj, nil
 		}
 
 		return nilObj, nil
 	}
+}
 
-	if int64(v1) == int64(v2) {
-		return tObj, nil
+func sign(obj *Object) int {
+	if f, isFloat, _ := floatValue(obj); isFloat {
+		switch {
+		case f > 0:
+			return 1
+		case f < 0:
+			return -1
+		default:
+			return 0
+		}
 	}
 
-	return nilObj, nil
+	return exactRational(obj).Sign()
 }
 
-func builtinLessThan(_ *Environment, args []*Object) (*Object, error) {
-	v1, _, err1 := floatValue(args[0])
-	if err1 != nil {
-		return nil, err1
+func isEven(obj *Object) bool {
+	v, _ := bigIntValue(obj)
+	return v.Bit(0) == 0
+}
+
+func typePredicate(pred func(*Object) bool) builtinFunctionType {
+	return func(_ *Environment, args []*Object) (*Object, error) {
+		if pred(args[0]) {
+			return tObj, nil
+		}
+
+		return nilObj, nil
 	}
+}
 
-	v2, _, err2 := floatValue(args[1])
-	if err2 != nil {
-		return nil, err2
+func builtinNumerator(_ *Environment, args []*Object) (*Object, error) {
+	// (numerator rational)
+	r, ok := ratValue(args[0])
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "numerator", argument: args[0]}
 	}
 
-	if v1 < v2 {
-		return tObj, nil
+	return newInteger(new(big.Int).Set(r.Num())), nil
+}
+
+func builtinDenominator(_ *Environment, args []*Object) (*Object, error) {
+	// (denominator rational)
+	r, ok := ratValue(args[0])
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "denominator", argument: args[0]}
 	}
 
-	return nilObj, nil
+	return newInteger(new(big.Int).Set(r.Denom())), nil
 }
 
-func builtinLessThanEqual(_ *Environment, args []*Object) (*Object, error) {
-	v1, _, err1 := floatValue(args[0])
-	if err1 != nil {
-		return nil, err1
+func builtinRational(_ *Environment, args []*Object) (*Object, error) {
+	// (rational real)
+	if isRational(args[0]) {
+		return args[0], nil
 	}
 
-	v2, _, err2 := floatValue(args[1])
-	if err2 != nil {
-		return nil, err2
+	if d, ok := args[0].value.(*Decimal); ok {
+		return newRational(d.rat()), nil
 	}
 
-	if v1 < v2 {
-		return tObj, nil
+	f, isFloat, _ := floatValue(args[0])
+	if !isFloat || math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, &ErrUnsupportedArgumentType{function: "rational", argument: args[0]}
 	}
 
-	return nilObj, nil
+	return newRational(new(big.Rat).SetFloat64(f)), nil
 }
 
-func builtinGreaterThan(_ *Environment, args []*Object) (*Object, error) {
-	v1, _, err1 := floatValue(args[0])
-	if err1 != nil {
-		return nil, err1
+// rationalize returns the simplest rational which converts back to f. It
+// walks the convergents of the continued fraction of f.
+func rationalize(f float64) *big.Rat {
+	return rationalizeWith(f, func(r *big.Rat) bool {
+		v, _ := r.Float64()
+		return v == f
+	})
+}
+
+func rationalizeSingle(f float32) *big.Rat {
+	return rationalizeWith(float64(f), func(r *big.Rat) bool {
+		v, _ := r.Float32()
+		return v == f
+	})
+}
+
+func rationalizeWith(f float64, same func(*big.Rat) bool) *big.Rat {
+	x := new(big.Rat).SetFloat64(f)
+	if x.IsInt() {
+		return x
 	}
 
-	v2, _, err2 := floatValue(args[1])
-	if err2 != nil {
-		return nil, err2
+	// convergents h(n)/k(n)
+	h0, h1 := big.NewInt(0), big.NewInt(1)
+	k0, k1 := big.NewInt(1), big.NewInt(0)
+	rest := new(big.Rat).Set(x)
+	for {
+		a := new(big.Int).Div(rest.Num(), rest.Denom())
+
+		h2 := new(big.Int).Add(new(big.Int).Mul(a, h1), h0)
+		k2 := new(big.Int).Add(new(big.Int).Mul(a, k1), k0)
+		h0, h1 = h1, h2
+		k0, k1 = k1, k2
+
+		ret := new(big.Rat).SetFrac(h1, k1)
+		if same(ret) {
+			return ret
+		}
+
+		rest.Sub(rest, new(big.Rat).SetInt(a))
+		if rest.Sign() == 0 {
+			return ret
+		}
+		rest.Inv(rest)
+	}
+}
+
+func builtinRationalize(_ *Environment, args []*Object) (*Object, error) {
+	// (rationalize real)
+	if isRational(args[0]) {
+		return args[0], nil
+	}
+
+	if d, ok := args[0].value.(*Decimal); ok {
+		return newRational(d.rat()), nil
 	}
 
-	if v1 > v2 {
-		return tObj, nil
+	f, isFloat, _ := floatValue(args[0])
+	if !isFloat || math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, &ErrUnsupportedArgumentType{function: "rationalize", argument: args[0]}
 	}
 
-	return nilObj, nil
+	if args[0].kind == SingleFloatType {
+		return newRational(rationalizeSingle(float32(f))), nil
+	}
+
+	return newRational(rationalize(f)), nil
 }
 
-func builtinGreaterThanEqual(_ *Environment, args []*Object) (*Object, error) {
-	v1, _, err1 := floatValue(args[0])
-	if err1 != nil {
-		return nil, err1
+func builtinComplex(_ *Environment, args []*Object) (*Object, error) {
+	// (complex realpart &optional imagpart)
+	if len(args) > 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
 	}
 
-	v2, _, err2 := floatValue(args[1])
-	if err2 != nil {
-		return nil, err2
+	for _, arg := range args {
+		if !isReal(arg) {
+			return nil, &ErrUnsupportedArgumentType{function: "complex", argument: arg}
+		}
 	}
 
-	if v1 >= v2 {
-		return tObj, nil
+	if len(args) == 1 || (isRational(args[1]) && isZero(args[1])) {
+		if isRational(args[0]) {
+			return args[0], nil
+		}
 	}
 
-	return nilObj, nil
+	re, _, _ := floatValue(args[0])
+	im := 0.0
+	if len(args) == 2 {
+		im, _, _ = floatValue(args[1])
+	}
+
+	return newComplex(complex(re, im)), nil
 }
 
-func builtinSin(_ *Environment, args []*Object) (*Object, error) {
-	v, _, err := floatValue(args[0])
-	if err != nil {
-		return nil, err
+func builtinRealpart(_ *Environment, args []*Object) (*Object, error) {
+	// (realpart number)
+	if v, ok := args[0].value.(complex128); ok {
+		return newFloat(real(v)), nil
+	}
+
+	if !isReal(args[0]) {
+		return nil, &ErrUnsupportedArgumentType{function: "realpart", argument: args[0]}
+	}
+
+	return args[0], nil
+}
+
+func builtinImagpart(_ *Environment, args []*Object) (*Object, error) {
+	// (imagpart number)
+	switch v := args[0].value.(type) {
+	case complex128:
+		return newFloat(imag(v)), nil
+	case float64:
+		return newFloat(0), nil
+	case float32:
+		return newSingleFloat(0), nil
+	}
+
+	if !isReal(args[0]) {
+		return nil, &ErrUnsupportedArgumentType{function: "imagpart", argument: args[0]}
+	}
+
+	return newFixnum(0), nil
+}
+
+func builtinConjugate(_ *Environment, args []*Object) (*Object, error) {
+	// (conjugate number)
+	if v, ok := args[0].value.(complex128); ok {
+		return newComplex(cmplx.Conj(v)), nil
+	}
+
+	if !isReal(args[0]) {
+		return nil, &ErrUnsupportedArgumentType{function: "conjugate", argument: args[0]}
+	}
+
+	return args[0], nil
+}
+
+func builtinPhase(_ *Environment, args []*Object) (*Object, error) {
+	// (phase number)
+	v, ok := complexValue(args[0])
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "phase", argument: args[0]}
+	}
+
+	if isReal(args[0]) {
+		return floatResult("phase", floatFormat(args[0]), cmplx.Phase(v))
+	}
+
+	return newFloat(cmplx.Phase(v)), nil
+}
+
+func builtinCis(_ *Environment, args []*Object) (*Object, error) {
+	// (cis radians)
+	if !isReal(args[0]) {
+		return nil, &ErrUnsupportedArgumentType{function: "cis", argument: args[0]}
+	}
+
+	v, _, _ := floatValue(args[0])
+	return newComplex(cmplx.Rect(1, v)), nil
+}
+
+func builtinAbs(_ *Environment, args []*Object) (*Object, error) {
+	// (abs number)
+	switch v := args[0].value.(type) {
+	case int64:
+		if v < 0 {
+			return arithmetic("abs", opSub, newFixnum(0), args[0])
+		}
+		return args[0], nil
+	case *big.Int:
+		return newInteger(new(big.Int).Abs(v)), nil
+	case *big.Rat:
+		return newRational(new(big.Rat).Abs(v)), nil
+	case *Decimal:
+		return newDecimal(new(big.Int).Abs(v.unscaled), v.scale), nil
+	case float64:
+		return newFloat(math.Abs(v)), nil
+	case float32:
+		return newSingleFloat(float32(math.Abs(float64(v)))), nil
+	case complex128:
+		return newFloat(cmplx.Abs(v)), nil
+	default:
+		return nil, &ErrUnsupportedArgumentType{function: "abs", argument: args[0]}
+	}
+}
+
+// realOrComplex returns real float if v has no imaginary part and the
+// argument was real
+func realOrComplex(name string, v complex128, kind objectType, argIsReal bool, operands ...float64) (*Object, error) {
+	if argIsReal && imag(v) == 0 {
+		return floatResult(name, kind, real(v), operands...)
+	}
+
+	return newComplex(v), nil
+}
+
+func builtinSqrt(_ *Environment, args []*Object) (*Object, error) {
+	// (sqrt number)
+	if isReal(args[0]) {
+		v, err := floatOperand("sqrt", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if v >= 0 {
+			return floatResult("sqrt", floatFormat(args[0]), math.Sqrt(v), v)
+		}
+	}
+
+	v, ok := complexValue(args[0])
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "sqrt", argument: args[0]}
+	}
+
+	return newComplex(cmplx.Sqrt(v)), nil
+}
+
+func builtinIsqrt(_ *Environment, args []*Object) (*Object, error) {
+	// (isqrt natural)
+	v, ok := bigIntValue(args[0])
+	if !ok || v.Sign() < 0 {
+		return nil, &ErrUnsupportedArgumentType{function: "isqrt", argument: args[0]}
+	}
+
+	return newInteger(new(big.Int).Sqrt(v)), nil
+}
+
+func builtinExp(_ *Environment, args []*Object) (*Object, error) {
+	// (exp number)
+	if isReal(args[0]) {
+		v, err := floatOperand("exp", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return floatResult("exp", floatFormat(args[0]), math.Exp(v), v)
+	}
+
+	v, ok := complexValue(args[0])
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "exp", argument: args[0]}
+	}
+
+	return newComplex(cmplx.Exp(v)), nil
+}
+
+func logarithm(obj *Object) (complex128, bool, error) {
+	if isReal(obj) {
+		v, err := floatOperand("log", obj)
+		if err != nil {
+			return 0, false, err
+		}
+
+		if v > 0 {
+			return complex(math.Log(v), 0), true, nil
+		}
+	}
+
+	v, ok := complexValue(obj)
+	if !ok {
+		return 0, false, &ErrUnsupportedArgumentType{function: "log", argument: obj}
+	}
+
+	if v == 0 {
+		if floatTrapEnabled("division-by-zero") {
+			return 0, false, &ErrDivisionByZero{function: "log"}
+		}
+		return complex(math.Inf(-1), 0), true, nil
 	}
 
-	return newFloat(math.Sin(v)), nil
+	return cmplx.Log(v), false, nil
 }
 
-func builtinCos(_ *Environment, args []*Object) (*Object, error) {
-	v, _, err := floatValue(args[0])
+func builtinLog(_ *Environment, args []*Object) (*Object, error) {
+	// (log number &optional base)
+	if len(args) > 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+	}
+
+	v, isRealResult, err := logarithm(args[0])
 	if err != nil {
 		return nil, err
 	}
 
-	return newFloat(math.Cos(v)), nil
+	if len(args) == 2 {
+		base, baseIsReal, err := logarithm(args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		if base == 0 {
+			return nil, &ErrDivisionByZero{function: "log"}
+		}
+
+		if isRealResult && baseIsReal {
+			v = complex(real(v)/real(base), 0)
+		} else {
+			v /= base
+		}
+		isRealResult = isRealResult && baseIsReal
+	}
+
+	return realOrComplex("log", v, floatFormat(args...), isRealResult, real(v))
 }
 
-func builtinTan(_ *Environment, args []*Object) (*Object, error) {
-	v, _, err := floatValue(args[0])
+// complexIntegerPower computes z**n by repeated squaring so that results
+// such as i**2 stay exact
+func complexIntegerPower(z complex128, n int64) complex128 {
+	if n < 0 {
+		return 1 / complexIntegerPower(z, -n)
+	}
+
+	ret := complex(1, 0)
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			ret *= z
+		}
+		z *= z
+	}
+
+	return ret
+}
+
+func builtinExpt(_ *Environment, args []*Object) (*Object, error) {
+	// (expt base power)
+	base := args[0]
+	power := args[1]
+	if !isNumber(base) {
+		return nil, &ErrUnsupportedArgumentType{function: "expt", argument: base}
+	}
+
+	if isInteger(power) {
+		if isRational(base) {
+			// exact power
+			p, _ := bigIntValue(power)
+			if p.Sign() < 0 && isZero(base) {
+				return nil, &ErrDivisionByZero{function: "expt"}
+			}
+
+			r, _ := ratValue(base)
+			e := new(big.Int).Abs(p)
+			num := new(big.Int).Exp(r.Num(), e, nil)
+			den := new(big.Int).Exp(r.Denom(), e, nil)
+			if p.Sign() < 0 {
+				num, den = den, num
+			}
+			return newRational(new(big.Rat).SetFrac(num, den)), nil
+		}
+
+		if base.kind == DecimalType {
+			p, _ := bigIntValue(power)
+			return decimalPower(base, p)
+		}
+
+		if f, isFloat, _ := floatValue(base); isFloat {
+			p, _, _ := floatValue(power)
+			return floatResult("expt", base.kind, math.Pow(f, p), f)
+		}
+
+		if b, ok := base.value.(complex128); ok {
+			if p, ok := power.value.(int64); ok {
+				return newComplex(complexIntegerPower(b, p)), nil
+			}
+		}
+	}
+
+	if isReal(base) && isReal(power) {
+		b, err := floatOperand("expt", base)
+		if err != nil {
+			return nil, err
+		}
+
+		p, err := floatOperand("expt", power)
+		if err != nil {
+			return nil, err
+		}
+
+		if b >= 0 {
+			return floatResult("expt", floatFormat(base, power), math.Pow(b, p), b, p)
+		}
+	}
+
+	b, _ := complexValue(base)
+	p, ok := complexValue(power)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "expt", argument: power}
+	}
+
+	if b == 0 {
+		if p == 0 {
+			return newFixnum(1), nil
+		}
+		return newFloat(0), nil
+	}
+
+	return newComplex(cmplx.Pow(b, p)), nil
+}
+
+// transcendentalFunction returns a builtin that computes fn for real
+// arguments in domain and cfn for others
+func transcendentalFunction(name string, fn func(float64) float64, cfn func(complex128) complex128,
+	domain func(float64) bool) builtinFunctionType {
+	return func(_ *Environment, args []*Object) (*Object, error) {
+		if isReal(args[0]) {
+			v, err := floatOperand(name, args[0])
+			if err != nil {
+				return nil, err
+			}
+
+			if domain == nil || domain(v) {
+				return floatResult(name, floatFormat(args[0]), fn(v), v)
+			}
+		}
+
+		v, ok := complexValue(args[0])
+		if !ok {
+			return nil, &ErrUnsupportedArgumentType{function: name, argument: args[0]}
+		}
+
+		return newComplex(cfn(v)), nil
+	}
+}
+
+// complexAsin follows the branch cut of Common Lisp, where asin of a real
+// number above 1 is continuous with quadrant IV. cmplx.Asin follows the sign
+// of the zero imaginary part instead.
+func complexAsin(v complex128) complex128 {
+	ret := cmplx.Asin(v)
+	if imag(v) == 0 && real(v) > 1 {
+		return complex(real(ret), -math.Abs(imag(ret)))
+	}
+
+	return ret
+}
+
+// complexAcos follows the branch cut of Common Lisp, where acos is pi/2
+// minus asin, so acos of a real number above 1 has a positive imaginary part
+func complexAcos(v complex128) complex128 {
+	ret := cmplx.Acos(v)
+	if imag(v) == 0 && real(v) > 1 {
+		return complex(real(ret), math.Abs(imag(ret)))
+	}
+
+	return ret
+}
+
+func inUnitRange(v float64) bool {
+	return v >= -1 && v <= 1
+}
+
+func builtinAtan(env *Environment, args []*Object) (*Object, error) {
+	// (atan number1 &optional number2)
+	if len(args) > 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+	}
+
+	if len(args) == 1 {
+		return transcendentalFunction("atan", math.Atan, cmplx.Atan, nil)(env, args)
+	}
+
+	for _, arg := range args {
+		if !isReal(arg) {
+			return nil, &ErrUnsupportedArgumentType{function: "atan", argument: arg}
+		}
+	}
+
+	y, err := floatOperand("atan", args[0])
 	if err != nil {
 		return nil, err
 	}
 
-	return newFloat(math.Tan(v)), nil
+	x, err := floatOperand("atan", args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return floatResult("atan", floatFormat(args...), math.Atan2(y, x), y, x)
 }
 
 func initNumberFunctions() {
@@ -289,15 +1445,85 @@ func initNumberFunctions() {
 	installBuiltinFunction("-", builtinMinus, 1, true)
 	installBuiltinFunction("*", builtinMul, 0, true)
 	installBuiltinFunction("/", builtinDiv, 1, true)
-	installBuiltinFunction("mod", builtinMod, 1, true)
-
-	installBuiltinFunction("=", builtinEqual, 2, false)
-	installBuiltinFunction("<", builtinLessThan, 2, false)
-	installBuiltinFunction("<=", builtinLessThanEqual, 2, false)
-	installBuiltinFunction(">", builtinGreaterThan, 2, false)
-	installBuiltinFunction(">=", builtinGreaterThanEqual, 2, false)
-
-	installBuiltinFunction("sin", builtinSin, 1, false)
-	installBuiltinFunction("cos", builtinCos, 1, false)
-	installBuiltinFunction("tan", builtinTan, 1, false)
+	installBuiltinFunction("1+", builtinOnePlus, 1, false)
+	installBuiltinFunction("1-", builtinOneMinus, 1, false)
+
+	installBuiltinFunction("floor", divisionFunction("floor", roundFloor), 1, true)
+	installBuiltinFunction("ceiling", divisionFunction("ceiling", roundCeiling), 1, true)
+	installBuiltinFunction("truncate", divisionFunction("truncate", roundTruncate), 1, true)
+	installBuiltinFunction("round", divisionFunction("round", roundNearest), 1, true)
+	installBuiltinFunction("mod", builtinMod, 2, false)
+	installBuiltinFunction("rem", builtinRem, 2, false)
+	installBuiltinFunction("gcd", builtinGcd, 0, true)
+	installBuiltinFunction("lcm", builtinLcm, 0, true)
+
+	installBuiltinFunction("=", builtinNumberEqual, 1, true)
+	installBuiltinFunction("/=", builtinNumberNotEqual, 1, true)
+	installBuiltinFunction("<", builtinLessThan, 1, true)
+	installBuiltinFunction("<=", builtinLessThanEqual, 1, true)
+	installBuiltinFunction(">", builtinGreaterThan, 1, true)
+	installBuiltinFunction(">=", builtinGreaterThanEqual, 1, true)
+	installBuiltinFunction("min", builtinMin, 1, true)
+	installBuiltinFunction("max", builtinMax, 1, true)
+
+	installBuiltinFunction("zerop", numberPredicate("zerop", isNumber, isZero), 1, false)
+	installBuiltinFunction("plusp", numberPredicate("plusp", isReal, func(obj *Object) bool {
+		return sign(obj) > 0
+	}), 1, false)
+	installBuiltinFunction("minusp", numberPredicate("minusp", isReal, func(obj *Object) bool {
+		return sign(obj) < 0
+	}), 1, false)
+	installBuiltinFunction("evenp", numberPredicate("evenp", isInteger, isEven), 1, false)
+	installBuiltinFunction("oddp", numberPredicate("oddp", isInteger, func(obj *Object) bool {
+		return !isEven(obj)
+	}), 1, false)
+	installBuiltinFunction("signum", builtinSignum, 1, false)
+
+	installBuiltinFunction("numberp", typePredicate(isNumber), 1, false)
+	installBuiltinFunction("integerp", typePredicate(isInteger), 1, false)
+	installBuiltinFunction("rationalp", typePredicate(isRational), 1, false)
+	installBuiltinFunction("realp", typePredicate(isReal), 1, false)
+	installBuiltinFunction("floatp", typePredicate(func(obj *Object) bool {
+		return obj.kind == FloatType
+	}), 1, false)
+	installBuiltinFunction("complexp", typePredicate(func(obj *Object) bool {
+		return obj.kind == ComplexType
+	}), 1, false)
+
+	installBuiltinFunction("numerator", builtinNumerator, 1, false)
+	installBuiltinFunction("denominator", builtinDenominator, 1, false)
+	installBuiltinFunction("rational", builtinRational, 1, false)
+	installBuiltinFunction("rationalize", builtinRationalize, 1, false)
+
+	installBuiltinFunction("complex", builtinComplex, 1, true)
+	installBuiltinFunction("realpart", builtinRealpart, 1, false)
+	installBuiltinFunction("imagpart", builtinImagpart, 1, false)
+	installBuiltinFunction("conjugate", builtinConjugate, 1, false)
+	installBuiltinFunction("phase", builtinPhase, 1, false)
+	installBuiltinFunction("cis", builtinCis, 1, false)
+
+	installBuiltinFunction("abs", builtinAbs, 1, false)
+	installBuiltinFunction("sqrt", builtinSqrt, 1, false)
+	installBuiltinFunction("isqrt", builtinIsqrt, 1, false)
+	installBuiltinFunction("exp", builtinExp, 1, false)
+	installBuiltinFunction("log", builtinLog, 1, true)
+	installBuiltinFunction("expt", builtinExpt, 2, false)
+
+	installBuiltinFunction("sin", transcendentalFunction("sin", math.Sin, cmplx.Sin, nil), 1, false)
+	installBuiltinFunction("cos", transcendentalFunction("cos", math.Cos, cmplx.Cos, nil), 1, false)
+	installBuiltinFunction("tan", transcendentalFunction("tan", math.Tan, cmplx.Tan, nil), 1, false)
+	installBuiltinFunction("asin", transcendentalFunction("asin", math.Asin, complexAsin, inUnitRange), 1, false)
+	installBuiltinFunction("acos", transcendentalFunction("acos", math.Acos, complexAcos, inUnitRange), 1, false)
+	installBuiltinFunction("atan", builtinAtan, 1, true)
+
+	installBuiltinFunction("sinh", transcendentalFunction("sinh", math.Sinh, cmplx.Sinh, nil), 1, false)
+	installBuiltinFunction("cosh", transcendentalFunction("cosh", math.Cosh, cmplx.Cosh, nil), 1, false)
+	installBuiltinFunction("tanh", transcendentalFunction("tanh", math.Tanh, cmplx.Tanh, nil), 1, false)
+	installBuiltinFunction("asinh", transcendentalFunction("asinh", math.Asinh, cmplx.Asinh, nil), 1, false)
+	installBuiltinFunction("acosh", transcendentalFunction("acosh", math.Acosh, cmplx.Acosh, func(v float64) bool {
+		return v >= 1
+	}), 1, false)
+	installBuiltinFunction("atanh", transcendentalFunction("atanh", math.Atanh, cmplx.Atanh, func(v float64) bool {
+		return v > -1 && v < 1
+	}), 1, false)
 }
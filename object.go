@@ -2,16 +2,25 @@ package banglisp
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 	"os"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 type objectType int
 
 const (
 	FixnumType objectType = iota + 1
+	BignumType
+	RatioType
 	FloatType
+	SingleFloatType
+	DecimalType
+	ComplexType
+	CharacterType
 	StringType
 	SymbolType
 	PackageType
@@ -19,6 +28,15 @@ const (
 	SpecialFormType
 	BuiltinFunctionType
 	ClosureType
+	HashTableType
+	StructureType
+	StreamType
+	RandomStateType
+	ReadtableType
+	PathnameType
+	ClassType
+	InstanceType
+	GenericFunctionType
 )
 
 type Object struct {
@@ -36,8 +54,9 @@ type Symbol struct {
 }
 
 type Package struct {
-	name  *Object
-	table map[string]*Object
+	name     *Object
+	table    map[string]*Object
+	external map[string]bool
 }
 
 type ConsCell struct {
@@ -49,19 +68,51 @@ var objectID = 0
 
 func isAtom(obj *Object) bool {
 	switch obj.kind {
-	case FixnumType, FloatType, StringType, SymbolType:
+	case FixnumType, BignumType, RatioType, FloatType, SingleFloatType, DecimalType, ComplexType, CharacterType, StringType, SymbolType,
+		HashTableType, StructureType, StreamType, RandomStateType, ReadtableType, PathnameType, ClassType, InstanceType:
 		return true
 	default:
 		return false
 	}
 }
 
+func isKeyword(obj *Object) bool {
+	sym, ok := obj.value.(*Symbol)
+	return ok && sym.package_ != nil && sym.package_ == keywordPackage
+}
+
+func keywordName(obj *Object) (string, bool) {
+	if !isKeyword(obj) {
+		return "", false
+	}
+
+	return obj.value.(*Symbol).name.value.(string), true
+}
+
+// isConstant reports whether obj names a constant variable, which cannot be
+// set or bound
+func isConstant(obj *Object) bool {
+	return isKeyword(obj) || obj == tObj || isNull(obj)
+}
+
 func (o objectType) String() string {
 	switch o {
 	case FixnumType:
 		return "Fixnum"
+	case BignumType:
+		return "Bignum"
+	case RatioType:
+		return "Ratio"
+	case SingleFloatType:
+		return "SingleFloat"
+	case DecimalType:
+		return "Decimal"
+	case ComplexType:
+		return "Complex"
 	case FloatType:
 		return "Float"
+	case CharacterType:
+		return "Character"
 	case StringType:
 		return "String"
 	case SymbolType:
@@ -76,6 +127,24 @@ func (o objectType) String() string {
 		return "SpecialForm"
 	case ClosureType:
 		return "ClosureType"
+	case HashTableType:
+		return "HashTable"
+	case StructureType:
+		return "Structure"
+	case StreamType:
+		return "Stream"
+	case RandomStateType:
+		return "RandomState"
+	case ReadtableType:
+		return "Readtable"
+	case PathnameType:
+		return "Pathname"
+	case ClassType:
+		return "Class"
+	case InstanceType:
+		return "Instance"
+	case GenericFunctionType:
+		return "GenericFunction"
 	default:
 		return "UNKNOWN_TYPE"
 	}
@@ -83,14 +152,19 @@ func (o objectType) String() string {
 
 func (obj *Object) isSelfEvaluated() bool {
 	switch obj.kind {
-	case FixnumType, FloatType, StringType:
+	case FixnumType, BignumType, RatioType, FloatType, SingleFloatType, DecimalType, ComplexType, CharacterType, StringType,
+		HashTableType, StructureType, StreamType, RandomStateType, ReadtableType, PathnameType, ClassType, InstanceType:
 		return true
+	case SymbolType:
+		return isKeyword(obj)
 	default:
 		return false
 	}
 }
 
 func (obj *Object) Eval(env *Environment) (*Object, error) {
+	env.clearValues()
+
 	if obj.isSelfEvaluated() {
 		return obj, nil
 	}
@@ -102,7 +176,7 @@ func (obj *Object) Eval(env *Environment) (*Object, error) {
 			v := obj.value.(*Symbol)
 			if v.value == nil {
 				name := v.name.value.(string)
-				return nil, &ErrUnboundVariable{name}
+				return nil, &ErrUnboundVariable{name: name}
 			}
 
 			val = v.value
@@ -114,14 +188,20 @@ func (obj *Object) Eval(env *Environment) (*Object, error) {
 
 		car, ok := v.car.value.(*Symbol)
 		if !ok {
-			return nil, fmt.Errorf("first element of cons cell is not list: %v(%v)", *obj, obj.kind)
+			err := fmt.Errorf("first element of cons cell is not list: %v(%v)", *obj, obj.kind)
+			return nil, withSourceLocation(err, obj)
 		}
 
-		if isNull(car.function) {
-			return nil, fmt.Errorf("symbol '%v' does not have function", *car.name)
+		if _, ok := env.lookupFunction(v.car); !ok && isNull(car.function) {
+			return nil, withSourceLocation(fmt.Errorf("symbol '%v' does not have function", *car.name), obj)
+		}
+
+		ret, err := v.car.apply(v.cdr, env)
+		if err != nil {
+			return nil, withSourceLocation(err, obj)
 		}
 
-		return v.car.apply(v.cdr, env)
+		return ret, nil
 	default:
 		return nil, fmt.Errorf("unsupported eval type")
 	}
@@ -130,6 +210,10 @@ func (obj *Object) Eval(env *Environment) (*Object, error) {
 func (obj *Object) apply(args *Object, env *Environment) (*Object, error) {
 	switch obj.kind {
 	case SymbolType:
+		if fn, ok := env.lookupFunction(obj); ok {
+			return fn.apply(args, env)
+		}
+
 		car := obj.value.(*Symbol)
 		if car.function == nil {
 			return nil, fmt.Errorf("symbol '%v' does not have function", *car.name)
@@ -139,14 +223,8 @@ func (obj *Object) apply(args *Object, env *Environment) (*Object, error) {
 	case SpecialFormType:
 		form := obj.value.(*SpecialForm)
 		formArgs := noEvalArguments(args)
-		if form.variadic {
-			if len(formArgs) < form.arity {
-				return nil, &ErrWrongNumberArguments{true, form.arity, len(formArgs)}
-			}
-		} else {
-			if len(formArgs) != form.arity {
-				return nil, &ErrWrongNumberArguments{false, form.arity, len(formArgs)}
-			}
+		if err := checkArity(form.arity, form.variadic, len(formArgs)); err != nil {
+			return nil, err
 		}
 		return form.code(env, formArgs)
 	case BuiltinFunctionType:
@@ -156,16 +234,11 @@ func (obj *Object) apply(args *Object, env *Environment) (*Object, error) {
 			return nil, err
 		}
 
-		if fn.variadic {
-			if len(fnArgs) < fn.arity {
-				return nil, &ErrWrongNumberArguments{true, fn.arity, len(fnArgs)}
-			}
-		} else {
-			if len(fnArgs) != fn.arity {
-				return nil, &ErrWrongNumberArguments{false, fn.arity, len(fnArgs)}
-			}
+		if err := checkArity(fn.arity, fn.variadic, len(fnArgs)); err != nil {
+			return nil, err
 		}
 
+		env.clearValues()
 		return fn.code(env, fnArgs)
 	case ClosureType:
 		fn := obj.value.(*Closure)
@@ -174,12 +247,36 @@ func (obj *Object) apply(args *Object, env *Environment) (*Object, error) {
 			return nil, err
 		}
 
+		env.clearValues()
 		return fn.apply(env, fnArgs)
+	case GenericFunctionType:
+		fn := obj.value.(*GenericFunction)
+		fnArgs, err := evalArguments(args, env)
+		if err != nil {
+			return nil, err
+		}
+
+		env.clearValues()
+		return fn.call(env, fnArgs)
 	default:
 		return nil, fmt.Errorf("first element of cons cell is not list")
 	}
 }
 
+func checkArity(arity int, variadic bool, got int) error {
+	if variadic {
+		if got < arity {
+			return &ErrWrongNumberArguments{variadic: true, expected: arity, got: got}
+		}
+	} else {
+		if got != arity {
+			return &ErrWrongNumberArguments{variadic: false, expected: arity, got: got}
+		}
+	}
+
+	return nil
+}
+
 func evalArguments(args *Object, env *Environment) ([]*Object, error) {
 	var ret []*Object
 	next := args
@@ -217,58 +314,35 @@ func noEvalArguments(args *Object) []*Object {
 	return ret
 }
 
-func stringConsCell(sb *strings.Builder, obj *Object) {
-	first := true
-	next := obj
-	for {
-		if next == emptyList {
-			break
-		}
-
-		v := next.value.(*ConsCell)
-		if !first {
-			first = true
-			sb.WriteByte(' ')
-		}
-
-		sb.WriteString(v.car.String())
-
-		if v.cdr.kind != ConsCellType {
-			sb.WriteString(" . ")
-			sb.WriteString(v.cdr.String())
-			break
-		}
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'E', -1, 64)
+}
 
-		first = false
-		next = v.cdr
-	}
+// formatSingleFloat prints single float with exponent marker F so that the
+// reader can read it back as single float
+func formatSingleFloat(v float32) string {
+	return strings.Replace(strconv.FormatFloat(float64(v), 'E', -1, 32), "E", "F", 1)
 }
 
 func (obj Object) String() string {
 	switch obj.kind {
-	case FixnumType:
-		v := obj.value.(int64)
-		return strconv.FormatInt(v, 10)
+	case FixnumType, BignumType, RatioType, CharacterType, StringType, SymbolType, ConsCellType,
+		HashTableType, StructureType, InstanceType, PathnameType:
+		return formatObject(&obj)
 	case FloatType:
 		v := obj.value.(float64)
-		return strconv.FormatFloat(v, 'E', -1, 64)
-	case StringType:
-		v := obj.value.(string)
-		return fmt.Sprintf(`"%s"`, v)
-	case SymbolType:
-		v := obj.value.(*Symbol)
-		n := v.name.value.(string)
-		return n
+		return formatFloat(v)
+	case SingleFloatType:
+		return formatSingleFloat(obj.value.(float32))
+	case DecimalType:
+		return obj.value.(*Decimal).String()
+	case ComplexType:
+		v := obj.value.(complex128)
+		return fmt.Sprintf("#C(%s %s)", formatFloat(real(v)), formatFloat(imag(v)))
 	case PackageType:
 		v := obj.value.(*Package)
 		n := v.name.value.(string)
 		return n
-	case ConsCellType:
-		var sb strings.Builder
-		sb.WriteByte('(')
-		stringConsCell(&sb, &obj)
-		sb.WriteByte(')')
-		return sb.String()
 	case BuiltinFunctionType:
 		return "#<builtin>"
 	case ClosureType:
@@ -278,6 +352,18 @@ func (obj Object) String() string {
 		} else {
 			return "#<function lambda>"
 		}
+	case StreamType:
+		return fmt.Sprintf("#<stream {%d}>", obj.id)
+	case RandomStateType:
+		return obj.value.(*RandomState).String()
+	case ReadtableType:
+		return fmt.Sprintf("#<readtable {%d}>", obj.id)
+	case ClassType:
+		v := obj.value.(*Class)
+		return v.String()
+	case GenericFunctionType:
+		v := obj.value.(*GenericFunction)
+		return fmt.Sprintf("#<generic-function %v>", *v.name)
 	default:
 		return "error: unsupported print type"
 	}
@@ -304,6 +390,143 @@ func objectEqual(a *Object, b *Object) bool {
 	return a.id == b.id
 }
 
+func isEql(a *Object, b *Object) bool {
+	if objectEqual(a, b) {
+		return true
+	}
+
+	if a.kind != b.kind {
+		return false
+	}
+
+	switch a.kind {
+	case FixnumType:
+		return a.value.(int64) == b.value.(int64)
+	case BignumType:
+		return a.value.(*big.Int).Cmp(b.value.(*big.Int)) == 0
+	case RatioType:
+		return a.value.(*big.Rat).Cmp(b.value.(*big.Rat)) == 0
+	case FloatType:
+		return math.Float64bits(a.value.(float64)) == math.Float64bits(b.value.(float64))
+	case SingleFloatType:
+		return math.Float32bits(a.value.(float32)) == math.Float32bits(b.value.(float32))
+	case DecimalType:
+		return a.value.(*Decimal).cmp(b.value.(*Decimal)) == 0
+	case CharacterType:
+		return a.value.(rune) == b.value.(rune)
+	case ComplexType:
+		x := a.value.(complex128)
+		y := b.value.(complex128)
+		return math.Float64bits(real(x)) == math.Float64bits(real(y)) &&
+			math.Float64bits(imag(x)) == math.Float64bits(imag(y))
+	default:
+		return false
+	}
+}
+
+func isEmptyList(obj *Object) bool {
+	return obj == emptyList || isNull(obj)
+}
+
+// comparedPairs holds the ids of pairs of objects being compared by equal
+// or equalp. A pair met again is assumed to be equal so that comparisons of
+// circular objects terminate.
+type comparedPairs map[[2]int]bool
+
+func (p comparedPairs) seen(a *Object, b *Object) bool {
+	pair := [2]int{a.id, b.id}
+	if p[pair] {
+		return true
+	}
+
+	p[pair] = true
+	return false
+}
+
+func isEqual(a *Object, b *Object) bool {
+	return equalObjects(a, b, comparedPairs{})
+}
+
+func equalObjects(a *Object, b *Object, pairs comparedPairs) bool {
+	if isEql(a, b) {
+		return true
+	}
+
+	if isEmptyList(a) || isEmptyList(b) {
+		return isEmptyList(a) && isEmptyList(b)
+	}
+
+	if a.kind != b.kind {
+		return false
+	}
+
+	switch a.kind {
+	case StringType:
+		return a.value.(string) == b.value.(string)
+	case PathnameType:
+		return a.value.(*Pathname).namestring() == b.value.(*Pathname).namestring()
+	case ConsCellType:
+		if pairs.seen(a, b) {
+			return true
+		}
+
+		ca := a.value.(*ConsCell)
+		cb := b.value.(*ConsCell)
+		return equalObjects(ca.car, cb.car, pairs) && equalObjects(ca.cdr, cb.cdr, pairs)
+	default:
+		return false
+	}
+}
+
+func isEqualp(a *Object, b *Object) bool {
+	return equalpObjects(a, b, comparedPairs{})
+}
+
+func equalpObjects(a *Object, b *Object, pairs comparedPairs) bool {
+	if equalObjects(a, b, comparedPairs{}) {
+		return true
+	}
+
+	if isEmptyList(a) || isEmptyList(b) {
+		return false
+	}
+
+	if isNumber(a) && isNumber(b) {
+		eq, err := numberEqual("equalp", a, b)
+		return err == nil && eq
+	}
+
+	if a.kind != b.kind {
+		return false
+	}
+
+	switch a.kind {
+	case CharacterType:
+		return unicode.ToLower(a.value.(rune)) == unicode.ToLower(b.value.(rune))
+	case StringType:
+		return strings.EqualFold(a.value.(string), b.value.(string))
+	case ConsCellType:
+		if pairs.seen(a, b) {
+			return true
+		}
+
+		ca := a.value.(*ConsCell)
+		cb := b.value.(*ConsCell)
+		return equalpObjects(ca.car, cb.car, pairs) && equalpObjects(ca.cdr, cb.cdr, pairs)
+	case HashTableType:
+		return pairs.seen(a, b) || a.value.(*HashTable).equalp(b.value.(*HashTable), pairs)
+	case StructureType:
+		return pairs.seen(a, b) || a.value.(*Structure).equalp(b.value.(*Structure), pairs)
+	default:
+		return false
+	}
+}
+
+func isNumber(obj *Object) bool {
+	_, ok := numberRank(obj)
+	return ok
+}
+
 func isNull(v *Object) bool {
 	return objectEqual(v, nilObj)
 }
@@ -329,6 +552,12 @@ func intern(name *Object, pack *Object) *Object {
 	sym.package_ = pack
 	p.table[n] = newSym
 
+	// keywords are external and evaluate to themselves
+	if pack == keywordPackage {
+		sym.value = newSym
+		p.external[n] = true
+	}
+
 	return newSym
 }
 
@@ -346,10 +575,30 @@ func newFixnum(val int64) *Object {
 	return newObject(FixnumType, val)
 }
 
+func newBignum(val *big.Int) *Object {
+	return newObject(BignumType, val)
+}
+
+func newRatio(val *big.Rat) *Object {
+	return newObject(RatioType, val)
+}
+
+func newComplex(val complex128) *Object {
+	return newObject(ComplexType, val)
+}
+
 func newFloat(val float64) *Object {
 	return newObject(FloatType, val)
 }
 
+func newSingleFloat(val float32) *Object {
+	return newObject(SingleFloatType, val)
+}
+
+func newCharacter(val rune) *Object {
+	return newObject(CharacterType, val)
+}
+
 func newString(val string) *Object {
 	return newObject(StringType, val)
 }
@@ -368,13 +617,20 @@ func newSymbol(val string) *Object {
 	return intern(newString(val), nil)
 }
 
+func newKeyword(val string) *Object {
+	return intern(newString(val), keywordPackage)
+}
+
 func newPackage(name string) *Object {
 	p := &Package{
-		name:  newString(name),
-		table: make(map[string]*Object),
+		name:     newString(name),
+		table:    make(map[string]*Object),
+		external: make(map[string]bool),
 	}
 
-	return newObject(PackageType, p)
+	ret := newObject(PackageType, p)
+	packages[name] = ret
+	return ret
 }
 
 func newConsCell(car *Object, cdr *Object) *Object {
@@ -0,0 +1,267 @@
+package banglisp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// packages is the table of all packages by name
+var packages = make(map[string]*Object)
+
+func findPackage(name string) (*Object, bool) {
+	p, ok := packages[name]
+	return p, ok
+}
+
+// findPackageFolded is findPackage for package prefixes of tokens. Since the
+// reader converts the case of tokens, it falls back to case-insensitive
+// comparison.
+func findPackageFolded(name string) (*Object, bool) {
+	if p, ok := findPackage(name); ok {
+		return p, true
+	}
+
+	for n, p := range packages {
+		if strings.EqualFold(n, name) {
+			return p, true
+		}
+	}
+
+	return nil, false
+}
+
+func (p *Package) isExternal(name string) bool {
+	return p.external[name]
+}
+
+// findSymbol returns the symbol named name in the package and whether the
+// symbol is external
+func (p *Package) findSymbol(name string) (*Object, bool, bool) {
+	sym, ok := p.table[name]
+	if !ok {
+		return nil, false, false
+	}
+
+	return sym, p.isExternal(name), true
+}
+
+// isAccessible reports whether sym can be referred to without package
+// prefix from package pack
+func isAccessible(sym *Object, pack *Object) bool {
+	v := sym.value.(*Symbol)
+	found, ok := pack.value.(*Package).table[v.name.value.(string)]
+	return ok && objectEqual(found, sym)
+}
+
+// qualifiedSymbol resolves pkg:name and pkg::name tokens. Single colon
+// refers only to external symbols.
+func qualifiedSymbol(pkgName string, name string, internal bool) (*Object, error) {
+	token := pkgName + ":" + name
+	if internal {
+		token = pkgName + "::" + name
+	}
+
+	pack, ok := findPackageFolded(pkgName)
+	if !ok {
+		return nil, &ErrInvalidToken{token: token, reason: fmt.Sprintf("package %s is not found", pkgName)}
+	}
+
+	// keywords are interned even by single colon syntax
+	if internal || pack == keywordPackage {
+		return intern(newString(name), pack), nil
+	}
+
+	p := pack.value.(*Package)
+	sym, external, ok := p.findSymbol(name)
+	if !ok || !external {
+		return nil, &ErrInvalidToken{token: token, reason: fmt.Sprintf("symbol %s is not external in package %s", name, pkgName)}
+	}
+
+	return sym, nil
+}
+
+// packageValue resolves package designator which is package, string or
+// symbol naming a package
+func packageValue(function string, obj *Object) (*Object, error) {
+	if obj.kind == PackageType {
+		return obj, nil
+	}
+
+	name, ok := symbolNameString(obj)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: function, argument: obj}
+	}
+
+	pack, ok := findPackage(name)
+	if !ok {
+		return nil, fmt.Errorf("%s: package %s is not found", function, name)
+	}
+
+	return pack, nil
+}
+
+func optionalPackage(function string, args []*Object, index int) (*Object, error) {
+	if len(args) > index {
+		return packageValue(function, args[index])
+	}
+
+	return CurrentPackage(), nil
+}
+
+func symbolStatus(pack *Object, name string) *Object {
+	if pack.value.(*Package).isExternal(name) {
+		return newKeyword("external")
+	}
+
+	return newKeyword("internal")
+}
+
+func builtinMakePackage(_ *Environment, args []*Object) (*Object, error) {
+	// (make-package name)
+	name, ok := symbolNameString(args[0])
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "make-package", argument: args[0]}
+	}
+
+	if _, ok := findPackage(name); ok {
+		return nil, fmt.Errorf("make-package: package %s already exists", name)
+	}
+
+	return newPackage(name), nil
+}
+
+func builtinFindPackage(_ *Environment, args []*Object) (*Object, error) {
+	// (find-package name)
+	if args[0].kind == PackageType {
+		return args[0], nil
+	}
+
+	name, ok := symbolNameString(args[0])
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "find-package", argument: args[0]}
+	}
+
+	if pack, ok := findPackage(name); ok {
+		return pack, nil
+	}
+
+	return nilObj, nil
+}
+
+func builtinPackageName(_ *Environment, args []*Object) (*Object, error) {
+	// (package-name package)
+	pack, err := packageValue("package-name", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return pack.value.(*Package).name, nil
+}
+
+func builtinPackagep(_ *Environment, args []*Object) (*Object, error) {
+	// (packagep obj)
+	if args[0].kind == PackageType {
+		return tObj, nil
+	}
+
+	return nilObj, nil
+}
+
+func builtinIntern(env *Environment, args []*Object) (*Object, error) {
+	// (intern string &optional package)
+	if len(args) > 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+	}
+
+	name, ok := args[0].value.(string)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "intern", argument: args[0]}
+	}
+
+	pack, err := optionalPackage("intern", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if sym, _, ok := pack.value.(*Package).findSymbol(name); ok {
+		return env.setValues([]*Object{sym, symbolStatus(pack, name)}), nil
+	}
+
+	return env.setValues([]*Object{intern(args[0], pack), nilObj}), nil
+}
+
+func builtinFindSymbol(env *Environment, args []*Object) (*Object, error) {
+	// (find-symbol string &optional package)
+	if len(args) > 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+	}
+
+	name, ok := args[0].value.(string)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "find-symbol", argument: args[0]}
+	}
+
+	pack, err := optionalPackage("find-symbol", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if sym, _, ok := pack.value.(*Package).findSymbol(name); ok {
+		return env.setValues([]*Object{sym, symbolStatus(pack, name)}), nil
+	}
+
+	return env.setValues([]*Object{nilObj, nilObj}), nil
+}
+
+func builtinExport(_ *Environment, args []*Object) (*Object, error) {
+	// (export symbols &optional package)
+	if len(args) > 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+	}
+
+	pack, err := optionalPackage("export", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := []*Object{args[0]}
+	if args[0].kind == ConsCellType {
+		symbols = noEvalArguments(args[0])
+	}
+
+	p := pack.value.(*Package)
+	for _, sym := range symbols {
+		if sym.kind != SymbolType {
+			return nil, &ErrUnsupportedArgumentType{function: "export", argument: sym}
+		}
+
+		if !isAccessible(sym, pack) {
+			return nil, fmt.Errorf("export: %v is not accessible in package %v", *sym, *p.name)
+		}
+
+		p.external[sym.value.(*Symbol).name.value.(string)] = true
+	}
+
+	return tObj, nil
+}
+
+func builtinMakeSymbol(_ *Environment, args []*Object) (*Object, error) {
+	// (make-symbol name)
+	name, ok := args[0].value.(string)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "make-symbol", argument: args[0]}
+	}
+
+	return newSymbolInternal(name), nil
+}
+
+func initPackageFunctions() {
+	installBuiltinFunction("make-package", builtinMakePackage, 1, false)
+	installBuiltinFunction("find-package", builtinFindPackage, 1, false)
+	installBuiltinFunction("package-name", builtinPackageName, 1, false)
+	installBuiltinFunction("packagep", builtinPackagep, 1, false)
+	installBuiltinFunction("intern", builtinIntern, 1, true)
+	installBuiltinFunction("find-symbol", builtinFindSymbol, 1, true)
+	installBuiltinFunction("export", builtinExport, 1, true)
+	installBuiltinFunction("make-symbol", builtinMakeSymbol, 1, false)
+}
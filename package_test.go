@@ -0,0 +1,99 @@
+package banglisp
+
+import (
+	"testing"
+)
+
+func TestPackage(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "internal symbol",
+			expr: `(intern "foo" "PKG-A")
+(list 'PKG-A::foo (eq 'PKG-A::foo (intern "foo" "PKG-A")) (eq 'PKG-A::foo 'foo))`,
+			want: "(PKG-A::foo t nil)",
+		},
+		{
+			name: "external symbol",
+			expr: `(export (intern "bar" "PKG-A") "PKG-A")
+(list 'PKG-A:bar 'PKG-A::bar (multiple-value-list (find-symbol "bar" (find-package "PKG-A"))))`,
+			want: "(PKG-A:bar PKG-A:bar (PKG-A:bar :external))",
+		},
+		{
+			name: "symbols of current package are not qualified",
+			expr: `(list 'CL-USER::car (eq 'CL-USER::car 'car) (package-name (symbol-package 'car)))`,
+			want: `(car t "CL-USER")`,
+		},
+		{
+			name: "keyword package",
+			expr: `(list 'KEYWORD:key (eq 'KEYWORD::key :key))`,
+			want: "(:key t)",
+		},
+		{
+			name: "intern status",
+			expr: `(list (multiple-value-list (intern "new" "PKG-A")) (multiple-value-list (intern "new" "PKG-A")))`,
+			want: "((PKG-A::new nil) (PKG-A::new :internal))",
+		},
+		{
+			name: "find-symbol does not intern",
+			expr: `(multiple-value-list (find-symbol "pkg-no-such-symbol" "PKG-A"))`,
+			want: "(nil nil)",
+		},
+		{
+			name: "uninterned symbols",
+			expr: `(list '#:foo (eq '#:foo '#:foo) (symbol-package '#:foo) (symbol-name (make-symbol "bar")))`,
+			want: `(#:foo nil nil "bar")`,
+		},
+		{
+			name:    "symbol is not external",
+			expr:    `(intern "baz" "PKG-A") 'PKG-A:baz`,
+			wantErr: true,
+		},
+		{
+			name:    "package is not found",
+			expr:    `'NO-SUCH-PACKAGE::foo`,
+			wantErr: true,
+		},
+		{
+			name:    "too many colons",
+			expr:    `'PKG-A:::foo`,
+			wantErr: true,
+		},
+		{
+			name:    "export inaccessible symbol",
+			expr:    `(export 'foo "PKG-A")`,
+			wantErr: true,
+		},
+		{
+			name:    "package already exists",
+			expr:    `(make-package "PKG-A")`,
+			wantErr: true,
+		},
+	}
+
+	// start from fresh package so that the test can run repeatedly
+	delete(packages, "PKG-A")
+	newPackage("PKG-A")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("readEvalString(%s) error = %v", tt.expr, err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("got: %v, expected: %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package banglisp
+
+import "strings"
+
+// Pathname is a file name split into directory, name and type. Empty
+// components are missing.
+type Pathname struct {
+	directory string
+	name      string
+	type_     string
+}
+
+func parsePathname(namestring string) *Pathname {
+	p := &Pathname{}
+	file := namestring
+	if i := strings.LastIndexByte(namestring, '/'); i >= 0 {
+		p.directory = namestring[:i+1]
+		file = namestring[i+1:]
+	}
+
+	// the dot of file names such as .emacs does not start a type
+	if i := strings.LastIndexByte(file, '.'); i > 0 {
+		p.name = file[:i]
+		p.type_ = file[i+1:]
+	} else {
+		p.name = file
+	}
+
+	return p
+}
+
+func (p *Pathname) namestring() string {
+	if p.type_ == "" {
+		return p.directory + p.name
+	}
+
+	return p.directory + p.name + "." + p.type_
+}
+
+// directoryList returns the directory as a list such as (:absolute "usr" "lib")
+func (p *Pathname) directoryList() *Object {
+	if p.directory == "" {
+		return nilObj
+	}
+
+	kind := "relative"
+	dir := p.directory
+	if strings.HasPrefix(dir, "/") {
+		kind = "absolute"
+		dir = dir[1:]
+	}
+
+	ret := emptyList
+	elems := strings.Split(dir, "/")
+	for i := len(elems) - 1; i >= 0; i-- {
+		if elems[i] != "" {
+			ret = cons(newString(elems[i]), ret)
+		}
+	}
+
+	return cons(newKeyword(kind), ret)
+}
+
+func newPathname(p *Pathname) *Object {
+	return newObject(PathnameType, p)
+}
+
+// pathnameValue resolves pathname designator which is pathname or string
+func pathnameValue(function string, obj *Object) (*Pathname, error) {
+	switch v := obj.value.(type) {
+	case *Pathname:
+		return v, nil
+	case string:
+		return parsePathname(v), nil
+	default:
+		return nil, &ErrUnsupportedArgumentType{function: function, argument: obj}
+	}
+}
+
+func stringOrNil(s string) *Object {
+	if s == "" {
+		return nilObj
+	}
+
+	return newString(s)
+}
+
+// readPathname reads #P syntax which is followed by namestring
+func readPathname(br *sourceReader, _ rune, _ *Object) (*Object, error) {
+	obj, err := read1(br)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isNull(specialValue(readSuppressObj)) {
+		return nilObj, nil
+	}
+
+	s, ok := obj.value.(string)
+	if !ok {
+		return nil, &ErrInvalidSyntax{message: "#P must be followed by string", text: obj.String()}
+	}
+
+	return newPathname(parsePathname(s)), nil
+}
+
+func builtinPathname(_ *Environment, args []*Object) (*Object, error) {
+	// (pathname pathspec)
+	if args[0].kind == PathnameType {
+		return args[0], nil
+	}
+
+	p, err := pathnameValue("pathname", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return newPathname(p), nil
+}
+
+func builtinPathnamep(_ *Environment, args []*Object) (*Object, error) {
+	// (pathnamep obj)
+	if args[0].kind == PathnameType {
+		return tObj, nil
+	}
+
+	return nilObj, nil
+}
+
+func builtinNamestring(_ *Environment, args []*Object) (*Object, error) {
+	// (namestring pathname)
+	p, err := pathnameValue("namestring", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return newString(p.namestring()), nil
+}
+
+func builtinPathnameDirectory(_ *Environment, args []*Object) (*Object, error) {
+	// (pathname-directory pathname)
+	p, err := pathnameValue("pathname-directory", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return p.directoryList(), nil
+}
+
+func builtinPathnameName(_ *Environment, args []*Object) (*Object, error) {
+	// (pathname-name pathname)
+	p, err := pathnameValue("pathname-name", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return stringOrNil(p.name), nil
+}
+
+func builtinPathnameType(_ *Environment, args []*Object) (*Object, error) {
+	// (pathname-type pathname)
+	p, err := pathnameValue("pathname-type", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return stringOrNil(p.type_), nil
+}
+
+func initPathnameFunctions() {
+	installBuiltinFunction("pathname", builtinPathname, 1, false)
+	installBuiltinFunction("pathnamep", builtinPathnamep, 1, false)
+	installBuiltinFunction("namestring", builtinNamestring, 1, false)
+	installBuiltinFunction("pathname-directory", builtinPathnameDirectory, 1, false)
+	installBuiltinFunction("pathname-name", builtinPathnameName, 1, false)
+	installBuiltinFunction("pathname-type", builtinPathnameType, 1, false)
+}
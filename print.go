@@ -0,0 +1,791 @@
+package banglisp
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var printCaseObj *Object
+var printCircleObj *Object
+var printEscapeObj *Object
+var printReadablyObj *Object
+var printBaseObj *Object
+var printRadixObj *Object
+var printLengthObj *Object
+var printLevelObj *Object
+
+// symbolNeedsEscape reports whether name must be escaped so that the reader
+// reads it back as the same symbol with readtable rt
+func symbolNeedsEscape(name string, rt *Readtable) bool {
+	if name == "" || strings.Trim(name, ".") == "" || isPotentialNumber(name) {
+		return true
+	}
+
+	base, err := readBase()
+	if err != nil {
+		base = 10
+	}
+
+	if _, ok, _ := parseNumber(name, base); ok {
+		return true
+	}
+
+	for i, c := range name {
+		switch {
+		case isSpace(c) || unicode.IsControl(c):
+			return true
+		case c == '|' || c == '\\' || c == ':':
+			return true
+		case rt.isTerminatingMacro(c):
+			return true
+		case i == 0 && rt.macros[c] != nil:
+			return true
+		case rt.readCase == caseUpcase && unicode.IsLower(c):
+			return true
+		case rt.readCase == caseDowncase && unicode.IsUpper(c):
+			return true
+		}
+	}
+
+	return false
+}
+
+// printer holds the printer control variables while printing an object.
+// length and level are negative if they are unlimited.
+type printer struct {
+	escape    bool
+	readably  bool
+	base      int
+	radix     bool
+	length    int
+	level     int
+	printCase string
+	circle    bool
+	// err is set when an unreadable object is printed readably
+	err error
+
+	// labels of conses. Objects are identified by id since String works on
+	// copies of objects.
+	shared  map[int]bool
+	labels  map[int]int
+	visited map[int]bool
+	active  map[int]bool
+}
+
+func printLimit(obj *Object) int {
+	if n, ok := obj.value.(int64); ok && n >= 0 {
+		return int(n)
+	}
+
+	return -1
+}
+
+// newPrinter makes printer from the values of the printer control
+// variables
+func newPrinter() *printer {
+	p := &printer{
+		escape:    true,
+		base:      10,
+		length:    -1,
+		level:     -1,
+		printCase: "downcase",
+		shared:    make(map[int]bool),
+		labels:    make(map[int]int),
+		visited:   make(map[int]bool),
+		active:    make(map[int]bool),
+	}
+
+	// objects may be printed while initializing the interpreter
+	if printLevelObj == nil {
+		return p
+	}
+
+	p.escape = !isNull(specialValue(printEscapeObj))
+	p.readably = !isNull(specialValue(printReadablyObj))
+	p.radix = !isNull(specialValue(printRadixObj))
+	p.circle = !isNull(specialValue(printCircleObj))
+	p.length = printLimit(specialValue(printLengthObj))
+	p.level = printLimit(specialValue(printLevelObj))
+
+	if base, ok := specialValue(printBaseObj).value.(int64); ok && base >= 2 && base <= 36 {
+		p.base = int(base)
+	}
+
+	if mode, ok := keywordName(specialValue(printCaseObj)); ok {
+		p.printCase = mode
+	}
+
+	return p
+}
+
+func (p *printer) format(obj *Object) (string, error) {
+	// printing readably needs escapes and whole objects
+	if p.readably {
+		p.escape = true
+		p.length = -1
+		p.level = -1
+	}
+
+	var sb strings.Builder
+	p.scan(obj)
+	p.write(&sb, obj, 0)
+	return sb.String(), p.err
+}
+
+// formatObject prints obj as prin1 does but never fails on unreadable
+// objects. Object.String uses it.
+func formatObject(obj *Object) string {
+	p := newPrinter()
+	p.escape = true
+	p.readably = false
+	s, _ := p.format(obj)
+	return s
+}
+
+func prin1String(obj *Object) (string, error) {
+	p := newPrinter()
+	p.escape = true
+	return p.format(obj)
+}
+
+func princString(obj *Object) (string, error) {
+	p := newPrinter()
+	p.escape = false
+	p.readably = false
+	return p.format(obj)
+}
+
+// capitalize upcases the first character of each word and downcases the
+// rest. Words are runs of letters and digits.
+func capitalize(s string) string {
+	var sb strings.Builder
+	inWord := false
+	for _, c := range s {
+		alnum := unicode.IsLetter(c) || unicode.IsDigit(c)
+		if alnum && !inWord {
+			sb.WriteRune(unicode.ToUpper(c))
+		} else {
+			sb.WriteRune(unicode.ToLower(c))
+		}
+		inWord = alnum
+	}
+
+	return sb.String()
+}
+
+// applyPrintCase converts the case of name for printing. As in Common Lisp,
+// *print-case* changes only the characters in the case which the reader
+// converts to, so it is ignored when the readtable case is :preserve or
+// :invert.
+func (p *printer) applyPrintCase(name string, rt *Readtable) string {
+	switch rt.readCase {
+	case casePreserve:
+		return name
+	case caseInvert:
+		t := &token{chars: []rune(name), escaped: make([]bool, len([]rune(name)))}
+		t.convertCase(caseInvert)
+		return t.String()
+	}
+
+	canonical := unicode.IsLower
+	if rt.readCase == caseUpcase {
+		canonical = unicode.IsUpper
+	}
+
+	capitalized := []rune(capitalize(name))
+	ret := []rune(name)
+	for i, c := range ret {
+		if !canonical(c) {
+			continue
+		}
+
+		switch p.printCase {
+		case "upcase":
+			ret[i] = unicode.ToUpper(c)
+		case "capitalize":
+			ret[i] = capitalized[i]
+		default:
+			ret[i] = unicode.ToLower(c)
+		}
+	}
+
+	return string(ret)
+}
+
+// formatSymbolName prints symbol name. With escapes, names which cannot be
+// read back as they are get surrounded by vertical bars.
+func (p *printer) formatSymbolName(name string) string {
+	// symbols may be printed while initializing the interpreter
+	if readtableObj == nil {
+		return name
+	}
+
+	rt := currentReadtable()
+	if !p.escape || !symbolNeedsEscape(name, rt) {
+		return p.applyPrintCase(name, rt)
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('|')
+	for _, c := range name {
+		if c == '|' || c == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(c)
+	}
+	sb.WriteByte('|')
+	return sb.String()
+}
+
+// formatSymbol prints symbol with package prefix if it is needed to read
+// the symbol back. Without escapes, neither prefixes nor bars are printed.
+func (p *printer) formatSymbol(obj *Object) string {
+	v := obj.value.(*Symbol)
+	n := v.name.value.(string)
+	name := p.formatSymbolName(n)
+	if !p.escape {
+		return name
+	}
+
+	if isKeyword(obj) {
+		return ":" + name
+	}
+
+	if v.package_ == nil {
+		return "#:" + name
+	}
+
+	if !isAccessible(obj, CurrentPackage()) {
+		pack := v.package_.value.(*Package)
+		if pack.isExternal(n) {
+			return pack.name.value.(string) + ":" + name
+		}
+		return pack.name.value.(string) + "::" + name
+	}
+
+	return name
+}
+
+func (p *printer) formatString(s string) string {
+	if !p.escape {
+		return s
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, c := range s {
+		if c == '"' || c == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(c)
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// formatInteger prints integer in *print-base*. Digits above 9 are upper
+// case.
+func (p *printer) formatInteger(v *big.Int) string {
+	return strings.ToUpper(v.Text(p.base))
+}
+
+// radixPrefix returns the prefix printed before rationals for *print-radix*
+func (p *printer) radixPrefix() string {
+	switch p.base {
+	case 2:
+		return "#b"
+	case 8:
+		return "#o"
+	case 16:
+		return "#x"
+	default:
+		return fmt.Sprintf("#%dr", p.base)
+	}
+}
+
+func (p *printer) formatRational(obj *Object) string {
+	var s string
+	isInteger := true
+	switch v := obj.value.(type) {
+	case int64:
+		s = p.formatInteger(big.NewInt(v))
+	case *big.Int:
+		s = p.formatInteger(v)
+	case *big.Rat:
+		s = p.formatInteger(v.Num()) + "/" + p.formatInteger(v.Denom())
+		isInteger = false
+	}
+
+	if !p.radix {
+		return s
+	}
+
+	// decimal integers get a trailing point instead of #10r
+	if p.base == 10 && isInteger {
+		return s + "."
+	}
+
+	return p.radixPrefix() + s
+}
+
+func isCons(obj *Object) bool {
+	return obj.kind == ConsCellType && !objectEqual(obj, emptyList)
+}
+
+// enter marks obj as visited and returns true if obj is seen for the first
+// time. Objects seen again are labeled if they are being scanned, or if
+// *print-circle* is true.
+func (p *printer) enter(obj *Object) bool {
+	if p.active[obj.id] || (p.circle && p.visited[obj.id]) {
+		p.shared[obj.id] = true
+		return false
+	}
+
+	if p.visited[obj.id] {
+		return false
+	}
+
+	p.visited[obj.id] = true
+	p.active[obj.id] = true
+	return true
+}
+
+// components returns the objects printed inside structure or hash table
+func components(obj *Object) []*Object {
+	switch v := obj.value.(type) {
+	case *Structure:
+		return v.slots
+	case *HashTable:
+		var ret []*Object
+		for _, e := range v.entries {
+			ret = append(ret, e.key, e.value)
+		}
+		return ret
+	default:
+		return nil
+	}
+}
+
+// scan finds the objects to be labeled by depth first search. Objects
+// which are reachable from themselves are always labeled so that printing
+// circular structures terminates. If *print-circle* is true, objects
+// appearing more than once are labeled too.
+func (p *printer) scan(obj *Object) {
+	if obj.kind == StructureType || obj.kind == HashTableType {
+		if p.enter(obj) {
+			for _, c := range components(obj) {
+				p.scan(c)
+			}
+			p.active[obj.id] = false
+		}
+		return
+	}
+
+	var chain []int
+	for isCons(obj) && p.enter(obj) {
+		chain = append(chain, obj.id)
+
+		cell := obj.value.(*ConsCell)
+		p.scan(cell.car)
+		obj = cell.cdr
+	}
+
+	for _, id := range chain {
+		p.active[id] = false
+	}
+}
+
+// writeLabel writes #n# and returns true if obj is already printed.
+// Otherwise it writes #n= for shared obj.
+func (p *printer) writeLabel(sb *strings.Builder, obj *Object) bool {
+	if !p.shared[obj.id] {
+		return false
+	}
+
+	if n, ok := p.labels[obj.id]; ok {
+		sb.WriteString("#" + strconv.Itoa(n) + "#")
+		return true
+	}
+
+	n := len(p.labels) + 1
+	p.labels[obj.id] = n
+	sb.WriteString("#" + strconv.Itoa(n) + "=")
+	return false
+}
+
+// writeList prints list. Lists nested deeper than *print-level* are
+// printed as # and elements after *print-length* are printed as ...
+func (p *printer) writeList(sb *strings.Builder, obj *Object, depth int) {
+	if p.level >= 0 && depth >= p.level {
+		sb.WriteByte('#')
+		return
+	}
+
+	if p.writeLabel(sb, obj) {
+		return
+	}
+
+	cell := obj.value.(*ConsCell)
+	sb.WriteByte('(')
+	p.writeElements(sb, cell.car, cell.cdr, depth)
+	sb.WriteByte(')')
+}
+
+// writeElements prints the elements of list whose car is car and cdr is
+// cdr without parentheses. Hash table entries are printed with it too.
+func (p *printer) writeElements(sb *strings.Builder, car *Object, cdr *Object, depth int) {
+	for count := 0; ; count++ {
+		if p.length >= 0 && count >= p.length {
+			sb.WriteString("...")
+			return
+		}
+
+		p.write(sb, car, depth+1)
+		if objectEqual(cdr, emptyList) || isNull(cdr) {
+			return
+		}
+
+		// shared tails are printed in dotted notation to have labels
+		if !isCons(cdr) || p.shared[cdr.id] {
+			sb.WriteString(" . ")
+			p.write(sb, cdr, depth+1)
+			return
+		}
+
+		sb.WriteByte(' ')
+		cell := cdr.value.(*ConsCell)
+		car, cdr = cell.car, cell.cdr
+	}
+}
+
+// writeStructure prints structure in #S syntax. Slots count toward
+// *print-length* and the structure counts toward *print-level*.
+func (p *printer) writeStructure(sb *strings.Builder, obj *Object, depth int) {
+	if p.level >= 0 && depth >= p.level {
+		sb.WriteByte('#')
+		return
+	}
+
+	if p.writeLabel(sb, obj) {
+		return
+	}
+
+	s := obj.value.(*Structure)
+	sb.WriteString("#S(")
+	p.write(sb, s.class.name, depth+1)
+	for i, slot := range s.class.slots {
+		if p.length >= 0 && i >= p.length {
+			sb.WriteString(" ...")
+			break
+		}
+
+		sb.WriteString(" :")
+		sb.WriteString(p.formatSymbolName(slot.name.value.(*Symbol).name.value.(string)))
+		sb.WriteByte(' ')
+		p.write(sb, s.slots[i], depth+1)
+	}
+	sb.WriteByte(')')
+}
+
+// writeHashTable prints hash table in #H syntax whose entries are printed
+// as (key . value) lists
+func (p *printer) writeHashTable(sb *strings.Builder, obj *Object, depth int) {
+	if p.level >= 0 && depth >= p.level {
+		sb.WriteByte('#')
+		return
+	}
+
+	if p.writeLabel(sb, obj) {
+		return
+	}
+
+	h := obj.value.(*HashTable)
+	sb.WriteString("#H(")
+	sb.WriteString(p.applyPrintCase(h.test.name, currentReadtable()))
+	for i, e := range h.entries {
+		if p.length >= 0 && i >= p.length {
+			sb.WriteString(" ...")
+			break
+		}
+
+		sb.WriteByte(' ')
+		if p.level >= 0 && depth+1 >= p.level {
+			sb.WriteByte('#')
+			continue
+		}
+
+		sb.WriteByte('(')
+		p.writeElements(sb, e.key, e.value, depth+1)
+		sb.WriteByte(')')
+	}
+	sb.WriteByte(')')
+}
+
+// writeUnreadable writes s which is printed in #< syntax
+func (p *printer) writeUnreadable(sb *strings.Builder, s string) {
+	if p.readably && p.err == nil && strings.HasPrefix(s, "#<") {
+		p.err = fmt.Errorf("%s cannot be printed readably", s)
+	}
+	sb.WriteString(s)
+}
+
+func (p *printer) write(sb *strings.Builder, obj *Object, depth int) {
+	switch obj.kind {
+	case FixnumType, BignumType, RatioType:
+		sb.WriteString(p.formatRational(obj))
+	case CharacterType:
+		if p.escape {
+			sb.WriteString(formatCharacter(obj.value.(rune)))
+		} else {
+			sb.WriteRune(obj.value.(rune))
+		}
+	case StringType:
+		sb.WriteString(p.formatString(obj.value.(string)))
+	case SymbolType:
+		sb.WriteString(p.formatSymbol(obj))
+	case ConsCellType:
+		if !isCons(obj) {
+			sb.WriteString(p.formatSymbol(nilObj))
+			return
+		}
+		p.writeList(sb, obj, depth)
+	case StructureType:
+		if str, ok := printObject(obj); ok {
+			sb.WriteString(str)
+			return
+		}
+		p.writeStructure(sb, obj, depth)
+	case HashTableType:
+		p.writeHashTable(sb, obj, depth)
+	case InstanceType:
+		if str, ok := printObject(obj); ok {
+			sb.WriteString(str)
+			return
+		}
+		p.writeUnreadable(sb, obj.value.(*Instance).String(obj))
+	case PathnameType:
+		if p.escape {
+			sb.WriteString("#P")
+		}
+		sb.WriteString(p.formatString(obj.value.(*Pathname).namestring()))
+	default:
+		p.writeUnreadable(sb, obj.String())
+	}
+}
+
+// printVariables maps keyword arguments of write to the printer control
+// variables
+func printVariables() map[string]*Object {
+	return map[string]*Object{
+		"escape":   printEscapeObj,
+		"readably": printReadablyObj,
+		"base":     printBaseObj,
+		"radix":    printRadixObj,
+		"length":   printLengthObj,
+		"level":    printLevelObj,
+		"case":     printCaseObj,
+		"circle":   printCircleObj,
+	}
+}
+
+// writeKeys are the keyword arguments of write. :pretty is accepted for
+// compatibility but objects are always printed in one line.
+var writeKeys = []string{"stream", "escape", "readably", "base", "radix", "length", "level", "case", "circle", "pretty"}
+
+// bindPrintVariables pushes a frame binding the printer control variables
+// given as keyword arguments. Caller must pop the frame.
+func bindPrintVariables(keys map[string]*Object) {
+	vars := printVariables()
+	frame := &Frame{}
+	for key, value := range keys {
+		if sym, ok := vars[key]; ok {
+			frame.addBinding(sym, value)
+		}
+	}
+	defaultEnvironment.pushFrame(frame)
+}
+
+// printTo writes str to the optional stream argument at index
+func printTo(function string, args []*Object, index int, str string) error {
+	s, err := optionalOutputStream(function, args, index)
+	if err != nil {
+		return err
+	}
+
+	return s.writeString(str)
+}
+
+func builtinPrin1(_ *Environment, args []*Object) (*Object, error) {
+	// (prin1 object &optional stream)
+	if len(args) > 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+	}
+
+	str, err := prin1String(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := printTo("prin1", args, 1, str); err != nil {
+		return nil, err
+	}
+
+	return args[0], nil
+}
+
+func builtinPrinc(_ *Environment, args []*Object) (*Object, error) {
+	// (princ object &optional stream)
+	if len(args) > 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+	}
+
+	str, err := princString(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := printTo("princ", args, 1, str); err != nil {
+		return nil, err
+	}
+
+	return args[0], nil
+}
+
+func builtinPrint(_ *Environment, args []*Object) (*Object, error) {
+	// (print object &optional stream)
+	if len(args) > 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+	}
+
+	str, err := prin1String(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := printTo("print", args, 1, "\n"+str+" "); err != nil {
+		return nil, err
+	}
+
+	return args[0], nil
+}
+
+func builtinPprint(env *Environment, args []*Object) (*Object, error) {
+	// (pprint object &optional stream)
+	if len(args) > 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+	}
+
+	str, err := prin1String(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := printTo("pprint", args, 1, "\n"+str); err != nil {
+		return nil, err
+	}
+
+	return env.setValues(nil), nil
+}
+
+func builtinWrite(_ *Environment, args []*Object) (*Object, error) {
+	// (write object &key stream escape readably base radix length level case circle pretty)
+	keys, err := parseKeywordArguments("write", args[1:], writeKeys...)
+	if err != nil {
+		return nil, err
+	}
+
+	bindPrintVariables(keys)
+	defer defaultEnvironment.popFrame(1)
+
+	str, err := newPrinter().format(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	stream := nilObj
+	if s, ok := keys["stream"]; ok {
+		stream = s
+	}
+
+	if err := printTo("write", []*Object{stream}, 0, str); err != nil {
+		return nil, err
+	}
+
+	return args[0], nil
+}
+
+func builtinWriteToString(_ *Environment, args []*Object) (*Object, error) {
+	// (write-to-string object &key escape readably base radix length level case circle pretty)
+	keys, err := parseKeywordArguments("write-to-string", args[1:], writeKeys[1:]...)
+	if err != nil {
+		return nil, err
+	}
+
+	bindPrintVariables(keys)
+	defer defaultEnvironment.popFrame(1)
+
+	str, err := newPrinter().format(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return newString(str), nil
+}
+
+func builtinPrin1ToString(_ *Environment, args []*Object) (*Object, error) {
+	// (prin1-to-string object)
+	str, err := prin1String(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return newString(str), nil
+}
+
+func builtinPrincToString(_ *Environment, args []*Object) (*Object, error) {
+	// (princ-to-string object)
+	str, err := princString(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return newString(str), nil
+}
+
+func initPrintFunctions() {
+	printCaseObj = newSymbol("*print-case*")
+	printCaseObj.value.(*Symbol).value = newKeyword("downcase")
+
+	printCircleObj = newSymbol("*print-circle*")
+	printCircleObj.value.(*Symbol).value = nilObj
+
+	printEscapeObj = newSymbol("*print-escape*")
+	printEscapeObj.value.(*Symbol).value = tObj
+
+	printReadablyObj = newSymbol("*print-readably*")
+	printReadablyObj.value.(*Symbol).value = nilObj
+
+	printBaseObj = newSymbol("*print-base*")
+	printBaseObj.value.(*Symbol).value = newFixnum(10)
+
+	printRadixObj = newSymbol("*print-radix*")
+	printRadixObj.value.(*Symbol).value = nilObj
+
+	printLengthObj = newSymbol("*print-length*")
+	printLengthObj.value.(*Symbol).value = nilObj
+
+	printLevelObj = newSymbol("*print-level*")
+	printLevelObj.value.(*Symbol).value = nilObj
+
+	installBuiltinFunction("prin1", builtinPrin1, 1, true)
+	installBuiltinFunction("princ", builtinPrinc, 1, true)
+	installBuiltinFunction("print", builtinPrint, 1, true)
+	installBuiltinFunction("pprint", builtinPprint, 1, true)
+	installBuiltinFunction("write", builtinWrite, 1, true)
+	installBuiltinFunction("write-to-string", builtinWriteToString, 1, true)
+	installBuiltinFunction("prin1-to-string", builtinPrin1ToString, 1, false)
+	installBuiltinFunction("princ-to-string", builtinPrincToString, 1, false)
+}
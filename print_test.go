@@ -0,0 +1,433 @@
+package banglisp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrintSymbolCase(t *testing.T) {
+	tests := []struct {
+		name      string
+		symbol    string
+		readCase  readtableCase
+		printCase string
+		want      string
+	}{
+		{
+			name:      "downcase",
+			symbol:    "foo-bar",
+			readCase:  caseDowncase,
+			printCase: "downcase",
+			want:      "foo-bar",
+		},
+		{
+			name:      "print upcase",
+			symbol:    "foo-bar",
+			readCase:  caseDowncase,
+			printCase: "upcase",
+			want:      "FOO-BAR",
+		},
+		{
+			name:      "print capitalize",
+			symbol:    "foo-bar2x",
+			readCase:  caseDowncase,
+			printCase: "capitalize",
+			want:      "Foo-Bar2x",
+		},
+		{
+			name:      "mixed case is escaped",
+			symbol:    "Foo",
+			readCase:  caseDowncase,
+			printCase: "upcase",
+			want:      "|Foo|",
+		},
+		{
+			name:      "readtable upcase",
+			symbol:    "FOO",
+			readCase:  caseUpcase,
+			printCase: "downcase",
+			want:      "foo",
+		},
+		{
+			name:      "preserve ignores print case",
+			symbol:    "Foo",
+			readCase:  casePreserve,
+			printCase: "upcase",
+			want:      "Foo",
+		},
+		{
+			name:      "invert",
+			symbol:    "FOO",
+			readCase:  caseInvert,
+			printCase: "upcase",
+			want:      "foo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := newReadtable()
+			standardReadtable.copyInto(rt)
+			rt.readCase = tt.readCase
+
+			frame := &Frame{}
+			frame.addBinding(readtableObj, newObject(ReadtableType, rt))
+			frame.addBinding(printCaseObj, newKeyword(tt.printCase))
+			defaultEnvironment.pushFrame(frame)
+			defer defaultEnvironment.popFrame(1)
+
+			if got := newSymbol(tt.symbol).String(); got != tt.want {
+				t.Errorf("got: %s, expected: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintSymbolRoundTrip(t *testing.T) {
+	names := []string{"", "foo", "Foo", "FOO BAR", "a(b", `a|b\c`, "1", "1.5", "...", "a:b", "#a", "a#", "1+", "日本"}
+
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			sym := newSymbol(name)
+			got, err := Read(strings.NewReader(sym.String()))
+			if err != nil {
+				t.Fatalf("Read(%s) error = %v", sym, err)
+			}
+
+			if got != sym {
+				t.Errorf("%s is read as %v", sym, *got)
+			}
+		})
+	}
+}
+
+func TestPrintCircle(t *testing.T) {
+	tests := []struct {
+		name   string
+		expr   string
+		circle bool
+		want   string
+	}{
+		{
+			name: "circular cdr",
+			expr: "#1=(a b . #1#)",
+			want: "#1=(a b . #1#)",
+		},
+		{
+			name: "circular tail",
+			expr: "(a . #1=(b c . #1#))",
+			want: "(a . #1=(b c . #1#))",
+		},
+		{
+			name: "circular car",
+			expr: "#1=(#1# b)",
+			want: "#1=(#1# b)",
+		},
+		{
+			name: "sharing without print-circle",
+			expr: "(#1=(a) #1#)",
+			want: "((a) (a))",
+		},
+		{
+			name:   "sharing with print-circle",
+			expr:   "(#1=(a) #1# #2=(b) #2#)",
+			circle: true,
+			want:   "(#1=(a) #1# #2=(b) #2#)",
+		},
+		{
+			name:   "shared tail with print-circle",
+			expr:   "(#1=(x y) . #1#)",
+			circle: true,
+			want:   "(#1=(x y) . #1#)",
+		},
+		{
+			name:   "circular list with print-circle",
+			expr:   "#1=(a (b . #1#) c)",
+			circle: true,
+			want:   "#1=(a (b . #1#) c)",
+		},
+		{
+			name: "empty list",
+			expr: "()",
+			want: "nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := Read(strings.NewReader(tt.expr))
+			if err != nil {
+				t.Fatalf("Read(%s) error = %v", tt.expr, err)
+			}
+
+			circle := nilObj
+			if tt.circle {
+				circle = tObj
+			}
+
+			frame := &Frame{}
+			frame.addBinding(printCircleObj, circle)
+			defaultEnvironment.pushFrame(frame)
+			defer defaultEnvironment.popFrame(1)
+
+			got := obj.String()
+			if got != tt.want {
+				t.Errorf("got: %s, expected: %s", got, tt.want)
+			}
+
+			// the printed representation reads back to the same structure
+			again, err := Read(strings.NewReader(got))
+			if err != nil {
+				t.Fatalf("Read(%s) error = %v", got, err)
+			}
+
+			if again.String() != got {
+				t.Errorf("read back as %s", again)
+			}
+		})
+	}
+}
+
+func TestPrintFunctions(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "prin1 to stream",
+			expr: `(let ((s (make-string-output-stream))) (prin1 "a\"b" s) (get-output-stream-string s))`,
+			want: `"\"a\\\"b\""`,
+		},
+		{
+			name: "princ to stream",
+			expr: `(let ((s (make-string-output-stream))) (princ "a\"b" s) (princ #\c s) (get-output-stream-string s))`,
+			want: `"a\"bc"`,
+		},
+		{
+			name: "print to stream",
+			expr: `(let ((s (make-string-output-stream))) (print 'foo s) (get-output-stream-string s))`,
+			want: `"
+foo "`,
+		},
+		{
+			name: "print returns object",
+			expr: `(let ((s (make-string-output-stream))) (print 42 s))`,
+			want: "42",
+		},
+		{
+			name: "prin1-to-string",
+			expr: `(prin1-to-string '(a "b" #\c))`,
+			want: `"(a \"b\" #\\c)"`,
+		},
+		{
+			name: "princ-to-string",
+			expr: `(princ-to-string '(a "b" #\c))`,
+			want: `"(a b c)"`,
+		},
+		{
+			name: "princ omits package prefix",
+			expr: `(princ-to-string :foo)`,
+			want: `"foo"`,
+		},
+		{
+			name: "write-to-string base",
+			expr: `(write-to-string 255 :base 16)`,
+			want: `"FF"`,
+		},
+		{
+			name: "write-to-string radix",
+			expr: `(write-to-string 5 :base 2 :radix t)`,
+			want: `"#b101"`,
+		},
+		{
+			name: "write-to-string decimal radix",
+			expr: `(write-to-string 10 :radix t)`,
+			want: `"10."`,
+		},
+		{
+			name: "write-to-string ratio in other base",
+			expr: `(write-to-string 1/3 :base 3 :radix t)`,
+			want: `"#3r1/10"`,
+		},
+		{
+			name: "write-to-string length",
+			expr: `(write-to-string '(1 2 3 4 5) :length 3)`,
+			want: `"(1 2 3 ...)"`,
+		},
+		{
+			name: "write-to-string level",
+			expr: `(write-to-string '(1 (2 (3 (4)))) :level 2)`,
+			want: `"(1 (2 #))"`,
+		},
+		{
+			name: "write-to-string escape nil",
+			expr: `(write-to-string "abc" :escape nil)`,
+			want: `"abc"`,
+		},
+		{
+			name: "write-to-string case",
+			expr: `(write-to-string 'foo-bar :case :capitalize)`,
+			want: `"Foo-Bar"`,
+		},
+		{
+			name: "readably ignores length",
+			expr: `(write-to-string '(1 2 3) :length 1 :readably t)`,
+			want: `"(1 2 3)"`,
+		},
+		{
+			name:    "readably unreadable object",
+			expr:    `(write-to-string (make-string-output-stream) :readably t)`,
+			wantErr: true,
+		},
+		{
+			name: "write to stream",
+			expr: `(let ((s (make-string-output-stream))) (write '(a "b") :stream s :escape nil) (get-output-stream-string s))`,
+			want: `"(a b)"`,
+		},
+		{
+			name:    "write unknown keyword",
+			expr:    `(write 1 :foo 2)`,
+			wantErr: true,
+		},
+		{
+			name: "princ structure slots",
+			expr: `(defstruct ppoint x y) (princ-to-string (make-ppoint :x "a" :y #\b))`,
+			want: `"#S(ppoint :x a :y b)"`,
+		},
+		{
+			name: "prin1 structure slots",
+			expr: `(defstruct ppoint x y) (prin1-to-string (make-ppoint :x "a" :y #\b))`,
+			want: `"#S(ppoint :x \"a\" :y #\\b)"`,
+		},
+		{
+			name: "structure slots in print-base",
+			expr: `(defstruct ppoint x y) (write-to-string (make-ppoint :x 255) :base 16 :radix t)`,
+			want: `"#S(ppoint :x #xFF :y nil)"`,
+		},
+		{
+			name: "structure in print-case",
+			expr: `(defstruct ppoint x y) (write-to-string (make-ppoint) :case :upcase)`,
+			want: `"#S(PPOINT :X NIL :Y NIL)"`,
+		},
+		{
+			name: "princ hash table entries",
+			expr: `(let ((h (make-hash-table :test 'equal))) (setf (gethash "k" h) "v") (princ-to-string h))`,
+			want: `"#H(equal (k . v))"`,
+		},
+		{
+			name: "princ pathname",
+			expr: `(princ-to-string #P"/tmp/a.txt")`,
+			want: `"/tmp/a.txt"`,
+		},
+		{
+			name: "prin1 pathname",
+			expr: `(prin1-to-string #P"/tmp/a.txt")`,
+			want: `"#P\"/tmp/a.txt\""`,
+		},
+		{
+			name: "print-base variable",
+			expr: `(let ((*print-base* 8)) (prin1-to-string 64))`,
+			want: `"100"`,
+		},
+		{
+			name: "print-length variable",
+			expr: `(let ((*print-length* 2)) (prin1-to-string '(a b c)))`,
+			want: `"(a b ...)"`,
+		},
+		{
+			name: "print-escape variable",
+			expr: `(let ((*print-escape* nil)) (write-to-string "x"))`,
+			want: `"x"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("got: %s, expected: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintStringRoundTrip(t *testing.T) {
+	for _, s := range []string{`plain`, `with "quotes"`, `back\slash`, ``} {
+		obj := newString(s)
+		again, err := Read(strings.NewReader(obj.String()))
+		if err != nil {
+			t.Fatalf("Read(%s) error = %v", obj, err)
+		}
+
+		if again.value.(string) != s {
+			t.Errorf("%q read back as %q", s, again.value)
+		}
+	}
+}
+
+func TestPrintCircularObjects(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{
+			name: "self-referential structure",
+			expr: `(defstruct cnode x y) (let ((n (make-cnode))) (setf (cnode-x n) n) (prin1-to-string n))`,
+			want: `"#1=#S(cnode :x #1# :y nil)"`,
+		},
+		{
+			name: "self-referential structure with print-circle",
+			expr: `(defstruct cnode x y) (let ((n (make-cnode))) (setf (cnode-x n) n) (let ((*print-circle* t)) (prin1-to-string n)))`,
+			want: `"#1=#S(cnode :x #1# :y nil)"`,
+		},
+		{
+			name: "structure through list",
+			expr: `(defstruct cnode x y) (let ((n (make-cnode))) (setf (cnode-y n) (list 1 n)) (prin1-to-string n))`,
+			want: `"#1=#S(cnode :x nil :y (1 #1#))"`,
+		},
+		{
+			name: "shared structure with print-circle",
+			expr: `(defstruct cnode x y) (let ((n (make-cnode))) (let ((*print-circle* t)) (prin1-to-string (list n n))))`,
+			want: `"(#1=#S(cnode :x nil :y nil) #1#)"`,
+		},
+		{
+			name: "hash table containing itself",
+			expr: `(let ((h (make-hash-table))) (setf (gethash 'self h) h) (prin1-to-string h))`,
+			want: `"#1=#H(eql (self . #1#))"`,
+		},
+		{
+			name: "structure under print-level",
+			expr: `(defstruct cnode x y) (let ((*print-level* 1)) (prin1-to-string (list (make-cnode))))`,
+			want: `"(#)"`,
+		},
+		{
+			name: "structure slots under print-length",
+			expr: `(defstruct cnode x y) (let ((*print-length* 1)) (prin1-to-string (make-cnode :x 1 :y 2)))`,
+			want: `"#S(cnode :x 1 ...)"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("got: %s, expected: %s", got, tt.want)
+			}
+		})
+	}
+}
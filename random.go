@@ -0,0 +1,192 @@
+package banglisp
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+)
+
+// RandomState is a splitmix64 generator. Its whole state is one integer so
+// that it can be printed and read back to replay random sequences.
+type RandomState struct {
+	state uint64
+}
+
+var randomStateObj *Object
+
+func newRandomState(seed uint64) *Object {
+	return newObject(RandomStateType, &RandomState{state: seed})
+}
+
+func (r *RandomState) next() uint64 {
+	r.state += 0x9e3779b97f4a7c15
+	z := r.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// uint64n returns uniform random number in [0, n)
+func (r *RandomState) uint64n(n uint64) uint64 {
+	// reject values below threshold to avoid modulo bias
+	threshold := -n % n
+	for {
+		v := r.next()
+		if v >= threshold {
+			return v % n
+		}
+	}
+}
+
+// bigIntn returns uniform random number in [0, n)
+func (r *RandomState) bigIntn(n *big.Int) *big.Int {
+	bits := n.BitLen()
+	words := make([]big.Word, (bits+63)/64)
+	ret := new(big.Int)
+	for {
+		for i := range words {
+			words[i] = big.Word(r.next())
+		}
+		if extra := uint(len(words)*64 - bits); extra > 0 {
+			words[len(words)-1] &= big.Word(math.MaxUint64 >> extra)
+		}
+
+		ret.SetBits(words)
+		if ret.Cmp(n) < 0 {
+			return new(big.Int).Set(ret)
+		}
+	}
+}
+
+// float64 returns uniform random number in [0, 1)
+func (r *RandomState) float64() float64 {
+	return float64(r.next()>>11) / (1 << 53)
+}
+
+func (r *RandomState) float32() float32 {
+	return float32(r.next()>>40) / (1 << 24)
+}
+
+func (r *RandomState) String() string {
+	return fmt.Sprintf("#S(random-state :state %s)", new(big.Int).SetUint64(r.state).String())
+}
+
+func randomStateValue(function string, obj *Object) (*RandomState, error) {
+	r, ok := obj.value.(*RandomState)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: function, argument: obj}
+	}
+
+	return r, nil
+}
+
+func builtinRandom(_ *Environment, args []*Object) (*Object, error) {
+	// (random limit &optional random-state)
+	if len(args) > 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+	}
+
+	stateObj := specialValue(randomStateObj)
+	if len(args) == 2 {
+		stateObj = args[1]
+	}
+
+	r, err := randomStateValue("random", stateObj)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := args[0]
+	if !isReal(limit) || sign(limit) <= 0 {
+		return nil, &ErrUnsupportedArgumentType{function: "random", argument: limit}
+	}
+
+	switch v := limit.value.(type) {
+	case int64:
+		return newFixnum(int64(r.uint64n(uint64(v)))), nil
+	case *big.Int:
+		return newInteger(r.bigIntn(v)), nil
+	case *Decimal:
+		return newDecimal(r.bigIntn(v.unscaled), v.scale), nil
+	case float64:
+		for {
+			if ret := r.float64() * v; ret < v {
+				return newFloat(ret), nil
+			}
+		}
+	case float32:
+		for {
+			if ret := r.float32() * v; ret < v {
+				return newSingleFloat(ret), nil
+			}
+		}
+	default:
+		return nil, &ErrUnsupportedArgumentType{function: "random", argument: limit}
+	}
+}
+
+func builtinMakeRandomState(_ *Environment, args []*Object) (*Object, error) {
+	// (make-random-state &optional state)
+	if len(args) > 1 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 1, got: len(args)}
+	}
+
+	state := nilObj
+	if len(args) == 1 {
+		state = args[0]
+	}
+
+	switch {
+	case isNull(state):
+		r, err := randomStateValue("make-random-state", specialValue(randomStateObj))
+		if err != nil {
+			return nil, err
+		}
+		return newRandomState(r.state), nil
+	case state == tObj:
+		return newRandomState(uint64(time.Now().UnixNano())), nil
+	case isInteger(state):
+		// seeded by the low 64 bits of the integer
+		v, _ := bigIntValue(state)
+		seed := new(big.Int).And(v, new(big.Int).SetUint64(math.MaxUint64))
+		return newRandomState(seed.Uint64()), nil
+	}
+
+	r, err := randomStateValue("make-random-state", state)
+	if err != nil {
+		return nil, err
+	}
+
+	return newRandomState(r.state), nil
+}
+
+func readRandomState(args []*Object) (*Object, error) {
+	if len(args) != 2 {
+		return nil, &ErrInvalidSyntax{message: "invalid random-state syntax"}
+	}
+
+	if name, ok := keywordName(args[0]); !ok || name != "state" {
+		return nil, &ErrInvalidSyntax{message: "invalid random-state slot", text: args[0].String()}
+	}
+
+	v, ok := bigIntValue(args[1])
+	if !ok || v.Sign() < 0 || v.BitLen() > 64 {
+		return nil, &ErrInvalidSyntax{message: "invalid random-state state", text: args[1].String()}
+	}
+
+	return newRandomState(v.Uint64()), nil
+}
+
+func initRandomFunctions() {
+	randomStateObj = newSymbol("*random-state*")
+	randomStateObj.value.(*Symbol).value = newRandomState(uint64(time.Now().UnixNano()))
+
+	structureReaders[newSymbol("random-state")] = readRandomState
+
+	installBuiltinFunction("random", builtinRandom, 1, true)
+	installBuiltinFunction("make-random-state", builtinMakeRandomState, 0, true)
+	installBuiltinFunction("random-state-p", typePredicate(func(obj *Object) bool {
+		return obj.kind == RandomStateType
+	}), 1, false)
+}
@@ -0,0 +1,128 @@
+package banglisp
+
+import (
+	"testing"
+)
+
+func TestRandom(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "integer in range",
+			expr: `(defun rnd-check (n limit)
+  (if (= n 0)
+      t
+      (let ((x (random limit)))
+        (if (and (>= x 0) (< x limit) (eq (type-of x) (type-of limit)))
+            (rnd-check (- n 1) limit)
+            x))))
+(rnd-check 200 10)`,
+			want: "t",
+		},
+		{
+			name: "float in range",
+			expr: "(rnd-check 200 1.5)",
+			want: "t",
+		},
+		{
+			name: "bignum in range",
+			expr: `(let ((n (random 1000000000000000000000000))) (and (integerp n) (>= n 0) (< n 1000000000000000000000000)))`,
+			want: "t",
+		},
+		{
+			name: "result types",
+			expr: "(list (type-of (random 1.0f0)) (type-of (random 1.0)) (type-of (random #m1.00)))",
+			want: "(single-float double-float decimal)",
+		},
+		{
+			name: "seeded states are reproducible",
+			expr: `(let ((a (make-random-state 42)) (b (make-random-state 42)))
+  (list (= (random 1000000 a) (random 1000000 b))
+        (= (random 1000000 a) (random 1000000 b))))`,
+			want: "(t t)",
+		},
+		{
+			name: "copied state replays",
+			expr: `(let* ((a (make-random-state 7)) (b (make-random-state a)))
+  (= (random 1.0 a) (random 1.0 b)))`,
+			want: "t",
+		},
+		{
+			name: "random-state special variable",
+			expr: `(let ((x (let ((*random-state* (make-random-state 1))) (random 100000)))
+      (y (let ((*random-state* (make-random-state 1))) (random 100000))))
+  (= x y))`,
+			want: "t",
+		},
+		{
+			name: "copy current state",
+			expr: `(let* ((*random-state* (make-random-state 3)) (saved (make-random-state nil)) (x (random 100000)))
+  (setq *random-state* saved)
+  (= x (random 100000)))`,
+			want: "t",
+		},
+		{
+			name: "print and read random state",
+			expr: "(make-random-state 10)",
+			want: "#S(random-state :state 10)",
+		},
+		{
+			name: "readable random state",
+			expr: "(random 1000000 #S(random-state :state 42))",
+			want: "(random 1000000 (make-random-state 42))",
+		},
+		{
+			name: "random-state-p",
+			expr: "(list (random-state-p *random-state*) (random-state-p 1) (type-of *random-state*))",
+			want: "(t nil random-state)",
+		},
+		{
+			name:    "zero limit",
+			expr:    "(random 0)",
+			wantErr: true,
+		},
+		{
+			name:    "ratio limit",
+			expr:    "(random 1/2)",
+			wantErr: true,
+		},
+		{
+			name:    "non number limit",
+			expr:    "(random 'a)",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("could not evaluate %s: %v", tt.expr, err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			want := tt.want
+			if tt.name == "readable random state" {
+				w, err := readEvalString(tt.want)
+				if err != nil {
+					t.Errorf("could not evaluate %s: %v", tt.want, err)
+					return
+				}
+				want = w.String()
+			}
+
+			if got.String() != want {
+				t.Errorf("%s => got: %v, expected %s", tt.expr, *got, want)
+				return
+			}
+		})
+	}
+}
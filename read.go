@@ -1,164 +1,318 @@
 package banglisp
 
 import (
-	"bufio"
 	"fmt"
 	"io"
-	"log"
+	"math/big"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
-func isSpace(c byte) bool {
-	return c == ' ' || c == '\f' || c == '\n' || c == '\r' || c == '\t' || c == '\v'
+func isSpace(c rune) bool {
+	return unicode.IsSpace(c)
 }
 
-func isDigit(c byte) bool {
+func isDigit(c rune) bool {
 	return c >= '0' && c <= '9'
 }
 
-func isAlpha(c byte) bool {
-	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+// isDelimiter reports whether c terminates a token. Terminating macro
+// characters of the current readtable are delimiters.
+func isDelimiter(c rune) bool {
+	return isSpace(c) || currentReadtable().isTerminatingMacro(c)
 }
 
-func isDelimiter(c byte) bool {
-	return isSpace(c) || c == '(' || c == ')' || c == '"' || c == ';'
-}
-
-func isInitialSymbolChar(c byte) bool {
-	return isAlpha(c) || c == '+' || c == '-' || c == '*' || c == '/' || c == '%' ||
-		c == '>' || c == '<' || c == '=' || c == '?' || c == '!'
-}
-
-func nextCharIsDigit(br *bufio.Reader) bool {
-	bs, err := br.Peek(1)
+func nextCharIsDelimiter(br *sourceReader) bool {
+	c, err := br.peekRune()
 	if err != nil {
 		return false
 	}
 
-	return isDigit(bs[0])
+	return isDelimiter(c)
 }
 
-func nextCharIsDelimiter(br *bufio.Reader) bool {
-	bs, err := br.Peek(1)
-	if err != nil {
-		return false
-	}
-
-	return isDelimiter(bs[0])
-}
-
-func skipWhiteSpace(br *bufio.Reader) {
+func skipWhiteSpace(br *sourceReader) error {
 	for {
-		c, err := br.ReadByte()
+		c, err := br.readRune()
 		if err == io.EOF {
-			return
+			return nil
 		}
 
 		if err != nil {
-			log.Fatalln(err)
+			return err
 		}
 
-		if isSpace(c) {
-			continue
+		if !isSpace(c) {
+			return br.unreadRune()
 		}
+	}
+}
 
-		if c == ';' { // comment
-			for {
-				c, err = br.ReadByte()
-				if err == io.EOF {
-					break
-				}
+var decimalIntegerSyntax = regexp.MustCompile(`^[+-]?\d+\.$`)
 
-				if err != nil {
-					log.Fatalln(err)
-				}
+var floatSyntax = regexp.MustCompile(`^[+-]?(\d+\.\d*|\.\d+|\d+)([esfdlESFDL][+-]?\d+)?$`)
 
-				if c == '\n' {
-					break
-				}
-			}
-		}
+// readBase returns the value of *read-base*
+func readBase() (int, error) {
+	v := specialValue(readBaseObj)
+	if base, ok := v.value.(int64); ok && base >= 2 && base <= 36 {
+		return int(base), nil
+	}
 
-		unreadChar(br)
-		return
+	return 0, &ErrInvalidSyntax{message: "invalid *read-base*", text: v.String()}
+}
+
+// parseRational parses integer or ratio in base. The second return value is
+// false if token is not rational syntax.
+func parseRational(token string, base int) (*Object, bool, error) {
+	num, den := token, ""
+	i := strings.IndexByte(token, '/')
+	if i >= 0 {
+		num, den = token[:i], token[i+1:]
+	}
+
+	n, ok := new(big.Int).SetString(num, base)
+	if !ok {
+		return nil, false, nil
+	}
+
+	if i < 0 {
+		return newInteger(n), true, nil
+	}
+
+	if den == "" || den[0] == '+' || den[0] == '-' {
+		return nil, false, nil
 	}
+
+	d, ok := new(big.Int).SetString(den, base)
+	if !ok {
+		return nil, false, nil
+	}
+
+	if d.Sign() == 0 {
+		return nil, true, &ErrInvalidToken{token: token, reason: "ratio with zero denominator"}
+	}
+
+	return newRational(new(big.Rat).SetFrac(n, d)), true, nil
 }
 
-func unreadChar(br *bufio.Reader) {
-	if err := br.UnreadByte(); err != nil {
-		log.Fatalln(err)
+// parseNumber parses token as number. Integers and ratios are read in base
+// while floats are always decimal. The second return value is false if token
+// is not number syntax.
+func parseNumber(token string, base int) (*Object, bool, error) {
+	// a trailing decimal point makes a decimal integer in any base
+	if decimalIntegerSyntax.MatchString(token) {
+		return parseRational(token[:len(token)-1], 10)
+	}
+
+	if obj, ok, err := parseRational(token, base); ok {
+		return obj, true, err
 	}
+
+	m := floatSyntax.FindStringSubmatch(token)
+	if m == nil || (!strings.Contains(m[1], ".") && m[2] == "") {
+		return nil, false, nil
+	}
+
+	var marker byte
+	if m[2] != "" {
+		marker = m[2][0]
+		token = token[:len(token)-len(m[2])] + "e" + m[2][1:]
+	}
+
+	obj, err := parseFloat(token, marker)
+	return obj, true, err
 }
 
-func readNumber(br *bufio.Reader, first byte) (*Object, error) {
-	var sign int64 = 1
-	if first == '-' {
-		sign = -1
-	} else {
-		unreadChar(br)
+// isPotentialNumber reports whether token starts like a number. As in Common
+// Lisp, tokens ending with a sign such as 1+ and tokens with two adjacent
+// letters such as 1st are not potential numbers. Letters which are digits
+// in *read-base* are not counted as letters.
+func isPotentialNumber(token string) bool {
+	if token == "" {
+		return false
 	}
 
-	var c byte
-	var err error
-	var num float64 = 0
-	hasPoint := false
-	var div float64 = 10
-	eof := false
-	for {
-		c, err = br.ReadByte()
-		if err == io.EOF {
-			eof = true
-			break
-		}
-		if err != nil {
-			return nil, err
-		}
+	s := token
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		s = s[1:]
+	}
+	if s != "" && s[0] == '.' {
+		s = s[1:]
+	}
 
-		if c == '.' {
-			if hasPoint {
-				return nil, fmt.Errorf("float value contains multiple dots")
+	last := token[len(token)-1]
+	if s == "" || !isDigit(rune(s[0])) || last == '+' || last == '-' {
+		return false
+	}
+
+	base, err := readBase()
+	if err != nil {
+		base = 10
+	}
+
+	digits := "0123456789abcdefghijklmnopqrstuvwxyz"[:base]
+	prevLetter := false
+	for _, c := range token {
+		letter := false
+		switch {
+		case strings.ContainsRune(digits, unicode.ToLower(c)):
+		case unicode.IsLetter(c):
+			if prevLetter {
+				return false
 			}
-			hasPoint = true
-			continue
+			letter = true
+		case !strings.ContainsRune("+-/.^_", c):
+			return false
 		}
+		prevLetter = letter
+	}
 
-		if !isDigit(c) {
-			break
-		}
+	return true
+}
 
-		if hasPoint {
-			num = num + (float64(c-'0') / div)
-			div *= 10
-		} else {
-			num = (num * 10) + float64(c-'0')
-		}
+// parseToken makes number or symbol from token. Potential numbers which
+// are not valid numbers are errors. Tokens with escapes are always symbols.
+func parseToken(t *token) (*Object, error) {
+	// tokens of skipped forms are not interpreted
+	if !isNull(specialValue(readSuppressObj)) {
+		return nilObj, nil
 	}
 
-	num *= float64(sign)
+	if t.hasEscape {
+		return symbolFromToken(t)
+	}
 
-	if eof || isDelimiter(c) {
-		if !eof {
-			unreadChar(br)
-		}
+	token := t.String()
+	base, err := readBase()
+	if err != nil {
+		return nil, err
+	}
 
-		if hasPoint {
-			return newFloat(num), nil
-		} else {
-			return newFixnum(int64(num)), nil
+	obj, ok, err := parseNumber(token, base)
+	if ok {
+		return obj, err
+	}
+
+	if isPotentialNumber(token) {
+		return nil, &ErrInvalidToken{token: token, reason: "invalid number syntax"}
+	}
+
+	if strings.Trim(token, ".") == "" {
+		return nil, &ErrInvalidToken{token: token, reason: "token consists only of dots"}
+	}
+
+	return symbolFromToken(t)
+}
+
+// readRational reads #b, #o, #x and #NNr syntax
+func readRational(br *sourceReader, base int) (*Object, error) {
+	c, err := br.readRune()
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := readToken(br, c)
+	if err != nil {
+		return nil, err
+	}
+
+	token := t.String()
+	obj, ok, err := parseRational(token, base)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, &ErrInvalidToken{token: token, reason: fmt.Sprintf("invalid number in base %d", base)}
+	}
+
+	return obj, nil
+}
+
+func readBinary(br *sourceReader, _ rune, _ *Object) (*Object, error) {
+	return readRational(br, 2)
+}
+
+func readOctal(br *sourceReader, _ rune, _ *Object) (*Object, error) {
+	return readRational(br, 8)
+}
+
+func readHexadecimal(br *sourceReader, _ rune, _ *Object) (*Object, error) {
+	return readRational(br, 16)
+}
+
+func readRadix(br *sourceReader, _ rune, arg *Object) (*Object, error) {
+	base, ok := arg.value.(int64)
+	if !ok || base < 2 || base > 36 {
+		return nil, &ErrInvalidSyntax{message: "invalid radix for #r", text: arg.String()}
+	}
+
+	return readRational(br, int(base))
+}
+
+// parseFloat parses float literal whose exponent marker is already replaced
+// with 'e'. Markers s and f denote single float, others double float.
+func parseFloat(s string, marker byte) (*Object, error) {
+	switch marker {
+	case 's', 'S', 'f', 'F':
+		f, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return nil, &ErrInvalidToken{token: s, reason: "could not parse float"}
+		}
+		return newSingleFloat(float32(f)), nil
+	default:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, &ErrInvalidToken{token: s, reason: "could not parse float"}
 		}
+		return newFloat(f), nil
 	}
+}
 
-	return nil, fmt.Errorf("could not parse fixnum")
+// unescapeChar returns the character denoted by escape sequence \c in
+// string literal. Other escaped characters denote themselves.
+func unescapeChar(br *sourceReader, c rune) (rune, error) {
+	switch c {
+	case 'n':
+		return '\n', nil
+	case 't':
+		return '\t', nil
+	case 'r':
+		return '\r', nil
+	case 'u':
+		var hex strings.Builder
+		for i := 0; i < 4; i++ {
+			d, err := br.readRune()
+			if err == io.EOF {
+				return 0, &ErrEndOfFile{context: "string"}
+			}
+			if err != nil {
+				return 0, err
+			}
+			hex.WriteRune(d)
+		}
+
+		code, err := strconv.ParseUint(hex.String(), 16, 16)
+		if err != nil {
+			return 0, &ErrInvalidSyntax{message: "invalid unicode escape", text: `\u` + hex.String()}
+		}
+		return rune(code), nil
+	default:
+		return c, nil
+	}
 }
 
-func readString(br *bufio.Reader) (*Object, error) {
+func readString(br *sourceReader) (*Object, error) {
 	var sb strings.Builder
 
 	for {
-		c, err := br.ReadByte()
+		c, err := br.readRune()
 		if err == io.EOF {
-			return nil, fmt.Errorf("string literal is not terminated")
+			return nil, &ErrEndOfFile{context: "string"}
 		}
 		if err != nil {
 			return nil, err
@@ -167,169 +321,929 @@ func readString(br *bufio.Reader) (*Object, error) {
 		if c == '"' {
 			break
 		} else if c == '\\' {
-			c, err = br.ReadByte()
+			c, err = br.readRune()
 			if err == io.EOF {
-				return nil, fmt.Errorf("string literal is not terminated")
+				return nil, &ErrEndOfFile{context: "string"}
 			}
 			if err != nil {
 				return nil, err
 			}
 
-			if c == 'n' {
-				c = '\n'
+			c, err = unescapeChar(br, c)
+			if err != nil {
+				return nil, err
 			}
 		}
 
-		sb.WriteByte(c)
+		sb.WriteRune(c)
 	}
 
 	return newString(sb.String()), nil
 }
 
-func readSymbol(br *bufio.Reader, c byte) (*Object, error) {
-	var sb strings.Builder
-	var err error
-	for {
-		if !(isInitialSymbolChar(c) || isDigit(c)) {
-			break
+// token is the characters of a token. escaped tells which characters were
+// escaped by | or \ so that they are neither case converted nor treated as
+// package markers.
+type token struct {
+	chars   []rune
+	escaped []bool
+	// hasEscape is true if the token contains any escape, even empty ||
+	hasEscape bool
+}
+
+func (t *token) add(c rune, escaped bool) {
+	t.chars = append(t.chars, c)
+	t.escaped = append(t.escaped, escaped)
+}
+
+func (t *token) String() string {
+	return string(t.chars)
+}
+
+// packageMarker returns the index of the first unescaped colon or -1
+func (t *token) packageMarker() int {
+	for i, c := range t.chars {
+		if c == ':' && !t.escaped[i] {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// convertCase converts unescaped characters as the readtable case mode.
+// :invert inverts the case only if all unescaped letters have the same case.
+func (t *token) convertCase(mode readtableCase) {
+	if mode == caseInvert {
+		upper, lower := false, false
+		for i, c := range t.chars {
+			if !t.escaped[i] {
+				upper = upper || unicode.IsUpper(c)
+				lower = lower || unicode.IsLower(c)
+			}
+		}
+
+		if upper && lower {
+			return
+		}
+	}
+
+	for i, c := range t.chars {
+		if t.escaped[i] {
+			continue
+		}
+
+		switch mode {
+		case caseUpcase:
+			t.chars[i] = unicode.ToUpper(c)
+		case caseDowncase:
+			t.chars[i] = unicode.ToLower(c)
+		case caseInvert:
+			if unicode.IsUpper(c) {
+				t.chars[i] = unicode.ToLower(c)
+			} else {
+				t.chars[i] = unicode.ToUpper(c)
+			}
+		}
+	}
+}
+
+// readToken reads characters up to the next delimiter starting with c.
+// Characters between vertical bars and the character after backslash are
+// escaped.
+func readToken(br *sourceReader, c rune) (*token, error) {
+	t := &token{}
+	multiple := false
+	for multiple || !isDelimiter(c) {
+		switch {
+		case c == '\\':
+			next, err := br.readRune()
+			if err == io.EOF {
+				return nil, &ErrEndOfFile{context: "escape"}
+			}
+			if err != nil {
+				return nil, err
+			}
+			t.add(next, true)
+			t.hasEscape = true
+		case c == '|':
+			multiple = !multiple
+			t.hasEscape = true
+		default:
+			t.add(c, multiple)
 		}
 
-		sb.WriteByte(c)
-		c, err = br.ReadByte()
+		next, err := br.readRune()
 		if err == io.EOF {
-			break
+			if multiple {
+				return nil, &ErrEndOfFile{context: "multiple escape"}
+			}
+			t.convertCase(currentReadtable().readCase)
+			return t, nil
 		}
 		if err != nil {
 			return nil, err
 		}
+		c = next
 	}
 
-	if !isDelimiter(c) {
-		return nil, fmt.Errorf("symbol not followed by delimiter")
+	if err := br.unreadRune(); err != nil {
+		return nil, err
 	}
 
-	unreadChar(br)
-
-	return newSymbol(sb.String()), nil
+	t.convertCase(currentReadtable().readCase)
+	return t, nil
 }
 
-func readList(br *bufio.Reader) (*Object, error) {
-	skipWhiteSpace(br)
+// symbolFromToken interns the symbol named by token. Tokens starting with
+// colon are keywords, and pkg:name or pkg::name refers to a symbol in
+// package pkg.
+func symbolFromToken(t *token) (*Object, error) {
+	text := t.String()
+	i := t.packageMarker()
+	if i < 0 {
+		return newSymbol(text), nil
+	}
+
+	if i == 0 {
+		name := &token{chars: t.chars[1:], escaped: t.escaped[1:]}
+		if name.packageMarker() >= 0 {
+			return nil, &ErrInvalidToken{token: text, reason: "invalid symbol syntax"}
+		}
+		return newKeyword(name.String()), nil
+	}
+
+	pkgName := string(t.chars[:i])
+	name := &token{chars: t.chars[i+1:], escaped: t.escaped[i+1:]}
+	internal := name.packageMarker() == 0
+	if internal {
+		name = &token{chars: name.chars[1:], escaped: name.escaped[1:]}
+	}
+
+	if len(name.chars) == 0 || name.packageMarker() >= 0 {
+		return nil, &ErrInvalidToken{token: text, reason: "invalid symbol syntax"}
+	}
+
+	return qualifiedSymbol(pkgName, name.String(), internal)
+}
 
-	c, err := br.ReadByte()
-	if err == io.EOF {
-		return nil, fmt.Errorf("list is not closed")
+// readUninternedSymbol reads #: syntax
+func readUninternedSymbol(br *sourceReader, _ rune, _ *Object) (*Object, error) {
+	c, err := br.readRune()
+	if err != nil {
+		return nil, err
 	}
+
+	t, err := readToken(br, c)
 	if err != nil {
 		return nil, err
 	}
 
-	if c == ')' {
-		return emptyList, nil
+	if len(t.chars) == 0 && !t.hasEscape || t.packageMarker() >= 0 {
+		return nil, &ErrInvalidToken{token: "#:" + t.String(), reason: "invalid uninterned symbol syntax"}
 	}
 
-	unreadChar(br)
-	carObj, err := read1(br)
+	return newSymbolInternal(t.String()), nil
+}
+
+// readFunction reads #'name as (function name)
+func readFunction(br *sourceReader, _ rune, _ *Object) (*Object, error) {
+	obj, err := read1(br)
 	if err != nil {
 		return nil, err
 	}
 
-	skipWhiteSpace(br)
+	return cons(newSymbol("function"), cons(obj, emptyList)), nil
+}
 
-	c, err = br.ReadByte()
+// readEval reads #.form and evaluates form at read time. It is an error if
+// *read-eval* is nil.
+func readEval(br *sourceReader, _ rune, _ *Object) (*Object, error) {
+	obj, err := read1(br)
 	if err != nil {
 		return nil, err
 	}
 
-	if c == '.' {
-		// dotted-pair
-		if !nextCharIsDelimiter(br) {
-			return nil, fmt.Errorf("dot not followed by delimiter")
+	if !isNull(specialValue(readSuppressObj)) {
+		return nilObj, nil
+	}
+
+	if isNull(specialValue(readEvalObj)) {
+		return nil, &ErrInvalidSyntax{message: "#. is not allowed since *read-eval* is nil", text: obj.String()}
+	}
+
+	return Eval(obj)
+}
+
+// readUnreadable signals an error for #< which starts printed representation
+// of objects that cannot be read back
+func readUnreadable(_ *sourceReader, _ rune, _ *Object) (*Object, error) {
+	return nil, &ErrInvalidSyntax{message: "unreadable object", text: "#<"}
+}
+
+func readList(br *sourceReader) (*Object, error) {
+	var elems []*Object
+	var locs []*sourceLocation
+	var tail *Object
+	dotted := false
+	for {
+		if err := skipWhiteSpace(br); err != nil {
+			return nil, err
 		}
 
-		cdrObj, err := read1(br)
+		c, err := br.readRune()
+		if err == io.EOF {
+			return nil, &ErrEndOfFile{context: "list"}
+		}
 		if err != nil {
 			return nil, err
 		}
 
-		skipWhiteSpace(br)
-		c, err = br.ReadByte()
+		if c == ')' {
+			if dotted && tail == nil {
+				return nil, &ErrInvalidSyntax{message: "no object follows dot"}
+			}
+			break
+		}
+
+		if c == '.' && nextCharIsDelimiter(br) {
+			// dotted-pair
+			if len(elems) == 0 || dotted {
+				return nil, &ErrInvalidSyntax{message: "invalid dot in list", text: "."}
+			}
+			dotted = true
+			continue
+		}
+
+		if err := br.unreadRune(); err != nil {
+			return nil, err
+		}
+
+		loc := br.location()
+		obj, ok, err := readObject(br)
 		if err != nil {
 			return nil, err
 		}
 
-		if c != ')' {
-			return nil, fmt.Errorf("list is not closed by right paren")
+		if !ok {
+			continue
+		}
+
+		if dotted {
+			if tail != nil {
+				return nil, &ErrInvalidSyntax{message: "more than one object follows dot", text: obj.String()}
+			}
+			tail = obj
+			continue
 		}
 
-		return cons(carObj, cdrObj), nil
+		elems = append(elems, obj)
+		locs = append(locs, loc)
 	}
 
-	unreadChar(br)
+	ret := emptyList
+	if tail != nil {
+		ret = tail
+	}
+	for i := len(elems) - 1; i >= 0; i-- {
+		ret = locatedCons(elems[i], ret, locs[i])
+	}
+
+	return ret, nil
+}
 
-	cdrObj, err := readList(br)
+func locatedCons(car *Object, cdr *Object, loc *sourceLocation) *Object {
+	ret := cons(car, cdr)
+	setFormLocation(ret, loc)
+	return ret
+}
+
+func readHashTable(br *sourceReader, _ rune, _ *Object) (*Object, error) {
+	c, err := br.readRune()
 	if err != nil {
 		return nil, err
 	}
 
-	return cons(carObj, cdrObj), nil
+	if c != '(' {
+		return nil, &ErrInvalidSyntax{message: "hash table syntax must be followed by list"}
+	}
+
+	list, err := readList(br)
+	if err != nil {
+		return nil, err
+	}
+
+	elems := noEvalArguments(list)
+	if len(elems) == 0 {
+		return nil, &ErrInvalidSyntax{message: "hash table syntax requires test"}
+	}
+
+	test, ok := hashTableTestFromDesignator(elems[0])
+	if !ok {
+		return nil, &ErrInvalidSyntax{message: "invalid hash table test", text: elems[0].String()}
+	}
+
+	ret := newHashTable(test)
+	h := ret.value.(*HashTable)
+	for _, elem := range elems[1:] {
+		pair, ok := elem.value.(*ConsCell)
+		if !ok || elem == emptyList {
+			return nil, &ErrInvalidSyntax{message: "hash table entry is not cons", text: elem.String()}
+		}
+
+		h.put(pair.car, pair.cdr)
+	}
+
+	return ret, nil
 }
 
-func read1(br *bufio.Reader) (*Object, error) {
-	skipWhiteSpace(br)
+// structureReaders reads #S syntax of built-in objects printed as structures
+var structureReaders = make(map[*Object]func(args []*Object) (*Object, error))
 
-	c, err := br.ReadByte()
+func readStructure(br *sourceReader, _ rune, _ *Object) (*Object, error) {
+	c, err := br.readRune()
 	if err != nil {
 		return nil, err
 	}
 
-	if isDigit(c) || (c == '-' && nextCharIsDigit(br)) {
-		return readNumber(br, c)
-	} else if c == '"' {
-		return readString(br)
-	} else if isInitialSymbolChar(c) {
-		return readSymbol(br, c)
-	} else if c == '(' {
-		return readList(br)
-	} else if c == '\'' {
-		rest, err := read1(br)
+	if c != '(' {
+		return nil, &ErrInvalidSyntax{message: "structure syntax must be followed by list"}
+	}
+
+	list, err := readList(br)
+	if err != nil {
+		return nil, err
+	}
+
+	elems := noEvalArguments(list)
+	if len(elems) == 0 || len(elems)%2 != 1 {
+		return nil, &ErrInvalidSyntax{message: "invalid structure syntax", text: list.String()}
+	}
+
+	if reader, ok := structureReaders[elems[0]]; ok {
+		return reader(elems[1:])
+	}
+
+	class, ok := structureClasses[elems[0]]
+	if !ok {
+		return nil, &ErrInvalidSyntax{message: "not a structure", text: elems[0].String()}
+	}
+
+	values := make([]*Object, len(class.slots))
+	for i := 1; i < len(elems); i += 2 {
+		name, ok := symbolNameString(elems[i])
+		index := class.slotIndex(name)
+		if !ok || index < 0 {
+			return nil, &ErrInvalidSyntax{message: fmt.Sprintf("invalid slot name for %v", *elems[0]), text: elems[i].String()}
+		}
+
+		values[index] = elems[i+1]
+	}
+
+	return class.makeInstance(defaultEnvironment, values)
+}
+
+func readComplex(br *sourceReader, _ rune, _ *Object) (*Object, error) {
+	c, err := br.readRune()
+	if err != nil {
+		return nil, err
+	}
+
+	if c != '(' {
+		return nil, &ErrInvalidSyntax{message: "complex syntax must be followed by list"}
+	}
+
+	list, err := readList(br)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := noEvalArguments(list)
+	if len(parts) != 2 || !isReal(parts[0]) || !isReal(parts[1]) {
+		return nil, &ErrInvalidSyntax{message: "invalid complex syntax", text: list.String()}
+	}
+
+	return builtinComplex(nil, parts)
+}
+
+func readDecimal(br *sourceReader, _ rune, _ *Object) (*Object, error) {
+	var sb strings.Builder
+	for {
+		c, err := br.readRune()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
 			return nil, err
 		}
 
-		quote := intern(newString("quote"), nil)
-		return cons(quote, cons(rest, emptyList)), nil
+		if isDelimiter(c) {
+			if err := br.unreadRune(); err != nil {
+				return nil, err
+			}
+			break
+		}
+		sb.WriteRune(c)
 	}
 
-	return nil, fmt.Errorf("unsupported data type")
+	d, err := parseDecimal(sb.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return newObject(DecimalType, d), nil
 }
 
-func Read(r io.Reader) (*Object, error) {
-	return read1(bufio.NewReader(r))
+func readListMacro(br *sourceReader, _ rune) (*Object, error) {
+	return readList(br)
 }
 
-func ReadEvalFile(file string) (*Object, error) {
-	f, err := os.Open(file)
+func readRightParen(_ *sourceReader, _ rune) (*Object, error) {
+	return nil, &ErrUnbalancedParen{}
+}
+
+func readQuote(br *sourceReader, _ rune) (*Object, error) {
+	rest, err := read1(br)
 	if err != nil {
 		return nil, err
 	}
 
-	br := bufio.NewReader(f)
-	var obj *Object
+	quote := intern(newString("quote"), nil)
+	return cons(quote, cons(rest, emptyList)), nil
+}
+
+func readStringMacro(br *sourceReader, _ rune) (*Object, error) {
+	return readString(br)
+}
+
+func readComment(br *sourceReader, _ rune) (*Object, error) {
 	for {
-		obj, err = read1(br)
+		c, err := br.readRune()
 		if err == io.EOF {
-			break
+			return nil, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if c == '\n' {
+			return nil, nil
 		}
+	}
+}
 
+// readBlockComment reads #| ... |# comment which can be nested
+func readBlockComment(br *sourceReader, _ rune, _ *Object) (*Object, error) {
+	depth := 1
+	var prev rune
+	for depth > 0 {
+		c, err := br.readRune()
+		if err == io.EOF {
+			return nil, &ErrEndOfFile{context: "block comment"}
+		}
 		if err != nil {
 			return nil, err
 		}
 
-		_, err = Eval(obj)
+		if prev == '|' && c == '#' {
+			depth--
+			c = 0
+		} else if prev == '#' && c == '|' {
+			depth++
+			c = 0
+		}
+		prev = c
+	}
+
+	return nil, nil
+}
+
+// readDatumComment reads #; which comments out the next datum
+func readDatumComment(br *sourceReader, _ rune, _ *Object) (*Object, error) {
+	if _, err := read1(br); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// featureEnabled evaluates feature expression. Features are compared by
+// name so that both #+banglisp and #+:banglisp work.
+func featureEnabled(expr *Object) (bool, error) {
+	if expr.kind == ConsCellType && expr != emptyList {
+		elems := noEvalArguments(expr)
+		op, _ := symbolNameString(elems[0])
+		switch op {
+		case "and", "or":
+			for _, elem := range elems[1:] {
+				ok, err := featureEnabled(elem)
+				if err != nil {
+					return false, err
+				}
+
+				if ok == (op == "or") {
+					return ok, nil
+				}
+			}
+			return op == "and", nil
+		case "not":
+			if len(elems) != 2 {
+				return false, &ErrInvalidSyntax{message: "invalid feature expression", text: expr.String()}
+			}
+
+			ok, err := featureEnabled(elems[1])
+			return !ok, err
+		default:
+			return false, &ErrInvalidSyntax{message: "invalid feature expression", text: expr.String()}
+		}
+	}
+
+	if expr.kind != SymbolType {
+		return false, &ErrInvalidSyntax{message: "invalid feature expression", text: expr.String()}
+	}
+
+	name, _ := symbolNameString(expr)
+	for next := specialValue(featuresObj); next.kind == ConsCellType && !isEmptyList(next); {
+		cell := next.value.(*ConsCell)
+		if feature, ok := symbolNameString(cell.car); ok && cell.car.kind == SymbolType && feature == name {
+			return true, nil
+		}
+		next = cell.cdr
+	}
+
+	return false, nil
+}
+
+// readConditional reads #+ and #- syntax. The next form is skipped unless
+// the feature expression is true for #+ or false for #-.
+func readConditional(br *sourceReader, c rune, _ *Object) (*Object, error) {
+	expr, err := read1(br)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, err := featureEnabled(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if enabled == (c == '+') {
+		return read1(br)
+	}
+
+	// bind *read-suppress* so that symbols of the skipped form are not
+	// interned and unknown packages are not errors
+	frame := &Frame{}
+	frame.addBinding(readSuppressObj, tObj)
+	defaultEnvironment.pushFrame(frame)
+	defer defaultEnvironment.popFrame(1)
+
+	if _, err := read1(br); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// replacePlaceholder replaces placeholder in obj with value. It walks
+// conses, structure slots, instance slots and hash table entries.
+func replacePlaceholder(obj *Object, placeholder *Object, value *Object, visited map[int]bool) {
+	replace := func(slot **Object) {
+		if *slot == placeholder {
+			*slot = value
+		} else {
+			replacePlaceholder(*slot, placeholder, value, visited)
+		}
+	}
+
+	for !visited[obj.id] {
+		visited[obj.id] = true
+
+		switch v := obj.value.(type) {
+		case *ConsCell:
+			if !isCons(obj) {
+				return
+			}
+
+			replace(&v.car)
+			if v.cdr == placeholder {
+				v.cdr = value
+				return
+			}
+			obj = v.cdr
+			continue
+		case *Structure:
+			for i := range v.slots {
+				replace(&v.slots[i])
+			}
+		case *Instance:
+			for i := range v.slots {
+				replace(&v.slots[i])
+			}
+		case *HashTable:
+			for _, e := range v.entries {
+				replace(&e.key)
+				replace(&e.value)
+			}
+
+			// the hash values of keys containing the placeholder changed
+			v.rehash()
+		}
+		return
+	}
+}
+
+func labelArgument(c rune, arg *Object) (int64, error) {
+	label, ok := arg.value.(int64)
+	if !ok {
+		return 0, &ErrInvalidSyntax{message: "label is required", text: fmt.Sprintf("#%c", c)}
+	}
+
+	return label, nil
+}
+
+// readLabelDefinition reads #n=object which labels object with n
+func readLabelDefinition(br *sourceReader, c rune, arg *Object) (*Object, error) {
+	if !isNull(specialValue(readSuppressObj)) {
+		return read1(br)
+	}
+
+	label, err := labelArgument(c, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := br.labels[label]; ok {
+		return nil, &ErrInvalidSyntax{message: "label is already defined", text: fmt.Sprintf("#%d=", label)}
+	}
+
+	if br.labels == nil {
+		br.labels = make(map[int64]*Object)
+	}
+
+	// the placeholder stands for the object while the object is being read
+	placeholder := newSymbolInternal(fmt.Sprintf("#%d#", label))
+	br.labels[label] = placeholder
+
+	obj, err := read1(br)
+	if err != nil {
+		return nil, err
+	}
+
+	if obj == placeholder {
+		return nil, &ErrInvalidSyntax{message: "object labels itself", text: fmt.Sprintf("#%d=#%d#", label, label)}
+	}
+
+	br.labels[label] = obj
+	replacePlaceholder(obj, placeholder, obj, make(map[int]bool))
+	return obj, nil
+}
+
+// readLabelReference reads #n# which refers to the object labeled with n
+func readLabelReference(br *sourceReader, c rune, arg *Object) (*Object, error) {
+	if !isNull(specialValue(readSuppressObj)) {
+		return nilObj, nil
+	}
+
+	label, err := labelArgument(c, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, ok := br.labels[label]
+	if !ok {
+		return nil, &ErrInvalidSyntax{message: "undefined label", text: fmt.Sprintf("#%d#", label)}
+	}
+
+	return obj, nil
+}
+
+// readerError locates typed reader error err at loc, the start of the
+// object being read, unless an inner object already located it
+func readerError(err error, loc *sourceLocation) error {
+	if e, ok := err.(locatableError); ok {
+		e.setLocation(loc)
+	}
+
+	return err
+}
+
+// readObject reads an object. The second return value is false if a reader
+// macro such as comment reads no object. It returns io.EOF only if input
+// ends before an object starts.
+func readObject(br *sourceReader) (*Object, bool, error) {
+	if err := skipWhiteSpace(br); err != nil {
+		return nil, false, err
+	}
+
+	loc := br.location()
+	if br.depth == 1 {
+		br.formStart = loc
+	}
+
+	c, err := br.readRune()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if m, ok := currentReadtable().macros[c]; ok {
+		obj, ok, err := callReaderMacro(br, m.function, newCharacter(c))
+		if err == io.EOF {
+			err = &ErrEndOfFile{context: fmt.Sprintf("%c macro", c)}
+		}
+		if err != nil {
+			return nil, false, readerError(err, loc)
+		}
+		if !ok {
+			return nil, false, nil
+		}
+
+		// forms made by reader macros are located at the macro character
+		setFormLocation(obj, loc)
+		return obj, true, nil
+	}
+
+	token, err := readToken(br, c)
+	if err != nil {
+		return nil, false, readerError(err, loc)
+	}
+
+	obj, err := parseToken(token)
+	if err != nil {
+		return nil, false, readerError(err, loc)
+	}
+
+	return obj, true, nil
+}
+
+func read1(br *sourceReader) (*Object, error) {
+	// labels are local to a top level object. Recursive reads by reader
+	// macros share them.
+	if br.depth == 0 {
+		br.labels = nil
+	}
+	br.depth++
+	defer func() { br.depth-- }()
+
+	for {
+		obj, ok, err := readObject(br)
 		if err != nil {
 			return nil, err
 		}
+
+		if ok {
+			if br.depth == 1 {
+				visited := make(map[int]bool)
+				for _, labeled := range br.labels {
+					forgetFormLocations(labeled, visited)
+				}
+			}
+			return obj, nil
+		}
+	}
+}
+
+// ReaderOptions configures Reader. File is the file name used in source
+// locations of forms and errors.
+type ReaderOptions struct {
+	File string
+}
+
+// Position is the position of the next character to be read. Offset is
+// the number of characters read so far.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+	Offset int
+}
+
+// Reader reads forms one by one from an io.Reader. It keeps buffered input
+// between calls, so forms following the one read are not lost.
+//
+// Forms can be iterated in the same way as bufio.Scanner:
+//
+//	r := NewReader(in, nil)
+//	for r.Next() {
+//		Eval(r.Form())
+//	}
+//	if err := r.Err(); err != nil {
+//		// handle the error
+//	}
+type Reader struct {
+	br   *sourceReader
+	form *Object
+	err  error
+}
+
+// NewReader returns a Reader reading from r. opts may be nil.
+func NewReader(r io.Reader, opts *ReaderOptions) *Reader {
+	file := ""
+	if opts != nil {
+		file = opts.File
+	}
+
+	return &Reader{br: newSourceReader(r, file)}
+}
+
+// ReadForm reads the next form. It returns io.EOF if the input ends before
+// a form starts, and *ErrEndOfFile if it ends in the middle of a form.
+func (r *Reader) ReadForm() (*Object, error) {
+	obj, err := read1(r.br)
+	if err == nil || err == io.EOF {
+		return obj, err
+	}
+
+	// typed reader errors are already located
+	if _, ok := err.(locatableError); ok {
+		return nil, err
+	}
+	return nil, &ErrAtLocation{location: r.br.location(), err: err}
+}
+
+// Next reads the next form, which is available by Form. It returns false at
+// the end of input or on an error, which is available by Err.
+func (r *Reader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+
+	r.form, r.err = r.ReadForm()
+	return r.err == nil
+}
+
+// Form returns the form read by the last call of Next
+func (r *Reader) Form() *Object {
+	return r.form
+}
+
+// Err returns the error which stopped Next. It is nil at the end of input.
+func (r *Reader) Err() error {
+	if r.err == io.EOF {
+		return nil
+	}
+
+	return r.err
+}
+
+// Position returns the position of the next character
+func (r *Reader) Position() Position {
+	return Position{
+		File:   r.br.file,
+		Line:   r.br.line,
+		Column: r.br.column,
+		Offset: r.br.offset,
+	}
+}
+
+// DiscardLine skips the rest of the current line, such as input following a
+// reader error. It does nothing at the beginning of a line.
+func (r *Reader) DiscardLine() error {
+	if r.br.column == 1 {
+		return nil
+	}
+
+	for {
+		c, err := r.br.readRune()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if c == '\n' {
+			return nil
+		}
+	}
+}
+
+// Read reads a form from r. Input buffered after the form is discarded, so
+// use Reader to read multiple forms from one io.Reader.
+func Read(r io.Reader) (*Object, error) {
+	return NewReader(r, nil).ReadForm()
+}
+
+func ReadEvalFile(file string) (*Object, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := NewReader(f, &ReaderOptions{File: file})
+	obj := nilObj
+	for r.Next() {
+		obj, err = Eval(r.Form())
+		if err != nil {
+			// errors of atoms have no location of their own
+			return nil, locateError(err, r.br.formStart)
+		}
+	}
+
+	if err := r.Err(); err != nil {
+		return nil, err
 	}
 
 	return obj, nil
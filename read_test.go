@@ -1,6 +1,8 @@
 package banglisp
 
 import (
+	"errors"
+	"io"
 	"math/big"
 	"strings"
 	"testing"
@@ -31,9 +33,15 @@ func TestReadFixnum(t *testing.T) {
 			want:    -12345,
 			wantErr: false,
 		},
+		{
+			name:    "end with point",
+			expr:    "-123.",
+			want:    -123,
+			wantErr: false,
+		},
 		{
 			name:    "invalid number",
-			expr:    "-123xyz",
+			expr:    "-123x",
 			wantErr: true,
 		},
 	}
@@ -69,6 +77,218 @@ func TestReadFixnum(t *testing.T) {
 	}
 }
 
+func TestReadBignum(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{
+			name: "positive bignum",
+			expr: "123456789012345678901234567890",
+			want: "123456789012345678901234567890",
+		},
+		{
+			name: "negative bignum",
+			expr: "-9223372036854775809",
+			want: "-9223372036854775809",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(strings.NewReader(tt.expr))
+			if err != nil {
+				t.Errorf("Read() error = %v", err)
+				return
+			}
+
+			if got.kind != BignumType {
+				t.Errorf("got invalid type object [input]: %s -> %v", tt.expr, got.kind)
+				return
+			}
+
+			v := got.value.(*big.Int)
+			if v.String() != tt.want {
+				t.Errorf("got invalid value object [input]: %s -> Got: %v, Expected: %s", tt.expr, v, tt.want)
+				return
+			}
+		})
+	}
+}
+
+func TestReadRatio(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "ratio",
+			expr: "1/3",
+			want: "1/3",
+		},
+		{
+			name: "negative ratio is normalized",
+			expr: "-4/6",
+			want: "-2/3",
+		},
+		{
+			name: "ratio denoting integer",
+			expr: "4/2",
+			want: "2",
+		},
+		{
+			name:    "zero denominator",
+			expr:    "1/0",
+			wantErr: true,
+		},
+		{
+			name:    "missing denominator",
+			expr:    "1/",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(strings.NewReader(tt.expr))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Read() error = %v", err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("got invalid value object [input]: %s -> Got: %v, Expected: %s", tt.expr, *got, tt.want)
+				return
+			}
+		})
+	}
+}
+
+func TestReadComplex(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "complex",
+			expr: "#C(1 2)",
+			want: "#C(1E+00 2E+00)",
+		},
+		{
+			name: "lower case",
+			expr: "#c(1.5 -2)",
+			want: "#C(1.5E+00 -2E+00)",
+		},
+		{
+			name: "rational complex with zero imaginary part",
+			expr: "#C(3 0)",
+			want: "3",
+		},
+		{
+			name:    "missing part",
+			expr:    "#C(1)",
+			wantErr: true,
+		},
+		{
+			name:    "non number part",
+			expr:    "#C(1 a)",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(strings.NewReader(tt.expr))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Read() error = %v", err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("got invalid value object [input]: %s -> Got: %v, Expected: %s", tt.expr, *got, tt.want)
+				return
+			}
+		})
+	}
+}
+
+func TestReadDecimal(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "decimal",
+			expr: "#m12.34",
+			want: "#m12.34",
+		},
+		{
+			name: "trailing zeros are kept",
+			expr: "#m1.50",
+			want: "#m1.50",
+		},
+		{
+			name: "negative decimal",
+			expr: "#M-0.05",
+			want: "#m-0.05",
+		},
+		{
+			name: "integral decimal",
+			expr: "#m+42",
+			want: "#m42",
+		},
+		{
+			name: "leading dot",
+			expr: "#m.5",
+			want: "#m0.5",
+		},
+		{
+			name:    "invalid digits",
+			expr:    "#m1.2x",
+			wantErr: true,
+		},
+		{
+			name:    "missing digits",
+			expr:    "#m-",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(strings.NewReader(tt.expr))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Read() error = %v", err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("got invalid value object [input]: %s -> Got: %v, Expected: %s", tt.expr, *got, tt.want)
+				return
+			}
+		})
+	}
+}
+
 func TestReadFloat(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -88,12 +308,6 @@ func TestReadFloat(t *testing.T) {
 			want:    big.NewFloat(-123.5),
 			wantErr: false,
 		},
-		{
-			name:    "end with point",
-			expr:    "-123.",
-			want:    big.NewFloat(-123),
-			wantErr: false,
-		},
 		{
 			name:    "more than one point",
 			expr:    "123.45.67",
@@ -138,42 +352,59 @@ func TestReadFloat(t *testing.T) {
 	}
 }
 
-func TestReadString(t *testing.T) {
+func TestReadFloatFormat(t *testing.T) {
 	tests := []struct {
 		name    string
 		expr    string
 		want    string
+		kind    objectType
 		wantErr bool
 	}{
 		{
-			name:    "text",
-			expr:    `"Hello World"`,
-			want:    "Hello World",
-			wantErr: false,
+			name: "single float",
+			expr: "1.5f0",
+			want: "1.5F+00",
+			kind: SingleFloatType,
 		},
 		{
-			name:    "string number",
-			expr:    `"1234"`,
-			want:    "1234",
-			wantErr: false,
+			name: "short float is single float",
+			expr: "-2.5s1",
+			want: "-2.5F+01",
+			kind: SingleFloatType,
 		},
 		{
-			name:    "contain escaped character",
-			expr:    `"foo\nbar"`,
-			want:    "foo\nbar",
-			wantErr: false,
+			name: "double float",
+			expr: "1.0d0",
+			want: "1E+00",
+			kind: FloatType,
 		},
 		{
-			name:    "unterminated string",
-			expr:    `"unterminated`,
+			name: "exponent without point",
+			expr: "1e3",
+			want: "1E+03",
+			kind: FloatType,
+		},
+		{
+			name: "printed representation can be read",
+			expr: "1.25E-02",
+			want: "1.25E-02",
+			kind: FloatType,
+		},
+		{
+			name:    "exponent marker without digits",
+			expr:    "1f",
+			wantErr: true,
+		},
+		{
+			name:    "exponent out of range",
+			expr:    "1d400",
 			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			r := strings.NewReader(tt.expr)
-			got, err := Read(r)
+			got, err := Read(strings.NewReader(tt.expr))
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Read() error = %v", err)
 				return
@@ -183,61 +414,124 @@ func TestReadString(t *testing.T) {
 				return
 			}
 
-			if got == nil {
-				t.Errorf("no error but return value is nil")
-				return
-			}
-
-			if got.kind != StringType {
-				t.Errorf("got invalid type object [input]: %s -> %v", tt.expr, got.kind)
-				return
-			}
-
-			if v, ok := got.value.(string); !(ok && v == tt.want) {
-				t.Errorf("got invalid value object [input]: %s -> Got: %s, Expected: %s", tt.expr, v, tt.want)
+			if got.String() != tt.want || got.kind != tt.kind {
+				t.Errorf("got invalid value object [input]: %s -> Got: %v(%v), Expected: %s(%v)",
+					tt.expr, *got, got.kind, tt.want, tt.kind)
 				return
 			}
 		})
 	}
 }
 
-func TestReadList(t *testing.T) {
+func TestReadNumberSyntax(t *testing.T) {
 	tests := []struct {
 		name    string
 		expr    string
+		want    string
+		kind    objectType
 		wantErr bool
 	}{
 		{
-			name:    "number list",
-			expr:    "(1 2 3)",
-			wantErr: false,
+			name: "leading plus",
+			expr: "+5",
+			want: "5",
+			kind: FixnumType,
 		},
 		{
-			name:    "string list",
-			expr:    `("1" "2" "3")`,
-			wantErr: false,
+			name: "leading dot",
+			expr: ".5",
+			want: "5E-01",
+			kind: FloatType,
 		},
 		{
-			name:    "mixed list",
-			expr:    `(1 "2" t)`,
-			wantErr: false,
+			name: "signed leading dot with exponent",
+			expr: "-.5e1",
+			want: "-5E+00",
+			kind: FloatType,
 		},
 		{
-			name:    "unclosed list string",
-			expr:    `(4 5 "foo"`,
+			name: "fraction is correctly rounded",
+			expr: "0.1",
+			want: "1E-01",
+			kind: FloatType,
+		},
+		{
+			name: "double float exponent",
+			expr: "1.5d-3",
+			want: "1.5E-03",
+			kind: FloatType,
+		},
+		{
+			name: "big integer keeps precision",
+			expr: "+123456789012345678901234567890",
+			want: "123456789012345678901234567890",
+			kind: BignumType,
+		},
+		{
+			name: "hexadecimal",
+			expr: "#x-FF",
+			want: "-255",
+			kind: FixnumType,
+		},
+		{
+			name: "binary ratio",
+			expr: "#b101/11",
+			want: "5/3",
+			kind: RatioType,
+		},
+		{
+			name: "octal",
+			expr: "#o17",
+			want: "15",
+			kind: FixnumType,
+		},
+		{
+			name: "radix",
+			expr: "#36rZZ",
+			want: "1295",
+			kind: FixnumType,
+		},
+		{
+			name: "token ending with sign is symbol",
+			expr: "1-",
+			want: "1-",
+			kind: SymbolType,
+		},
+		{
+			name:    "invalid digit for radix",
+			expr:    "#b102",
 			wantErr: true,
 		},
 		{
-			name:    "unclosed list number",
-			expr:    `(4 5 6`,
+			name:    "radix out of range",
+			expr:    "#37r1",
+			wantErr: true,
+		},
+		{
+			name:    "radix without argument",
+			expr:    "#r1",
+			wantErr: true,
+		},
+		{
+			name:    "float in radix syntax",
+			expr:    "#x1.5",
+			wantErr: true,
+		},
+		{
+			name:    "signed denominator",
+			expr:    "1/-2",
+			wantErr: true,
+		},
+		{
+			name:    "only dots",
+			expr:    "..",
 			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			r := strings.NewReader(tt.expr)
-			got, err := Read(r)
+			got, err := Read(strings.NewReader(tt.expr))
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Read() error = %v", err)
 				return
@@ -247,14 +541,1022 @@ func TestReadList(t *testing.T) {
 				return
 			}
 
-			if got == nil {
-				t.Errorf("no error but return value is nil")
+			if got.String() != tt.want || got.kind != tt.kind {
+				t.Errorf("got invalid value object [input]: %s -> Got: %v(%v), Expected: %s(%v)",
+					tt.expr, *got, got.kind, tt.want, tt.kind)
 				return
 			}
+		})
+	}
+}
 
-			if got.kind != ConsCellType {
-				t.Errorf("got invalid type object [input]: %s -> %v", tt.expr, got.kind)
-				return
+func TestReadBase(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "integers are read in read base",
+			expr: `(let ((*read-base* 16)) (read-from-string "(ff -10 a/b)"))`,
+			want: "(255 -16 10/11)",
+		},
+		{
+			name: "floats are decimal",
+			expr: `(let ((*read-base* 2)) (read-from-string "(10 1.5 11)"))`,
+			want: "(2 1.5E+00 3)",
+		},
+		{
+			name: "non digit token is symbol",
+			expr: `(let ((*read-base* 16)) (read-from-string "fox"))`,
+			want: "fox",
+		},
+		{
+			name:    "invalid read base",
+			expr:    `(let ((*read-base* 1)) (read-from-string "1"))`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("readEvalString(%s) error = %v", tt.expr, err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("got: %v, expected: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadString(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "text",
+			expr:    `"Hello World"`,
+			want:    "Hello World",
+			wantErr: false,
+		},
+		{
+			name:    "string number",
+			expr:    `"1234"`,
+			want:    "1234",
+			wantErr: false,
+		},
+		{
+			name:    "contain escaped character",
+			expr:    `"foo\nbar"`,
+			want:    "foo\nbar",
+			wantErr: false,
+		},
+		{
+			name: "escaped characters",
+			expr: `"tab\there \\ \"quoted\""`,
+			want: "tab\there \\ \"quoted\"",
+		},
+		{
+			name: "unicode escape",
+			expr: `"\u3042\u3044"`,
+			want: "あい",
+		},
+		{
+			name: "multibyte characters",
+			expr: `"日本語"`,
+			want: "日本語",
+		},
+		{
+			name:    "invalid unicode escape",
+			expr:    `"\u30g0"`,
+			wantErr: true,
+		},
+		{
+			name:    "unterminated string",
+			expr:    `"unterminated`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := strings.NewReader(tt.expr)
+			got, err := Read(r)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Read() error = %v", err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got == nil {
+				t.Errorf("no error but return value is nil")
+				return
+			}
+
+			if got.kind != StringType {
+				t.Errorf("got invalid type object [input]: %s -> %v", tt.expr, got.kind)
+				return
+			}
+
+			if v, ok := got.value.(string); !(ok && v == tt.want) {
+				t.Errorf("got invalid value object [input]: %s -> Got: %s, Expected: %s", tt.expr, v, tt.want)
+				return
+			}
+		})
+	}
+}
+
+func TestReadList(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr bool
+	}{
+		{
+			name:    "number list",
+			expr:    "(1 2 3)",
+			wantErr: false,
+		},
+		{
+			name:    "string list",
+			expr:    `("1" "2" "3")`,
+			wantErr: false,
+		},
+		{
+			name:    "mixed list",
+			expr:    `(1 "2" t)`,
+			wantErr: false,
+		},
+		{
+			name:    "unclosed list string",
+			expr:    `(4 5 "foo"`,
+			wantErr: true,
+		},
+		{
+			name:    "unclosed list number",
+			expr:    `(4 5 6`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := strings.NewReader(tt.expr)
+			got, err := Read(r)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Read() error = %v", err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got == nil {
+				t.Errorf("no error but return value is nil")
+				return
+			}
+
+			if got.kind != ConsCellType {
+				t.Errorf("got invalid type object [input]: %s -> %v", tt.expr, got.kind)
+				return
+			}
+		})
+	}
+}
+
+func TestReadCommentsAndFeatures(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "block comment",
+			expr: "'(1 #| comment |# 2)",
+			want: "(1 2)",
+		},
+		{
+			name: "nested block comment",
+			expr: "#| outer #| inner |# still comment |# '(a #|| b ||# c)",
+			want: "(a c)",
+		},
+		{
+			name: "datum comment",
+			expr: "'(1 #;(2 3) 4 #; 5)",
+			want: "(1 4)",
+		},
+		{
+			name: "line comment before close paren",
+			expr: "'(1 ; comment\n)",
+			want: "(1)",
+		},
+		{
+			name: "feature present",
+			expr: "'(#+banglisp yes #-banglisp no)",
+			want: "(yes)",
+		},
+		{
+			name: "keyword feature",
+			expr: "'(#+:banglisp yes #+no-such-feature no)",
+			want: "(yes)",
+		},
+		{
+			name: "feature expressions",
+			expr: "'(#+(or sbcl banglisp) a #+(and sbcl banglisp) b #+(not sbcl) c #-(or sbcl ccl) d)",
+			want: "(a c d)",
+		},
+		{
+			name: "features are special variable",
+			expr: `(let ((*features* '(:sbcl))) (read-from-string "(#+sbcl a #+banglisp b)"))`,
+			want: "(a)",
+		},
+		{
+			name: "skipped form is not interpreted",
+			expr: "'(#+no-such-feature no-such-package:foo #-banglisp 1x ok)",
+			want: "(ok)",
+		},
+		{
+			name:    "unterminated block comment",
+			expr:    "#| comment",
+			wantErr: true,
+		},
+		{
+			name:    "invalid feature expression",
+			expr:    "'#+(xor a b) 1",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("readEvalString(%s) error = %v", tt.expr, err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("got: %v, expected: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadUnicode(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		kind    objectType
+		wantErr bool
+	}{
+		{
+			name: "japanese symbol",
+			expr: "数",
+			want: "数",
+			kind: SymbolType,
+		},
+		{
+			name: "latin symbol",
+			expr: "café",
+			want: "café",
+			kind: SymbolType,
+		},
+		{
+			name: "ideographic space is whitespace",
+			expr: "(あ　い)",
+			want: "(あ い)",
+			kind: ConsCellType,
+		},
+		{
+			name: "character",
+			expr: `#\日`,
+			want: `#\日`,
+			kind: CharacterType,
+		},
+		{
+			name:    "invalid UTF-8",
+			expr:    "ab\xffc",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(strings.NewReader(tt.expr))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Read() error = %v", err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want || got.kind != tt.kind {
+				t.Errorf("got invalid value object [input]: %s -> Got: %v(%v), Expected: %s(%v)",
+					tt.expr, *got, got.kind, tt.want, tt.kind)
+			}
+		})
+	}
+}
+
+func TestReadPotentialNumbers(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "trailing decimal point",
+			expr: "(list 10. -7. (type-of 10.))",
+			want: "(10 -7 fixnum)",
+		},
+		{
+			name: "trailing decimal point in other base",
+			expr: `(let ((*read-base* 16)) (list (read-from-string "10.") (read-from-string "10")))`,
+			want: "(10 16)",
+		},
+		{
+			name: "adjacent letters",
+			expr: "(list '1st (type-of '1st) '3d-point)",
+			want: "(1st symbol 3d-point)",
+		},
+		{
+			name: "ending with sign",
+			expr: "(type-of '1+)",
+			want: "symbol",
+		},
+		{
+			name:    "single letter",
+			expr:    "'2x",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("got: %s, expected: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSymbolCase(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "symbols are read in lower case",
+			expr: "(list (eq 'CAR 'car) (CAR '(1 2)) (symbol-name 'Foo))",
+			want: `(t 1 "foo")`,
+		},
+		{
+			name: "default readtable case",
+			expr: "(readtable-case *readtable*)",
+			want: ":downcase",
+		},
+		{
+			name: "multiple escape",
+			expr: "(list '|Foo Bar| (symbol-name '|Foo Bar|))",
+			want: `(|Foo Bar| "Foo Bar")`,
+		},
+		{
+			name: "single escape",
+			expr: `(list 'a\(b (symbol-name 'a\(b) (symbol-name 'A\BC))`,
+			want: `(|a(b| "a(b" "aBc")`,
+		},
+		{
+			name: "escapes in the middle of token",
+			expr: "(symbol-name 'ab|CD|ef)",
+			want: `"abCDef"`,
+		},
+		{
+			name: "escaped number is symbol",
+			expr: "(list (typep '|1| 'symbol) '|1| '\\1.5)",
+			want: "(t |1| |1.5|)",
+		},
+		{
+			name: "empty symbol name",
+			expr: "(list '|| (symbol-name '||) (symbol-name '#:||))",
+			want: `(|| "" "")`,
+		},
+		{
+			name: "escaped colon is not package marker",
+			expr: "(list '|a:b| :|Key| (symbol-name :|Key|))",
+			want: `(|a:b| :|Key| "Key")`,
+		},
+		{
+			name: "escaped bar and backslash",
+			expr: `(symbol-name '|a\|b\\c|)`,
+			want: `"a|b\\c"`,
+		},
+		{
+			name: "package prefix is case insensitive",
+			expr: "(eq 'cl-user::car 'car)",
+			want: "t",
+		},
+		{
+			name: "upcase",
+			expr: `(let ((*readtable* (copy-readtable))) (setf (readtable-case *readtable*) :upcase) (symbol-name (read-from-string "Foo")))`,
+			want: `"FOO"`,
+		},
+		{
+			name: "preserve",
+			expr: `(let ((*readtable* (copy-readtable))) (setf (readtable-case *readtable*) :preserve) (symbol-name (read-from-string "Foo")))`,
+			want: `"Foo"`,
+		},
+		{
+			name: "invert",
+			expr: `(let ((*readtable* (copy-readtable))) (setf (readtable-case *readtable*) :invert) (list (symbol-name (read-from-string "foo")) (symbol-name (read-from-string "FOO")) (symbol-name (read-from-string "Foo")) (symbol-name (read-from-string "|foo|bar"))))`,
+			want: `("FOO" "foo" "Foo" "fooBAR")`,
+		},
+		{
+			name: "copied readtable keeps case",
+			expr: `(let ((rt (copy-readtable))) (setf (readtable-case rt) :invert) (readtable-case (copy-readtable rt)))`,
+			want: ":invert",
+		},
+		{
+			name:    "invalid readtable case",
+			expr:    "(setf (readtable-case (copy-readtable)) :capitalize)",
+			wantErr: true,
+		},
+		{
+			name:    "standard readtable case cannot be changed",
+			expr:    "(setf (readtable-case nil) :upcase)",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated multiple escape",
+			expr:    "'|foo",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("readEvalString(%s) error = %v", tt.expr, err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("got: %v, expected: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSharpsign(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "function",
+			expr: "(list '#'car (funcall #'car '(1 2)))",
+			want: "((function car) 1)",
+		},
+		{
+			name: "function of lambda",
+			expr: "(funcall #'(lambda (x) (* x 2)) 21)",
+			want: "42",
+		},
+		{
+			name: "read time evaluation",
+			expr: "'(a #.(+ 1 2) b)",
+			want: "(a 3 b)",
+		},
+		{
+			name:    "read time evaluation disabled",
+			expr:    `(let ((*read-eval* nil)) (read-from-string "#.(+ 1 2)"))`,
+			wantErr: true,
+		},
+		{
+			name: "read time evaluation in skipped form",
+			expr: `(let ((*read-eval* nil)) (read-from-string "(#+no-such-feature #.(+ 1 2) ok)"))`,
+			want: "(ok)",
+		},
+		{
+			name: "uninterned symbol",
+			expr: "(list '#:foo (eq '#:foo '#:foo) (symbol-package '#:foo))",
+			want: "(#:foo nil nil)",
+		},
+		{
+			name: "pathname",
+			expr: `(list #P"/usr/lib/foo.lisp" (pathnamep #p"foo") (type-of #P"foo"))`,
+			want: `(#P"/usr/lib/foo.lisp" t pathname)`,
+		},
+		{
+			name: "pathname components",
+			expr: `(list (pathname-directory #P"/usr/lib/foo.lisp") (pathname-name #P"/usr/lib/foo.lisp") (pathname-type #P"/usr/lib/foo.lisp") (pathname-directory #P"src/.emacs") (pathname-type #P".emacs"))`,
+			want: `((:absolute "usr" "lib") "foo" "lisp" (:relative "src") nil)`,
+		},
+		{
+			name: "pathnames are equal by namestring",
+			expr: `(list (equal #P"a/b.c" (pathname "a/b.c")) (namestring #P"a/b.c"))`,
+			want: `(t "a/b.c")`,
+		},
+		{
+			name:    "pathname needs string",
+			expr:    "#P foo",
+			wantErr: true,
+		},
+		{
+			name: "structure",
+			expr: "(defstruct rs-point x y) (rs-point-y #S(rs-point :x 1 :y 2))",
+			want: "2",
+		},
+		{
+			name:    "unreadable object",
+			expr:    "'(a #<function foo> b)",
+			wantErr: true,
+		},
+		{
+			name:    "unreadable object from string",
+			expr:    `(read-from-string "#<readtable {1}>")`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("readEvalString(%s) error = %v", tt.expr, err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("got: %v, expected: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		check   func(err error) bool
+		wantMsg string
+	}{
+		{
+			name: "unclosed list",
+			expr: "(a\n (b c)",
+			check: func(err error) bool {
+				var e *ErrEndOfFile
+				return errors.As(err, &e)
+			},
+			wantMsg: "1:1: end of file in list",
+		},
+		{
+			name: "unclosed inner list",
+			expr: "(a (b",
+			check: func(err error) bool {
+				var e *ErrEndOfFile
+				return errors.As(err, &e)
+			},
+			wantMsg: "1:4: end of file in list",
+		},
+		{
+			name: "unterminated string",
+			expr: `  "abc`,
+			check: func(err error) bool {
+				var e *ErrEndOfFile
+				return errors.As(err, &e)
+			},
+			wantMsg: "1:3: end of file in string",
+		},
+		{
+			name: "nothing after quote",
+			expr: "'",
+			check: func(err error) bool {
+				var e *ErrEndOfFile
+				return errors.As(err, &e)
+			},
+			wantMsg: "1:1: end of file in ' macro",
+		},
+		{
+			name: "nothing after sharpsign",
+			expr: "#",
+			check: func(err error) bool {
+				var e *ErrEndOfFile
+				return errors.As(err, &e)
+			},
+			wantMsg: "1:1: end of file in # macro",
+		},
+		{
+			name: "unterminated multiple escape",
+			expr: "|abc",
+			check: func(err error) bool {
+				var e *ErrEndOfFile
+				return errors.As(err, &e)
+			},
+			wantMsg: "1:1: end of file in multiple escape",
+		},
+		{
+			name: "unbalanced paren",
+			expr: "\n  )",
+			check: func(err error) bool {
+				var e *ErrUnbalancedParen
+				return errors.As(err, &e)
+			},
+			wantMsg: "2:3: unbalanced close parenthesis",
+		},
+		{
+			name: "invalid number",
+			expr: "(1 2x)",
+			check: func(err error) bool {
+				var e *ErrInvalidToken
+				return errors.As(err, &e) && e.token == "2x"
+			},
+			wantMsg: "1:4: invalid number syntax: 2x",
+		},
+		{
+			name: "dots",
+			expr: "...",
+			check: func(err error) bool {
+				var e *ErrInvalidToken
+				return errors.As(err, &e)
+			},
+			wantMsg: "1:1: token consists only of dots: ...",
+		},
+		{
+			name: "unknown character name",
+			expr: `#\Foo`,
+			check: func(err error) bool {
+				var e *ErrInvalidToken
+				return errors.As(err, &e)
+			},
+			wantMsg: `1:1: unknown character name: #\Foo`,
+		},
+		{
+			name: "invalid dot",
+			expr: "(. a)",
+			check: func(err error) bool {
+				var e *ErrInvalidSyntax
+				return errors.As(err, &e)
+			},
+			wantMsg: "1:1: invalid dot in list: .",
+		},
+		{
+			name: "float out of range",
+			expr: "(a 1e400)",
+			check: func(err error) bool {
+				var e *ErrInvalidToken
+				return errors.As(err, &e)
+			},
+			wantMsg: "1:4: could not parse float: 1e400",
+		},
+		{
+			name: "unknown package",
+			expr: "(a\n rd-nopkg:foo)",
+			check: func(err error) bool {
+				var e *ErrInvalidToken
+				return errors.As(err, &e) && e.token == "rd-nopkg:foo"
+			},
+			wantMsg: "2:2: package rd-nopkg is not found: rd-nopkg:foo",
+		},
+		{
+			name: "internal symbol with single colon",
+			expr: "rd-p2:s1",
+			check: func(err error) bool {
+				var e *ErrInvalidToken
+				return errors.As(err, &e)
+			},
+			wantMsg: "1:1: symbol s1 is not external in package rd-p2: rd-p2:s1",
+		},
+		{
+			name: "invalid decimal",
+			expr: "#m1.2.3",
+			check: func(err error) bool {
+				var e *ErrInvalidToken
+				return errors.As(err, &e)
+			},
+			wantMsg: "1:1: invalid decimal syntax: 1.2.3",
+		},
+		{
+			name: "invalid random-state slot",
+			expr: "#S(random-state :seed 1)",
+			check: func(err error) bool {
+				var e *ErrInvalidSyntax
+				return errors.As(err, &e)
+			},
+			wantMsg: "1:1: invalid random-state slot: :seed",
+		},
+		{
+			name: "unsupported dispatch character",
+			expr: "#q",
+			check: func(err error) bool {
+				var e *ErrInvalidSyntax
+				return errors.As(err, &e)
+			},
+			wantMsg: "1:1: unsupported dispatch character: #q",
+		},
+		{
+			name: "invalid UTF-8 in token",
+			expr: "ab\xffc",
+			check: func(err error) bool {
+				var e *ErrInvalidSyntax
+				return errors.As(err, &e)
+			},
+			wantMsg: `1:3: invalid UTF-8 encoding: \xff`,
+		},
+		{
+			name: "invalid UTF-8 before object",
+			expr: "\n \xfe",
+			check: func(err error) bool {
+				var e *ErrInvalidSyntax
+				return errors.As(err, &e)
+			},
+			wantMsg: `2:2: invalid UTF-8 encoding: \xfe`,
+		},
+	}
+
+	if _, err := readEvalString(`(intern "s1" (make-package "rd-p2"))`); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.expr))
+			if err == nil || !tt.check(err) {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+
+			if err.Error() != tt.wantMsg {
+				t.Errorf("got: %s, expected: %s", err.Error(), tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestReaderFunctionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{
+			name: "invalid read-base",
+			expr: `(let ((*read-base* 1)) (read-from-string "10"))`,
+		},
+		{
+			name: "not a dispatch macro character",
+			expr: `(set-dispatch-macro-character #\a #\b (lambda (s c n) nil) (copy-readtable))`,
+		},
+		{
+			name: "digit sub character",
+			expr: `(set-dispatch-macro-character #\# #\1 (lambda (s c n) nil) (copy-readtable))`,
+		},
+		{
+			name: "standard readtable",
+			expr: `(set-macro-character #\! 'car nil nil)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readEvalString(tt.expr)
+			var e *ErrInvalidSyntax
+			if !errors.As(err, &e) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestReadEndOfInput(t *testing.T) {
+	if _, err := Read(strings.NewReader("  ; comment only\n")); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+
+	_, err := readEvalString(`(read-char (make-string-input-stream ""))`)
+	var eof *ErrEndOfFile
+	if !errors.As(err, &eof) {
+		t.Errorf("expected end of file error, got %v", err)
+	}
+}
+
+func TestReader(t *testing.T) {
+	t.Run("keeps buffered forms", func(t *testing.T) {
+		r := NewReader(strings.NewReader("(+ 1 2) (+ 3 4)\nfoo"), nil)
+		var got []string
+		for r.Next() {
+			got = append(got, r.Form().String())
+		}
+
+		if err := r.Err(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if want := "(+ 1 2),(+ 3 4),foo"; strings.Join(got, ",") != want {
+			t.Errorf("got: %v, expected: %s", got, want)
+		}
+	})
+
+	t.Run("read form", func(t *testing.T) {
+		r := NewReader(strings.NewReader("a b"), nil)
+		for _, want := range []string{"a", "b"} {
+			got, err := r.ReadForm()
+			if err != nil || got.String() != want {
+				t.Fatalf("got: %v, %v, expected: %s", got, err, want)
+			}
+		}
+
+		if _, err := r.ReadForm(); err != io.EOF {
+			t.Errorf("expected io.EOF, got %v", err)
+		}
+	})
+
+	t.Run("position", func(t *testing.T) {
+		r := NewReader(strings.NewReader("(a\n b) 日本 c"), &ReaderOptions{File: "test.lisp"})
+		if _, err := r.ReadForm(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.ReadForm(); err != nil {
+			t.Fatal(err)
+		}
+
+		want := Position{File: "test.lisp", Line: 2, Column: 7, Offset: 9}
+		if got := r.Position(); got != want {
+			t.Errorf("got: %+v, expected: %+v", got, want)
+		}
+	})
+
+	t.Run("iteration stops at error", func(t *testing.T) {
+		r := NewReader(strings.NewReader("a (b"), &ReaderOptions{File: "test.lisp"})
+		count := 0
+		for r.Next() {
+			count++
+		}
+
+		var eof *ErrEndOfFile
+		if count != 1 || !errors.As(r.Err(), &eof) {
+			t.Fatalf("got %d forms and error %v", count, r.Err())
+		}
+
+		if want := "test.lisp:1:3: end of file in list"; r.Err().Error() != want {
+			t.Errorf("got: %s, expected: %s", r.Err(), want)
+		}
+	})
+
+	t.Run("discard line", func(t *testing.T) {
+		r := NewReader(strings.NewReader("(1 2x 3) 4\n5"), nil)
+		if _, err := r.ReadForm(); err == nil {
+			t.Fatal("expected error")
+		}
+
+		if err := r.DiscardLine(); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := r.ReadForm()
+		if err != nil || got.String() != "5" {
+			t.Errorf("got: %v, %v, expected: 5", got, err)
+		}
+	})
+}
+
+func TestReadLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "shared object",
+			expr: "(let ((x '(#1=(a b) #1#))) (eq (car x) (car (cdr x))))",
+			want: "t",
+		},
+		{
+			name: "circular list",
+			expr: "(let ((x '#1=(a b . #1#))) (eq x (cdr (cdr x))))",
+			want: "t",
+		},
+		{
+			name: "circular car",
+			expr: "(let ((x '#1=(#1# b))) (eq x (car x)))",
+			want: "t",
+		},
+		{
+			name: "label inside structure",
+			expr: "(defstruct lpoint x) (let ((p '#1=#S(lpoint :x #1#))) (eq p (lpoint-x p)))",
+			want: "t",
+		},
+		{
+			name: "label inside hash table value",
+			expr: "(let ((h '#1=#H(eql (a . #1#)))) (eq h (gethash 'a h)))",
+			want: "t",
+		},
+		{
+			name: "label inside hash table key",
+			expr: "(let ((x '#1=(k #H(equal ((#1#) . found))))) (gethash (list x) (car (cdr x))))",
+			want: "found",
+		},
+		{
+			name: "labels are local to top level object",
+			expr: `(list (read-from-string "#1=(a)") (read-from-string "#1=(b #1#)"))`,
+			want: "((a) #1=(b #1#))",
+		},
+		{
+			name: "label of atom",
+			expr: "'(#5=foo #5# #5#)",
+			want: "(foo foo foo)",
+		},
+		{
+			name: "label in skipped form",
+			expr: "'(#-banglisp #1=a #1=b #1#)",
+			want: "(b b)",
+		},
+		{
+			name:    "label in skipped form is not defined",
+			expr:    "'(#-banglisp #1=a #1#)",
+			wantErr: true,
+		},
+		{
+			name:    "undefined label",
+			expr:    "'(a #2#)",
+			wantErr: true,
+		},
+		{
+			name:    "label defined twice",
+			expr:    "'(#1=a #1=b)",
+			wantErr: true,
+		},
+		{
+			name:    "label without number",
+			expr:    "'#=(a)",
+			wantErr: true,
+		},
+		{
+			name:    "object labels itself",
+			expr:    "'#1=#1#",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("readEvalString(%s) error = %v", tt.expr, err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("got: %v, expected: %s", got, tt.want)
 			}
 		})
 	}
@@ -0,0 +1,565 @@
+package banglisp
+
+import (
+	"io"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+// readerFunction implements a built-in reader macro. It returns nil if it
+// reads no object, like comments.
+type readerFunction func(br *sourceReader, c rune) (*Object, error)
+
+// dispatchReaderFunction implements a built-in dispatch macro. arg is the
+// decimal number between the dispatch character and the sub character, or
+// nil if there is no number.
+type dispatchReaderFunction func(br *sourceReader, c rune, arg *Object) (*Object, error)
+
+type readerMacro struct {
+	function       *Object
+	nonTerminating bool
+	// dispatch maps upcased sub characters to functions if this is a
+	// dispatch macro character
+	dispatch map[rune]*Object
+}
+
+// readtableCase is the case conversion applied to unescaped characters of
+// tokens
+type readtableCase int
+
+const (
+	caseUpcase readtableCase = iota
+	caseDowncase
+	casePreserve
+	caseInvert
+)
+
+var readtableCaseNames = []string{"upcase", "downcase", "preserve", "invert"}
+
+type Readtable struct {
+	macros map[rune]*readerMacro
+	// readCase defaults to :downcase since the names of built-in symbols
+	// are lower case
+	readCase readtableCase
+}
+
+var readtableObj *Object
+var readBaseObj *Object
+var readSuppressObj *Object
+var readEvalObj *Object
+var featuresObj *Object
+var standardReadtable *Readtable
+var dispatchMacroFunction *Object
+
+func newReadtable() *Readtable {
+	return &Readtable{macros: make(map[rune]*readerMacro), readCase: caseDowncase}
+}
+
+func (rt *Readtable) copyInto(to *Readtable) {
+	to.macros = make(map[rune]*readerMacro)
+	to.readCase = rt.readCase
+	for c, m := range rt.macros {
+		macro := *m
+		if m.dispatch != nil {
+			macro.dispatch = make(map[rune]*Object)
+			for sub, fn := range m.dispatch {
+				macro.dispatch[sub] = fn
+			}
+		}
+		to.macros[c] = &macro
+	}
+}
+
+func (rt *Readtable) isTerminatingMacro(c rune) bool {
+	m, ok := rt.macros[c]
+	return ok && !m.nonTerminating
+}
+
+func (rt *Readtable) setMacroCharacter(c rune, function *Object, nonTerminating bool) {
+	rt.macros[c] = &readerMacro{function: function, nonTerminating: nonTerminating}
+}
+
+func (rt *Readtable) makeDispatchMacroCharacter(c rune, nonTerminating bool) {
+	rt.macros[c] = &readerMacro{
+		function:       dispatchMacroFunction,
+		nonTerminating: nonTerminating,
+		dispatch:       make(map[rune]*Object),
+	}
+}
+
+func (rt *Readtable) setDispatchMacroCharacter(c rune, sub rune, function *Object) error {
+	m, ok := rt.macros[c]
+	if !ok || m.dispatch == nil {
+		return &ErrInvalidSyntax{message: "not a dispatch macro character", text: formatCharacter(c)}
+	}
+
+	m.dispatch[unicode.ToUpper(sub)] = function
+	return nil
+}
+
+// currentReadtable returns the value of *readtable*
+func currentReadtable() *Readtable {
+	if rt, ok := specialValue(readtableObj).value.(*Readtable); ok {
+		return rt
+	}
+
+	return standardReadtable
+}
+
+func newReaderMacroFunction(fn readerFunction) *Object {
+	return newBuiltinFunction(func(env *Environment, args []*Object) (*Object, error) {
+		s, err := inputStream("reader macro", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		c, err := characterValue("reader macro", args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		ret, err := fn(s.r, c)
+		if err != nil {
+			return nil, err
+		}
+
+		if ret == nil {
+			return env.setValues(nil), nil
+		}
+
+		return ret, nil
+	}, 2, false)
+}
+
+func newDispatchReaderFunction(fn dispatchReaderFunction) *Object {
+	return newBuiltinFunction(func(_ *Environment, args []*Object) (*Object, error) {
+		s, err := inputStream("dispatch macro", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		c, err := characterValue("dispatch macro", args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		return fn(s.r, c, args[2])
+	}, 3, false)
+}
+
+// callReaderMacro calls reader macro function with the stream and the macro
+// character. The second return value is false if the function returns no
+// values.
+func callReaderMacro(br *sourceReader, function *Object, args ...*Object) (*Object, bool, error) {
+	env := defaultEnvironment
+	env.clearValues()
+
+	ret, err := funcall(env, function, append([]*Object{br.inputStream()}, args...))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if values := env.multipleValues(ret); len(values) == 0 {
+		return nil, false, nil
+	}
+
+	return ret, true, nil
+}
+
+// readDispatchMacro reads an optional decimal argument and a sub character,
+// then calls the function of the sub character
+func readDispatchMacro(br *sourceReader, c rune) (*Object, error) {
+	var digits strings.Builder
+	var sub rune
+	for {
+		b, err := br.readRune()
+		if err != nil {
+			return nil, err
+		}
+
+		if !isDigit(b) {
+			sub = b
+			break
+		}
+		digits.WriteRune(b)
+	}
+
+	arg := nilObj
+	if digits.Len() > 0 {
+		v, _ := new(big.Int).SetString(digits.String(), 10)
+		arg = newInteger(v)
+	}
+
+	m, ok := currentReadtable().macros[c]
+	if !ok || m.dispatch == nil {
+		return nil, &ErrInvalidSyntax{message: "not a dispatch macro character", text: formatCharacter(c)}
+	}
+
+	fn, ok := m.dispatch[unicode.ToUpper(sub)]
+	if !ok {
+		return nil, &ErrInvalidSyntax{message: "unsupported dispatch character", text: string([]rune{c, sub})}
+	}
+
+	ret, ok, err := callReaderMacro(br, fn, newCharacter(sub), arg)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func readtableValue(function string, obj *Object) (*Readtable, error) {
+	if isNull(obj) {
+		return standardReadtable, nil
+	}
+
+	rt, ok := obj.value.(*Readtable)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: function, argument: obj}
+	}
+
+	return rt, nil
+}
+
+// optionalReadtable resolves optional readtable argument which defaults to
+// *readtable*. nil designates the standard readtable.
+func optionalReadtable(function string, args []*Object, index int) (*Readtable, error) {
+	if len(args) > index {
+		return readtableValue(function, args[index])
+	}
+
+	return currentReadtable(), nil
+}
+
+// modifiableReadtable is same as optionalReadtable but rejects the standard
+// readtable
+func modifiableReadtable(function string, args []*Object, index int) (*Readtable, error) {
+	rt, err := optionalReadtable(function, args, index)
+	if err != nil {
+		return nil, err
+	}
+
+	if rt == standardReadtable {
+		return nil, &ErrInvalidSyntax{message: function + ": standard readtable cannot be modified"}
+	}
+
+	return rt, nil
+}
+
+func builtinReadtablep(_ *Environment, args []*Object) (*Object, error) {
+	// (readtablep obj)
+	if args[0].kind == ReadtableType {
+		return tObj, nil
+	}
+
+	return nilObj, nil
+}
+
+func builtinCopyReadtable(_ *Environment, args []*Object) (*Object, error) {
+	// (copy-readtable &optional from-readtable to-readtable)
+	if len(args) > 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+	}
+
+	from, err := optionalReadtable("copy-readtable", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) == 2 && !isNull(args[1]) {
+		to, err := modifiableReadtable("copy-readtable", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		from.copyInto(to)
+		return args[1], nil
+	}
+
+	to := newReadtable()
+	from.copyInto(to)
+	return newObject(ReadtableType, to), nil
+}
+
+func builtinSetMacroCharacter(_ *Environment, args []*Object) (*Object, error) {
+	// (set-macro-character char new-function &optional non-terminating-p readtable)
+	if len(args) > 4 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 4, got: len(args)}
+	}
+
+	c, err := characterValue("set-macro-character", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	rt, err := modifiableReadtable("set-macro-character", args, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	nonTerminating := len(args) > 2 && !isNull(args[2])
+	rt.setMacroCharacter(c, args[1], nonTerminating)
+	return tObj, nil
+}
+
+func builtinGetMacroCharacter(env *Environment, args []*Object) (*Object, error) {
+	// (get-macro-character char &optional readtable)
+	if len(args) > 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+	}
+
+	c, err := characterValue("get-macro-character", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	rt, err := optionalReadtable("get-macro-character", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := rt.macros[c]
+	if !ok {
+		return env.setValues([]*Object{nilObj, nilObj}), nil
+	}
+
+	nonTerminating := nilObj
+	if m.nonTerminating {
+		nonTerminating = tObj
+	}
+
+	return env.setValues([]*Object{m.function, nonTerminating}), nil
+}
+
+func builtinMakeDispatchMacroCharacter(_ *Environment, args []*Object) (*Object, error) {
+	// (make-dispatch-macro-character char &optional non-terminating-p readtable)
+	if len(args) > 3 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 3, got: len(args)}
+	}
+
+	c, err := characterValue("make-dispatch-macro-character", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	rt, err := modifiableReadtable("make-dispatch-macro-character", args, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	rt.makeDispatchMacroCharacter(c, len(args) > 1 && !isNull(args[1]))
+	return tObj, nil
+}
+
+func builtinSetDispatchMacroCharacter(_ *Environment, args []*Object) (*Object, error) {
+	// (set-dispatch-macro-character disp-char sub-char new-function &optional readtable)
+	if len(args) > 4 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 4, got: len(args)}
+	}
+
+	c, err := characterValue("set-dispatch-macro-character", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := characterValue("set-dispatch-macro-character", args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if isDigit(sub) {
+		return nil, &ErrInvalidSyntax{message: "set-dispatch-macro-character: sub character must not be digit", text: formatCharacter(sub)}
+	}
+
+	rt, err := modifiableReadtable("set-dispatch-macro-character", args, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rt.setDispatchMacroCharacter(c, sub, args[2]); err != nil {
+		return nil, err
+	}
+
+	return tObj, nil
+}
+
+func builtinGetDispatchMacroCharacter(_ *Environment, args []*Object) (*Object, error) {
+	// (get-dispatch-macro-character disp-char sub-char &optional readtable)
+	if len(args) > 3 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 3, got: len(args)}
+	}
+
+	c, err := characterValue("get-dispatch-macro-character", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := characterValue("get-dispatch-macro-character", args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	rt, err := optionalReadtable("get-dispatch-macro-character", args, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := rt.macros[c]
+	if !ok || m.dispatch == nil {
+		return nil, &ErrInvalidSyntax{message: "not a dispatch macro character", text: formatCharacter(c)}
+	}
+
+	if fn, ok := m.dispatch[unicode.ToUpper(sub)]; ok {
+		return fn, nil
+	}
+
+	return nilObj, nil
+}
+
+func builtinReadtableCase(_ *Environment, args []*Object) (*Object, error) {
+	// (readtable-case readtable)
+	rt, err := readtableValue("readtable-case", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return newKeyword(readtableCaseNames[rt.readCase]), nil
+}
+
+func setfReadtableCase(_ *Environment, args []*Object, value *Object) (*Object, error) {
+	// (setf (readtable-case readtable) mode)
+	if len(args) != 1 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 1, got: len(args)}
+	}
+
+	rt, err := modifiableReadtable("readtable-case", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	name, _ := keywordName(value)
+	for mode, n := range readtableCaseNames {
+		if n == name {
+			rt.readCase = readtableCase(mode)
+			return value, nil
+		}
+	}
+
+	return nil, &ErrUnsupportedArgumentType{function: "readtable-case", argument: value}
+}
+
+func builtinRead(_ *Environment, args []*Object) (*Object, error) {
+	// (read &optional stream eof-error-p eof-value recursive-p)
+	if len(args) > 4 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 4, got: len(args)}
+	}
+
+	s, err := optionalInputStream("read", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := read1(s.r)
+	if err == io.EOF {
+		return endOfFile(args, 1)
+	}
+
+	return ret, err
+}
+
+func builtinReadFromString(env *Environment, args []*Object) (*Object, error) {
+	// (read-from-string string &optional eof-error-p eof-value)
+	if len(args) > 3 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 3, got: len(args)}
+	}
+
+	str, ok := args[0].value.(string)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "read-from-string", argument: args[0]}
+	}
+
+	br := newSourceReader(strings.NewReader(str), "")
+	ret, err := read1(br)
+	if err == io.EOF {
+		ret, err = endOfFile(args, 1)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return env.setValues([]*Object{ret, newFixnum(int64(br.offset))}), nil
+}
+
+func initStandardReadtable() {
+	dispatchMacroFunction = newReaderMacroFunction(readDispatchMacro)
+
+	standardReadtable = newReadtable()
+	rt := standardReadtable
+	rt.setMacroCharacter('(', newReaderMacroFunction(readListMacro), false)
+	rt.setMacroCharacter(')', newReaderMacroFunction(readRightParen), false)
+	rt.setMacroCharacter('\'', newReaderMacroFunction(readQuote), false)
+	rt.setMacroCharacter('"', newReaderMacroFunction(readStringMacro), false)
+	rt.setMacroCharacter(';', newReaderMacroFunction(readComment), false)
+
+	rt.makeDispatchMacroCharacter('#', true)
+	dispatch := map[rune]dispatchReaderFunction{
+		'\\': readCharacter,
+		':':  readUninternedSymbol,
+		'H':  readHashTable,
+		'S':  readStructure,
+		'C':  readComplex,
+		'M':  readDecimal,
+		'B':  readBinary,
+		'O':  readOctal,
+		'X':  readHexadecimal,
+		'R':  readRadix,
+		'|':  readBlockComment,
+		';':  readDatumComment,
+		'+':  readConditional,
+		'-':  readConditional,
+		'\'': readFunction,
+		'.':  readEval,
+		'P':  readPathname,
+		'<':  readUnreadable,
+		'=':  readLabelDefinition,
+		'#':  readLabelReference,
+	}
+	for sub, fn := range dispatch {
+		_ = rt.setDispatchMacroCharacter('#', sub, newDispatchReaderFunction(fn))
+	}
+}
+
+func initReadtableFunctions() {
+	initStandardReadtable()
+
+	current := newReadtable()
+	standardReadtable.copyInto(current)
+
+	readtableObj = newSymbol("*readtable*")
+	readtableObj.value.(*Symbol).value = newObject(ReadtableType, current)
+
+	readBaseObj = newSymbol("*read-base*")
+	readBaseObj.value.(*Symbol).value = newFixnum(10)
+
+	readSuppressObj = newSymbol("*read-suppress*")
+	readSuppressObj.value.(*Symbol).value = nilObj
+
+	readEvalObj = newSymbol("*read-eval*")
+	readEvalObj.value.(*Symbol).value = tObj
+
+	featuresObj = newSymbol("*features*")
+	featuresObj.value.(*Symbol).value = cons(newKeyword("banglisp"), emptyList)
+
+	installBuiltinFunction("readtablep", builtinReadtablep, 1, false)
+	installBuiltinFunction("copy-readtable", builtinCopyReadtable, 0, true)
+	installBuiltinFunction("set-macro-character", builtinSetMacroCharacter, 2, true)
+	installBuiltinFunction("get-macro-character", builtinGetMacroCharacter, 1, true)
+	installBuiltinFunction("make-dispatch-macro-character", builtinMakeDispatchMacroCharacter, 1, true)
+	installBuiltinFunction("set-dispatch-macro-character", builtinSetDispatchMacroCharacter, 3, true)
+	installBuiltinFunction("get-dispatch-macro-character", builtinGetDispatchMacroCharacter, 2, true)
+	installBuiltinFunction("readtable-case", builtinReadtableCase, 1, false)
+	installSetfFunction("readtable-case", setfReadtableCase)
+	installBuiltinFunction("read", builtinRead, 0, true)
+	installBuiltinFunction("read-from-string", builtinReadFromString, 1, true)
+}
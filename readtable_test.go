@@ -0,0 +1,139 @@
+package banglisp
+
+import (
+	"testing"
+)
+
+func TestReadtable(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "characters",
+			expr: `(list #\a #\Space #\newline (type-of #\a) (char-code #\A) (code-char 97))`,
+			want: `(#\a #\Space #\Newline character 65 #\a)`,
+		},
+		{
+			name: "built-in syntax is in standard readtable",
+			expr: `(list (readtablep *readtable*) (if (get-macro-character #\( nil) t nil) (get-macro-character #\a))`,
+			want: "(t t nil)",
+		},
+		{
+			name: "non-terminating dispatch macro character",
+			expr: `(multiple-value-list (get-macro-character #\#))`,
+			want: "(#<builtin> t)",
+		},
+		{
+			name: "macro character",
+			expr: `(set-macro-character #\! (lambda (stream char) (list 'not (read stream t nil t))))
+(quote (a !b))`,
+			want: "(a (not b))",
+		},
+		{
+			name: "macro character terminates token",
+			expr: `(set-macro-character #\! (lambda (stream char) (list 'not (read stream t nil t))))
+(quote (a!b))`,
+			want: "(a (not b))",
+		},
+		{
+			name: "macro character returning no values",
+			expr: `(set-macro-character #\% (lambda (stream char) (read stream t nil t) (values)))
+(list 1 %2 3 %4)`,
+			want: "(1 3)",
+		},
+		{
+			name: "reader macro reads characters",
+			expr: `(defun rt-read-word (stream)
+  (let ((c (read-char stream nil nil)))
+    (if (or (null c) (eql c #\]))
+        nil
+        (cons c (rt-read-word stream)))))
+(set-macro-character #\[ (lambda (stream char) (list 'quote (rt-read-word stream))))
+[ab]`,
+			want: `(#\a #\b)`,
+		},
+		{
+			name: "dispatch macro with argument",
+			expr: `(set-dispatch-macro-character #\# #\! (lambda (stream char arg) (list char arg)))
+(list '#3! '#!)`,
+			want: `((#\! 3) (#\! nil))`,
+		},
+		{
+			name: "new dispatch macro character",
+			expr: `(make-dispatch-macro-character #\$)
+(set-dispatch-macro-character #\$ #\v (lambda (stream char arg) (length (read stream t nil t))))
+(list $v(1 2 3) $V"ab" (if (get-dispatch-macro-character #\$ #\V) t nil))`,
+			want: "(3 2 t)",
+		},
+		{
+			name: "copied readtable is independent",
+			expr: `(set-macro-character #\! (lambda (stream char) 1))
+(list (if (get-macro-character #\!) t nil) (get-macro-character #\! nil) (get-macro-character #\! (copy-readtable nil)))`,
+			want: "(t nil nil)",
+		},
+		{
+			name: "copy into readtable",
+			expr: `(let ((rt (copy-readtable nil)))
+  (set-macro-character #\! (lambda (stream char) 1) nil rt)
+  (copy-readtable nil rt)
+  (get-macro-character #\! rt))`,
+			want: "nil",
+		},
+		{
+			name: "read from string",
+			expr: `(list (multiple-value-list (read-from-string "(a b) c")) (read-from-string "" nil 'eof))`,
+			want: "(((a b) 5) eof)",
+		},
+		{
+			name: "read from string input stream",
+			expr: `(let ((s (make-string-input-stream "  x y")))
+  (list (peek-char t s) (read s) (read-char s) (read s) (read s nil 'eof)))`,
+			want: `(#\x x #\Space y eof)`,
+		},
+		{
+			name:    "standard readtable cannot be modified",
+			expr:    `(set-macro-character #\! 'car nil nil)`,
+			wantErr: true,
+		},
+		{
+			name:    "not dispatch macro character",
+			expr:    `(set-dispatch-macro-character #\( #\a 'car)`,
+			wantErr: true,
+		},
+		{
+			name:    "undefined dispatch sub character",
+			expr:    `'#q`,
+			wantErr: true,
+		},
+		{
+			name:    "unbalanced close parenthesis",
+			expr:    `)`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				readtableObj.value.(*Symbol).value, _ = builtinCopyReadtable(nil, []*Object{nilObj})
+			}()
+
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("readEvalString(%s) error = %v", tt.expr, err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("got: %v, expected: %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package banglisp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"unicode/utf8"
+)
+
+type sourceLocation struct {
+	file   string
+	line   int
+	column int
+}
+
+func (l *sourceLocation) String() string {
+	if l.file == "" {
+		return fmt.Sprintf("%d:%d", l.line, l.column)
+	}
+
+	return fmt.Sprintf("%s:%d:%d", l.file, l.line, l.column)
+}
+
+// sourceLocations records where the reader read each cons, keyed by the id
+// of the cons. It is a side table so that Object stays small. A finalizer
+// removes the entry of a cons when the cons is garbage collected.
+var sourceLocations = struct {
+	sync.Mutex
+	table map[int]*sourceLocation
+}{table: make(map[int]*sourceLocation)}
+
+// formLocation returns where the reader read form
+func formLocation(obj *Object) (*sourceLocation, bool) {
+	sourceLocations.Lock()
+	defer sourceLocations.Unlock()
+
+	loc, ok := sourceLocations.table[obj.id]
+	return loc, ok
+}
+
+// setFormLocation records where the reader read cons obj
+func setFormLocation(obj *Object, loc *sourceLocation) {
+	if !isCons(obj) {
+		return
+	}
+
+	sourceLocations.Lock()
+	defer sourceLocations.Unlock()
+
+	if _, ok := sourceLocations.table[obj.id]; !ok {
+		runtime.SetFinalizer(obj, forgetFormLocation)
+	}
+	sourceLocations.table[obj.id] = loc
+}
+
+func forgetFormLocation(obj *Object) {
+	sourceLocations.Lock()
+	defer sourceLocations.Unlock()
+
+	delete(sourceLocations.table, obj.id)
+}
+
+// forgetFormLocations removes the locations of conses in obj. A cycle
+// containing an object with a finalizer is never collected, so objects
+// labeled by #n=, which may be circular, are not located.
+func forgetFormLocations(obj *Object, visited map[int]bool) {
+	if visited[obj.id] {
+		return
+	}
+	visited[obj.id] = true
+
+	switch v := obj.value.(type) {
+	case *ConsCell:
+		if isCons(obj) {
+			runtime.SetFinalizer(obj, nil)
+			forgetFormLocation(obj)
+			forgetFormLocations(v.car, visited)
+			forgetFormLocations(v.cdr, visited)
+		}
+	case *Structure:
+		for _, slot := range v.slots {
+			forgetFormLocations(slot, visited)
+		}
+	case *Instance:
+		for _, slot := range v.slots {
+			forgetFormLocations(slot, visited)
+		}
+	case *HashTable:
+		for _, e := range v.entries {
+			forgetFormLocations(e.key, visited)
+			forgetFormLocations(e.value, visited)
+		}
+	}
+}
+
+// sourceReader reads UTF-8 characters and tracks the line and the column
+// of the next character
+type sourceReader struct {
+	r      *bufio.Reader
+	file   string
+	line   int
+	column int
+	offset int
+	stream *Object
+	// history keeps recently read characters so that they can be unread,
+	// and pending keeps unread characters to be read again
+	history []positionedRune
+	pending []positionedRune
+	// labels holds objects labeled by #n= while reading a top level
+	// object, and depth is the nesting level of reads
+	labels map[int64]*Object
+	depth  int
+	// formStart is where the last top level object started
+	formStart *sourceLocation
+}
+
+// positionedRune is a character with the position where it was read
+type positionedRune struct {
+	c      rune
+	line   int
+	column int
+}
+
+// historySize is the number of characters which can be unread in a row
+const historySize = 16
+
+func newSourceReader(r io.Reader, file string) *sourceReader {
+	return &sourceReader{
+		r:      bufio.NewReader(r),
+		file:   file,
+		line:   1,
+		column: 1,
+	}
+}
+
+func (r *sourceReader) readRune() (rune, error) {
+	var pr positionedRune
+	if n := len(r.pending); n > 0 {
+		pr = r.pending[n-1]
+		r.pending = r.pending[:n-1]
+	} else {
+		c, size, err := r.r.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+
+		if c == utf8.RuneError && size == 1 {
+			return 0, r.invalidEncoding()
+		}
+
+		pr = positionedRune{c, r.line, r.column}
+	}
+
+	if len(r.history) == historySize {
+		r.history = r.history[1:]
+	}
+	r.history = append(r.history, pr)
+
+	r.offset++
+	if pr.c == '\n' {
+		r.line++
+		r.column = 1
+	} else {
+		r.column++
+	}
+
+	return pr.c, nil
+}
+
+// invalidEncoding returns the error for the invalid byte just read, which
+// is located at the byte rather than at the object being read
+func (r *sourceReader) invalidEncoding() error {
+	_ = r.r.UnreadRune()
+	b, _ := r.r.ReadByte()
+
+	err := &ErrInvalidSyntax{message: "invalid UTF-8 encoding", text: fmt.Sprintf("\\x%02x", b)}
+	err.setLocation(r.location())
+	return err
+}
+
+func (r *sourceReader) unreadRune() error {
+	n := len(r.history)
+	if n == 0 {
+		return fmt.Errorf("no character to unread")
+	}
+
+	pr := r.history[n-1]
+	r.history = r.history[:n-1]
+	r.pending = append(r.pending, pr)
+
+	r.offset--
+	r.line = pr.line
+	r.column = pr.column
+	return nil
+}
+
+// peekRune returns the next character without consuming it
+func (r *sourceReader) peekRune() (rune, error) {
+	c, err := r.readRune()
+	if err != nil {
+		return 0, err
+	}
+
+	return c, r.unreadRune()
+}
+
+// inputStream returns the stream object passed to reader macro functions
+func (r *sourceReader) inputStream() *Object {
+	if r.stream == nil {
+		r.stream = newInputStream(r)
+	}
+
+	return r.stream
+}
+
+func (r *sourceReader) location() *sourceLocation {
+	return &sourceLocation{r.file, r.line, r.column}
+}
+
+// locatableError is implemented by typed errors which carry the location
+// of the form causing them
+type locatableError interface {
+	error
+	setLocation(loc *sourceLocation)
+}
+
+// withSourceLocation attaches the location of form to err unless err
+// already has one
+func withSourceLocation(err error, form *Object) error {
+	loc, ok := formLocation(form)
+	if !ok {
+		return err
+	}
+
+	return locateError(err, loc)
+}
+
+// locateError attaches loc to err unless err already has a location. Typed
+// errors keep their types so that callers can still assert them.
+func locateError(err error, loc *sourceLocation) error {
+	switch e := err.(type) {
+	case locatableError:
+		e.setLocation(loc)
+		return e
+	case *ErrAtLocation:
+		return e
+	default:
+		return &ErrAtLocation{location: loc, err: err}
+	}
+}
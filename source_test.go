@@ -0,0 +1,174 @@
+package banglisp
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSourceLocationOfForms(t *testing.T) {
+	br := newSourceReader(strings.NewReader("  (a\n   (b c)\n 'd)"), "test.lisp")
+	obj, err := read1(br)
+	if err != nil {
+		t.Fatalf("could not read: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		form *Object
+		want string
+	}{
+		{
+			name: "list",
+			form: obj,
+			want: "test.lisp:1:3",
+		},
+		{
+			name: "nested list",
+			form: noEvalArguments(obj)[1],
+			want: "test.lisp:2:4",
+		},
+		{
+			name: "cons of element",
+			form: obj.value.(*ConsCell).cdr,
+			want: "test.lisp:2:4",
+		},
+		{
+			name: "quote form",
+			form: noEvalArguments(obj)[2],
+			want: "test.lisp:3:2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, ok := formLocation(tt.form)
+			if !ok {
+				t.Errorf("location of %v is not recorded", *tt.form)
+				return
+			}
+
+			if loc.String() != tt.want {
+				t.Errorf("location of %v: got %v, expected %s", *tt.form, loc, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceLocationOfCollectedForms(t *testing.T) {
+	id := func() int {
+		obj, err := read1(newSourceReader(strings.NewReader("(a (b))"), "test.lisp"))
+		if err != nil {
+			t.Fatalf("could not read: %v", err)
+		}
+
+		if _, ok := formLocation(obj); !ok {
+			t.Fatalf("location of %v is not recorded", *obj)
+		}
+		return obj.id
+	}()
+
+	for i := 0; i < 100; i++ {
+		runtime.GC()
+
+		sourceLocations.Lock()
+		_, ok := sourceLocations.table[id]
+		sourceLocations.Unlock()
+		if !ok {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Errorf("location of collected form is not removed")
+}
+
+func TestSourceLocationOfLabeledForms(t *testing.T) {
+	obj, err := read1(newSourceReader(strings.NewReader("(a #1=(b . #1#) (c))"), "test.lisp"))
+	if err != nil {
+		t.Fatalf("could not read: %v", err)
+	}
+
+	args := noEvalArguments(obj)
+	if _, ok := formLocation(args[1]); ok {
+		t.Errorf("location of labeled object %v is recorded", *args[1])
+	}
+
+	if _, ok := formLocation(args[2]); !ok {
+		t.Errorf("location of %v is not recorded", *args[2])
+	}
+}
+
+func TestSourceLocationOfErrors(t *testing.T) {
+	_, err := readEvalString("(list 1\n  (car src-unbound))")
+	if _, ok := err.(*ErrUnboundVariable); !ok {
+		t.Errorf("could not get unbound variable error: %v", err)
+	} else if !strings.HasPrefix(err.Error(), "2:3: ") {
+		t.Errorf("unbound variable error does not have location: %v", err)
+	}
+
+	_, err = readEvalString("\n(cons 1)")
+	if _, ok := err.(*ErrWrongNumberArguments); !ok {
+		t.Errorf("could not get wrong number arguments error: %v", err)
+	} else if !strings.HasPrefix(err.Error(), "2:1: ") {
+		t.Errorf("arity error does not have location: %v", err)
+	}
+
+	_, err = readEvalString("(list (src-undefined-function 1))")
+	var located *ErrAtLocation
+	if !errors.As(err, &located) {
+		t.Errorf("could not get located error: %v", err)
+	} else if located.location.String() != "1:7" || errors.Unwrap(err) == nil {
+		t.Errorf("invalid located error: %v", err)
+	}
+}
+
+func TestReadEvalFileLocation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "banglisp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "script.lisp")
+	if err := ioutil.WriteFile(file, []byte("(setq src-x 1)\n\n(+ src-x\n   (/ 1 0))\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ReadEvalFile(file)
+	if _, ok := err.(*ErrDivisionByZero); !ok {
+		t.Fatalf("could not get division by zero error: %v", err)
+	}
+
+	if want := file + ":4:4: "; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("got %v, expected prefix %s", err, want)
+	}
+}
+
+func TestReadEvalFileAtomLocation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "banglisp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "script.lisp")
+	if err := ioutil.WriteFile(file, []byte("(setq src-y 1)\n\n  undefined-src-var\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ReadEvalFile(file)
+	if _, ok := err.(*ErrUnboundVariable); !ok {
+		t.Fatalf("could not get unbound variable error: %v", err)
+	}
+
+	if want := file + ":3:3: "; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("got %v, expected prefix %s", err, want)
+	}
+}
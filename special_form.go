@@ -1,5 +1,7 @@
 package banglisp
 
+import "fmt"
+
 type specialFormFunction func(env *Environment, args []*Object) (*Object, error)
 
 type SpecialForm struct {
@@ -23,16 +25,65 @@ func installSpecialForm(name string, code specialFormFunction, arity int, variad
 	v.function = newSpecialForm(code, arity, variadic)
 }
 
+type setfFunction func(env *Environment, args []*Object, value *Object) (*Object, error)
+
+var setfFunctions = make(map[*Object]setfFunction)
+
+func installSetfFunction(name string, code setfFunction) {
+	setfFunctions[newSymbol(name)] = code
+}
+
+func evalBody(env *Environment, body []*Object) (*Object, error) {
+	ret := nilObj
+	var err error
+	for _, expr := range body {
+		ret, err = expr.Eval(env)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+func setVariable(env *Environment, variable *Object, value *Object) (*Object, error) {
+	sym, ok := variable.value.(*Symbol)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "setq", argument: variable}
+	}
+
+	if isConstant(variable) {
+		return nil, fmt.Errorf("%v is a constant and cannot be set", *variable)
+	}
+
+	if _, ok := env.lookupSymbol(variable); !ok {
+		sym.value = value
+		return value, nil
+	}
+
+	// change value of local variable
+	env.updateValue(variable, value)
+	return value, nil
+}
+
 func specialQuote(_ *Environment, args []*Object) (*Object, error) {
 	// (quote exp)
 	return args[0], nil
 }
 
-func specialFunction(_ *Environment, args []*Object) (*Object, error) {
-	// (function symbol)
+func specialFunction(env *Environment, args []*Object) (*Object, error) {
+	// (function symbol) or (function (lambda (params...) body))
+	if cell, ok := args[0].value.(*ConsCell); ok && cell.car == newSymbol("lambda") {
+		lambda := noEvalArguments(cell.cdr)
+		if len(lambda) == 0 {
+			return nil, &ErrUnsupportedArgumentType{function: "function", argument: args[0]}
+		}
+		return specialLambda(env, lambda)
+	}
+
 	sym, ok := args[0].value.(*Symbol)
 	if !ok {
-		return nil, &ErrUnsupportedArgumentType{"function", args[0]}
+		return nil, &ErrUnsupportedArgumentType{function: "function", argument: args[0]}
 	}
 
 	return sym.function, nil
@@ -68,34 +119,82 @@ func specialIf(env *Environment, args []*Object) (*Object, error) {
 
 func specialSetq(env *Environment, args []*Object) (*Object, error) {
 	// (setq sym value)
-	if _, ok := env.lookupSymbol(args[0]); !ok {
-		sym := args[0].value.(*Symbol)
-		sym.value = args[1]
-		return args[1], nil
+	value, err := args[1].Eval(env)
+	if err != nil {
+		return nil, err
 	}
 
-	// change value of local variable
-	env.updateValue(args[0], args[1])
-	return args[1], nil
+	// setq returns only the primary value
+	env.clearValues()
+	return setVariable(env, args[0], value)
+}
+
+func specialSetf(env *Environment, args []*Object) (*Object, error) {
+	// (setf place1 value1 place2 value2...)
+	if len(args)%2 != 0 {
+		return nil, fmt.Errorf("setf: odd number of arguments")
+	}
+
+	ret := nilObj
+	for i := 0; i < len(args); i += 2 {
+		place := args[i]
+		value, err := args[i+1].Eval(env)
+		if err != nil {
+			return nil, err
+		}
+
+		switch place.kind {
+		case SymbolType:
+			ret, err = setVariable(env, place, value)
+		case ConsCellType:
+			c := place.value.(*ConsCell)
+			code, ok := setfFunctions[c.car]
+			if !ok {
+				return nil, fmt.Errorf("setf: undefined place %v", *c.car)
+			}
+
+			var placeArgs []*Object
+			placeArgs, err = evalArguments(c.cdr, env)
+			if err != nil {
+				return nil, err
+			}
+
+			ret, err = code(env, placeArgs, value)
+		default:
+			return nil, &ErrUnsupportedArgumentType{function: "setf", argument: place}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// setf returns only the primary value
+	env.clearValues()
+	return ret, nil
 }
 
 func specialDefun(env *Environment, args []*Object) (*Object, error) {
 	// (defun name (params...) body)
 	nameSym, ok := args[0].value.(*Symbol)
 	if !ok {
-		return nil, ErrUnsupportedArgumentType{"defun", args[0]}
+		return nil, ErrUnsupportedArgumentType{function: "defun", argument: args[0]}
 	}
 
 	sym := intern(nameSym.name, nil)
 	symValue := sym.value.(*Symbol)
-	symValue.function = newClosure(args[0], noEvalArguments(args[1]), args[2:], env)
+	fn, err := newClosure(args[0], noEvalArguments(args[1]), args[2:], env)
+	if err != nil {
+		return nil, err
+	}
+
+	symValue.function = fn
 	return args[0], nil
 }
 
 func specialLambda(env *Environment, args []*Object) (*Object, error) {
 	// (lambda (params...) body)
-	fn := newClosure(nil, noEvalArguments(args[0]), args[1:], env)
-	return fn, nil
+	return newClosure(nil, noEvalArguments(args[0]), args[1:], env)
 }
 
 func specialLet(env *Environment, args []*Object) (*Object, error) {
@@ -111,6 +210,9 @@ func specialLet(env *Environment, args []*Object) (*Object, error) {
 
 		pair := iter.car.value.(*ConsCell)
 		name := pair.car
+		if isConstant(name) {
+			return nil, fmt.Errorf("%v is a constant and cannot be bound", *name)
+		}
 
 		valueObj := pair.cdr.value.(*ConsCell)
 		value, err := valueObj.car.Eval(env)
@@ -150,6 +252,9 @@ func specialLetStar(env *Environment, args []*Object) (*Object, error) {
 
 		pair := iter.car.value.(*ConsCell)
 		name := pair.car
+		if isConstant(name) {
+			return nil, fmt.Errorf("%v is a constant and cannot be bound", *name)
+		}
 
 		valueObj := pair.cdr.value.(*ConsCell)
 		value, err := valueObj.car.Eval(env)
@@ -180,15 +285,58 @@ func specialLetStar(env *Environment, args []*Object) (*Object, error) {
 	return ret, nil
 }
 
+func specialMultipleValueBind(env *Environment, args []*Object) (*Object, error) {
+	// (multiple-value-bind (var1 var2...) form body...)
+	primary, err := args[1].Eval(env)
+	if err != nil {
+		return nil, err
+	}
+	values := env.multipleValues(primary)
+
+	frame := &Frame{}
+	for i, name := range noEvalArguments(args[0]) {
+		value := nilObj
+		if i < len(values) {
+			value = values[i]
+		}
+		frame.addBinding(name, value)
+	}
+
+	env.pushFrame(frame)
+	defer env.popFrame(1)
+
+	return evalBody(env, args[2:])
+}
+
+func specialMultipleValueList(env *Environment, args []*Object) (*Object, error) {
+	// (multiple-value-list form)
+	primary, err := args[0].Eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := emptyList
+	values := env.multipleValues(primary)
+	for i := len(values) - 1; i >= 0; i-- {
+		ret = cons(values[i], ret)
+	}
+
+	return ret, nil
+}
+
 func specialOr(env *Environment, args []*Object) (*Object, error) {
 	// (or expr1 expr2...)
-	for _, expr := range args[0:] {
+	for i, expr := range args {
 		ret, err := expr.Eval(env)
 		if err != nil {
 			return nil, err
 		}
 
 		if !isNull(ret) {
+			// only the last form returns multiple values
+			if i < len(args)-1 {
+				env.clearValues()
+			}
 			return ret, nil
 		}
 	}
@@ -200,13 +348,17 @@ func specialAnd(env *Environment, args []*Object) (*Object, error) {
 	// (and expr1 expr2...)
 	ret := nilObj
 	var err error
-	for _, expr := range args[0:] {
+	for i, expr := range args {
 		ret, err = expr.Eval(env)
 		if err != nil {
 			return nil, err
 		}
 
 		if isNull(ret) {
+			// only the last form returns multiple values
+			if i < len(args)-1 {
+				env.clearValues()
+			}
 			return nilObj, err
 		}
 	}
@@ -219,6 +371,7 @@ func initSpecialForm() {
 	installSpecialForm("function", specialFunction, 1, false)
 	installSpecialForm("if", specialIf, 2, true)
 	installSpecialForm("setq", specialSetq, 2, false)
+	installSpecialForm("setf", specialSetf, 0, true)
 	installSpecialForm("defun", specialDefun, 2, true)
 	installSpecialForm("lambda", specialLambda, 1, true)
 
@@ -227,4 +380,7 @@ func initSpecialForm() {
 
 	installSpecialForm("or", specialOr, 1, true)
 	installSpecialForm("and", specialAnd, 1, true)
+
+	installSpecialForm("multiple-value-bind", specialMultipleValueBind, 2, true)
+	installSpecialForm("multiple-value-list", specialMultipleValueList, 1, false)
 }
@@ -345,3 +345,52 @@ func TestSimpleOrAnd(t *testing.T) {
 		})
 	}
 }
+
+func TestLambdaList(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{
+			name: "optional",
+			expr: "(funcall (lambda (a &optional (b 10) c) (list a b c)) 1)",
+			want: "(1 10 nil)",
+		},
+		{
+			name: "optional supplied-p",
+			expr: "(funcall (lambda (&optional (a 1 a-p)) (list a a-p)) 5)",
+			want: "(5 t)",
+		},
+		{
+			name: "rest",
+			expr: "(funcall (lambda (a &rest args) (list a args)) 1 2 3)",
+			want: "(1 (2 3))",
+		},
+		{
+			name: "key",
+			expr: "(funcall (lambda (&key a (b (+ a 1))) (list a b)) :a 1)",
+			want: "(1 2)",
+		},
+		{
+			name: "aux",
+			expr: "(funcall (lambda (a &aux (b (* a 2))) b) 21)",
+			want: "42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if err != nil {
+				t.Errorf("could not evaluate %s: %v", tt.expr, err)
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("%s => got: %v, expected %s", tt.expr, *got, tt.want)
+				return
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package banglisp
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+type Stream struct {
+	r  *sourceReader
+	w  io.Writer
+	sb *strings.Builder
+	// midLine is true if the last character written is not newline
+	midLine bool
+}
+
+var standardInputObj *Object
+var standardOutputObj *Object
+var terminalIOObj *Object
+
+func newOutputStream(w io.Writer) *Object {
+	return newObject(StreamType, &Stream{w: w})
+}
+
+func newInputStream(r *sourceReader) *Object {
+	return newObject(StreamType, &Stream{r: r})
+}
+
+func newStringOutputStream() *Object {
+	sb := &strings.Builder{}
+	return newObject(StreamType, &Stream{w: sb, sb: sb})
+}
+
+func (s *Stream) writeString(str string) error {
+	if str != "" {
+		s.midLine = str[len(str)-1] != '\n'
+	}
+
+	_, err := io.WriteString(s.w, str)
+	return err
+}
+
+// outputStream resolves an output stream designator. nil means
+// *standard-output* and t means the terminal.
+func outputStream(function string, obj *Object) (*Stream, error) {
+	if isNull(obj) {
+		obj = standardOutputObj.value.(*Symbol).value
+	} else if obj == tObj {
+		obj = terminalIOObj
+	}
+
+	s, ok := obj.value.(*Stream)
+	if !ok || s.w == nil {
+		return nil, &ErrUnsupportedArgumentType{function: function, argument: obj}
+	}
+
+	return s, nil
+}
+
+// inputStream resolves an input stream designator. nil means
+// *standard-input* and t means the terminal.
+func inputStream(function string, obj *Object) (*Stream, error) {
+	if isNull(obj) {
+		obj = specialValue(standardInputObj)
+	} else if obj == tObj {
+		obj = terminalIOObj
+	}
+
+	s, ok := obj.value.(*Stream)
+	if !ok || s.r == nil {
+		return nil, &ErrUnsupportedArgumentType{function: function, argument: obj}
+	}
+
+	return s, nil
+}
+
+func optionalInputStream(function string, args []*Object, index int) (*Stream, error) {
+	if len(args) > index {
+		return inputStream(function, args[index])
+	}
+
+	return inputStream(function, nilObj)
+}
+
+// endOfFile returns eof-value if eof-error-p at index is nil, otherwise
+// the end of file error
+func endOfFile(args []*Object, index int) (*Object, error) {
+	if len(args) > index && isNull(args[index]) {
+		if len(args) > index+1 {
+			return args[index+1], nil
+		}
+		return nilObj, nil
+	}
+
+	return nil, &ErrEndOfFile{context: "stream"}
+}
+
+func optionalOutputStream(function string, args []*Object, index int) (*Stream, error) {
+	if len(args) > index {
+		return outputStream(function, args[index])
+	}
+
+	return outputStream(function, nilObj)
+}
+
+func builtinWriteString(_ *Environment, args []*Object) (*Object, error) {
+	// (write-string string &optional stream)
+	if len(args) > 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+	}
+
+	str, ok := args[0].value.(string)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "write-string", argument: args[0]}
+	}
+
+	s, err := optionalOutputStream("write-string", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.writeString(str); err != nil {
+		return nil, err
+	}
+
+	return args[0], nil
+}
+
+func builtinTerpri(_ *Environment, args []*Object) (*Object, error) {
+	// (terpri &optional stream)
+	if len(args) > 1 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 1, got: len(args)}
+	}
+
+	s, err := optionalOutputStream("terpri", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.writeString("\n"); err != nil {
+		return nil, err
+	}
+
+	return nilObj, nil
+}
+
+func builtinMakeStringOutputStream(_ *Environment, _ []*Object) (*Object, error) {
+	// (make-string-output-stream)
+	return newStringOutputStream(), nil
+}
+
+func builtinGetOutputStreamString(_ *Environment, args []*Object) (*Object, error) {
+	// (get-output-stream-string stream)
+	s, ok := args[0].value.(*Stream)
+	if !ok || s.sb == nil {
+		return nil, &ErrUnsupportedArgumentType{function: "get-output-stream-string", argument: args[0]}
+	}
+
+	ret := s.sb.String()
+	s.sb.Reset()
+	return newString(ret), nil
+}
+
+func builtinReadChar(_ *Environment, args []*Object) (*Object, error) {
+	// (read-char &optional stream eof-error-p eof-value recursive-p)
+	if len(args) > 4 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 4, got: len(args)}
+	}
+
+	s, err := optionalInputStream("read-char", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.r.readRune()
+	if err == io.EOF {
+		return endOfFile(args, 1)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newCharacter(c), nil
+}
+
+func builtinPeekChar(_ *Environment, args []*Object) (*Object, error) {
+	// (peek-char &optional peek-type stream eof-error-p eof-value recursive-p)
+	if len(args) > 5 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 5, got: len(args)}
+	}
+
+	s, err := optionalInputStream("peek-char", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		c, err := s.r.peekRune()
+		if err == io.EOF {
+			return endOfFile(args, 2)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// non-nil peek-type skips whitespaces
+		if len(args) > 0 && !isNull(args[0]) && isSpace(c) {
+			_, _ = s.r.readRune()
+			continue
+		}
+
+		return newCharacter(c), nil
+	}
+}
+
+func builtinUnreadChar(_ *Environment, args []*Object) (*Object, error) {
+	// (unread-char char &optional stream)
+	if len(args) > 2 {
+		return nil, &ErrWrongNumberArguments{variadic: false, expected: 2, got: len(args)}
+	}
+
+	if _, err := characterValue("unread-char", args[0]); err != nil {
+		return nil, err
+	}
+
+	s, err := optionalInputStream("unread-char", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.r.unreadRune(); err != nil {
+		return nil, err
+	}
+
+	return nilObj, nil
+}
+
+func builtinMakeStringInputStream(_ *Environment, args []*Object) (*Object, error) {
+	// (make-string-input-stream string)
+	str, ok := args[0].value.(string)
+	if !ok {
+		return nil, &ErrUnsupportedArgumentType{function: "make-string-input-stream", argument: args[0]}
+	}
+
+	return newInputStream(newSourceReader(strings.NewReader(str), "")), nil
+}
+
+func initStreamFunctions() {
+	terminalIOObj = newObject(StreamType, &Stream{r: newSourceReader(os.Stdin, ""), w: os.Stdout})
+
+	standardInputObj = newSymbol("*standard-input*")
+	standardInputObj.value.(*Symbol).value = terminalIOObj
+
+	standardOutputObj = newSymbol("*standard-output*")
+	standardOutputObj.value.(*Symbol).value = terminalIOObj
+
+	installBuiltinFunction("read-char", builtinReadChar, 0, true)
+	installBuiltinFunction("peek-char", builtinPeekChar, 0, true)
+	installBuiltinFunction("unread-char", builtinUnreadChar, 1, true)
+	installBuiltinFunction("make-string-input-stream", builtinMakeStringInputStream, 1, false)
+	installBuiltinFunction("write-string", builtinWriteString, 1, true)
+	installBuiltinFunction("terpri", builtinTerpri, 0, true)
+	installBuiltinFunction("make-string-output-stream", builtinMakeStringOutputStream, 0, false)
+	installBuiltinFunction("get-output-stream-string", builtinGetOutputStreamString, 1, false)
+}
@@ -0,0 +1,467 @@
+package banglisp
+
+import (
+	"fmt"
+)
+
+type structureSlot struct {
+	name     *Object
+	initform *Object
+	typ      *Object
+	readOnly bool
+}
+
+type StructureClass struct {
+	name   *Object
+	parent *StructureClass
+	slots  []*structureSlot
+}
+
+type Structure struct {
+	class *StructureClass
+	slots []*Object
+}
+
+var structureClasses = make(map[*Object]*StructureClass)
+
+func newStructure(class *StructureClass, slots []*Object) *Object {
+	s := &Structure{
+		class: class,
+		slots: slots,
+	}
+
+	return newObject(StructureType, s)
+}
+
+func (c *StructureClass) isSubclassOf(other *StructureClass) bool {
+	for p := c; p != nil; p = p.parent {
+		if p == other {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *StructureClass) slotIndex(name string) int {
+	for i, slot := range c.slots {
+		if slot.name.value.(*Symbol).name.value.(string) == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// makeInstance creates a structure from given slot values. Slots whose
+// value is nil are initialized by evaluating their initforms.
+func (c *StructureClass) makeInstance(env *Environment, values []*Object) (*Object, error) {
+	slots := make([]*Object, len(c.slots))
+	for i, slot := range c.slots {
+		value := values[i]
+		if value == nil {
+			var err error
+			value, err = slot.initform.Eval(env)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if err := slot.checkType(value); err != nil {
+			return nil, err
+		}
+
+		slots[i] = value
+	}
+
+	return newStructure(c, slots), nil
+}
+
+func (s *structureSlot) checkType(value *Object) error {
+	if s.typ == nil {
+		return nil
+	}
+
+	ok, known := isOfType(value, s.typ)
+	if known && !ok {
+		return fmt.Errorf("slot %v: %v is not of type %v", *s.name, *value, *s.typ)
+	}
+
+	return nil
+}
+
+func (s *Structure) equalp(other *Structure, pairs comparedPairs) bool {
+	if s.class != other.class {
+		return false
+	}
+
+	for i := range s.slots {
+		if !equalpObjects(s.slots[i], other.slots[i], pairs) {
+			return false
+		}
+	}
+
+	return true
+}
+
+type structureOptions struct {
+	name          *Object
+	concName      string
+	constructors  []*Object
+	noConstructor bool
+	copier        string
+	predicate     string
+	include       *StructureClass
+	overrides     []*Object
+}
+
+func symbolNameString(obj *Object) (string, bool) {
+	switch v := obj.value.(type) {
+	case *Symbol:
+		return v.name.value.(string), true
+	case string:
+		return v, true
+	default:
+		return "", false
+	}
+}
+
+func parseStructureOptions(nameAndOptions *Object) (*structureOptions, error) {
+	opts := &structureOptions{}
+
+	var options []*Object
+	if nameAndOptions.kind == ConsCellType {
+		elems := noEvalArguments(nameAndOptions)
+		if len(elems) == 0 {
+			return nil, &ErrUnsupportedArgumentType{function: "defstruct", argument: nameAndOptions}
+		}
+		opts.name = elems[0]
+		options = elems[1:]
+	} else {
+		opts.name = nameAndOptions
+	}
+
+	name, ok := opts.name.value.(*Symbol)
+	if !ok || isKeyword(opts.name) {
+		return nil, &ErrUnsupportedArgumentType{function: "defstruct", argument: opts.name}
+	}
+
+	n := name.name.value.(string)
+	opts.concName = n + "-"
+	opts.copier = "copy-" + n
+	opts.predicate = n + "-p"
+
+	for _, option := range options {
+		var key string
+		var args []*Object
+		if option.kind == ConsCellType {
+			elems := noEvalArguments(option)
+			key, ok = keywordName(elems[0])
+			args = elems[1:]
+		} else {
+			key, ok = keywordName(option)
+		}
+
+		if !ok {
+			return nil, fmt.Errorf("defstruct: invalid option %v", *option)
+		}
+
+		switch key {
+		case "conc-name":
+			opts.concName = ""
+			if len(args) > 0 && !isNull(args[0]) {
+				opts.concName, ok = symbolNameString(args[0])
+				if !ok {
+					return nil, fmt.Errorf("defstruct: invalid conc-name %v", *args[0])
+				}
+			}
+		case "constructor":
+			if len(args) == 0 {
+				continue
+			}
+			if isNull(args[0]) {
+				opts.noConstructor = true
+				continue
+			}
+			opts.constructors = append(opts.constructors, option)
+		case "copier", "predicate":
+			value := ""
+			if len(args) > 0 && !isNull(args[0]) {
+				value, ok = symbolNameString(args[0])
+				if !ok {
+					return nil, fmt.Errorf("defstruct: invalid %s %v", key, *args[0])
+				}
+			}
+
+			if key == "copier" {
+				opts.copier = value
+			} else {
+				opts.predicate = value
+			}
+		case "include":
+			if len(args) == 0 {
+				return nil, fmt.Errorf("defstruct: include requires structure name")
+			}
+
+			parent, ok := structureClasses[args[0]]
+			if !ok {
+				return nil, fmt.Errorf("defstruct: %v is not a structure", *args[0])
+			}
+			opts.include = parent
+			opts.overrides = args[1:]
+		default:
+			return nil, fmt.Errorf("defstruct: unsupported option %v", *option)
+		}
+	}
+
+	return opts, nil
+}
+
+func parseStructureSlot(obj *Object) (*structureSlot, error) {
+	if obj.kind == SymbolType {
+		return &structureSlot{name: obj, initform: nilObj}, nil
+	}
+
+	elems := noEvalArguments(obj)
+	if obj.kind != ConsCellType || len(elems) == 0 || elems[0].kind != SymbolType {
+		return nil, fmt.Errorf("defstruct: invalid slot description %v", *obj)
+	}
+
+	slot := &structureSlot{name: elems[0], initform: nilObj}
+	if len(elems) >= 2 {
+		slot.initform = elems[1]
+	}
+
+	options := elems[2:]
+	if len(options)%2 != 0 {
+		return nil, fmt.Errorf("defstruct: invalid slot options %v", *obj)
+	}
+
+	for i := 0; i < len(options); i += 2 {
+		key, _ := keywordName(options[i])
+		switch key {
+		case "type":
+			slot.typ = options[i+1]
+		case "read-only":
+			slot.readOnly = !isNull(options[i+1])
+		default:
+			return nil, fmt.Errorf("defstruct: invalid slot option %v", *options[i])
+		}
+	}
+
+	return slot, nil
+}
+
+func structureValue(function string, class *StructureClass, obj *Object) (*Structure, error) {
+	s, ok := obj.value.(*Structure)
+	if !ok || !s.class.isSubclassOf(class) {
+		return nil, &ErrUnsupportedArgumentType{function: function, argument: obj}
+	}
+
+	return s, nil
+}
+
+func installStructureAccessor(class *StructureClass, concName string, index int) {
+	slot := class.slots[index]
+	name := concName + slot.name.value.(*Symbol).name.value.(string)
+
+	installBuiltinFunction(name, func(_ *Environment, args []*Object) (*Object, error) {
+		s, err := structureValue(name, class, args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return s.slots[index], nil
+	}, 1, false)
+
+	if slot.readOnly {
+		delete(setfFunctions, newSymbol(name))
+		return
+	}
+
+	installSetfFunction(name, func(_ *Environment, args []*Object, value *Object) (*Object, error) {
+		if len(args) != 1 {
+			return nil, &ErrWrongNumberArguments{variadic: false, expected: 1, got: len(args)}
+		}
+
+		s, err := structureValue(name, class, args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if err := slot.checkType(value); err != nil {
+			return nil, err
+		}
+
+		s.slots[index] = value
+		return value, nil
+	})
+}
+
+func installKeywordConstructor(class *StructureClass, name string) {
+	var slotNames []string
+	for _, slot := range class.slots {
+		slotNames = append(slotNames, slot.name.value.(*Symbol).name.value.(string))
+	}
+
+	installBuiltinFunction(name, func(env *Environment, args []*Object) (*Object, error) {
+		keys, err := parseKeywordArguments(name, args, slotNames...)
+		if err != nil {
+			return nil, err
+		}
+
+		values := make([]*Object, len(slotNames))
+		for i, slotName := range slotNames {
+			values[i] = keys[slotName]
+		}
+
+		return class.makeInstance(env, values)
+	}, 0, true)
+}
+
+func installBOAConstructor(class *StructureClass, name string, params []*Object) error {
+	ll, err := parseLambdaList(params)
+	if err != nil {
+		return err
+	}
+
+	// optional parameters without default value are initialized by
+	// slot initforms
+	for _, p := range append(ll.optional, ll.keys...) {
+		if p.init == nilObj {
+			if i := class.slotIndex(p.name.value.(*Symbol).name.value.(string)); i >= 0 {
+				p.init = class.slots[i].initform
+			}
+		}
+	}
+
+	installBuiltinFunction(name, func(env *Environment, args []*Object) (*Object, error) {
+		if err := ll.bind(env, args); err != nil {
+			return nil, err
+		}
+		defer env.popFrame(1)
+
+		values := make([]*Object, len(class.slots))
+		for i, slot := range class.slots {
+			if v, ok := env.frames[0].lookup(slot.name); ok {
+				values[i] = v
+			}
+		}
+
+		return class.makeInstance(env, values)
+	}, 0, true)
+
+	return nil
+}
+
+func specialDefstruct(env *Environment, args []*Object) (*Object, error) {
+	// (defstruct name-and-options [doc-string] slot-description...)
+	opts, err := parseStructureOptions(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	class := &StructureClass{
+		name:   opts.name,
+		parent: opts.include,
+	}
+
+	if opts.include != nil {
+		for _, slot := range opts.include.slots {
+			copied := *slot
+			class.slots = append(class.slots, &copied)
+		}
+
+		for _, override := range opts.overrides {
+			slot, err := parseStructureSlot(override)
+			if err != nil {
+				return nil, err
+			}
+
+			i := class.slotIndex(slot.name.value.(*Symbol).name.value.(string))
+			if i < 0 {
+				return nil, fmt.Errorf("defstruct: %v is not an included slot", *slot.name)
+			}
+
+			if slot.typ == nil {
+				slot.typ = class.slots[i].typ
+			}
+			class.slots[i] = slot
+		}
+	}
+
+	slotDescs := args[1:]
+	if len(slotDescs) > 0 && slotDescs[0].kind == StringType {
+		slotDescs = slotDescs[1:]
+	}
+
+	for _, desc := range slotDescs {
+		slot, err := parseStructureSlot(desc)
+		if err != nil {
+			return nil, err
+		}
+
+		if class.slotIndex(slot.name.value.(*Symbol).name.value.(string)) >= 0 {
+			return nil, fmt.Errorf("defstruct: duplicated slot %v", *slot.name)
+		}
+		class.slots = append(class.slots, slot)
+	}
+
+	structureClasses[opts.name] = class
+
+	name := opts.name.value.(*Symbol).name.value.(string)
+	if len(opts.constructors) == 0 && !opts.noConstructor {
+		installKeywordConstructor(class, "make-"+name)
+	}
+
+	for _, constructor := range opts.constructors {
+		elems := noEvalArguments(constructor)
+		cname, ok := symbolNameString(elems[1])
+		if !ok {
+			return nil, fmt.Errorf("defstruct: invalid constructor %v", *elems[1])
+		}
+
+		if len(elems) == 2 {
+			installKeywordConstructor(class, cname)
+			continue
+		}
+
+		if err := installBOAConstructor(class, cname, noEvalArguments(elems[2])); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range class.slots {
+		installStructureAccessor(class, opts.concName, i)
+	}
+
+	if opts.predicate != "" {
+		installBuiltinFunction(opts.predicate, func(_ *Environment, args []*Object) (*Object, error) {
+			if s, ok := args[0].value.(*Structure); ok && s.class.isSubclassOf(class) {
+				return tObj, nil
+			}
+
+			return nilObj, nil
+		}, 1, false)
+	}
+
+	if opts.copier != "" {
+		copier := opts.copier
+		installBuiltinFunction(copier, func(_ *Environment, args []*Object) (*Object, error) {
+			s, err := structureValue(copier, class, args[0])
+			if err != nil {
+				return nil, err
+			}
+
+			slots := make([]*Object, len(s.slots))
+			copy(slots, s.slots)
+			return newStructure(s.class, slots), nil
+		}, 1, false)
+	}
+
+	return opts.name, nil
+}
+
+func initStructureFunctions() {
+	installSpecialForm("defstruct", specialDefstruct, 1, true)
+}
@@ -0,0 +1,113 @@
+package banglisp
+
+import (
+	"testing"
+)
+
+func TestDefstruct(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "keyword constructor and defaults",
+			expr: `(defstruct st-point x (y 10))
+(make-st-point :x 1)`,
+			want: "#S(st-point :x 1 :y 10)",
+		},
+		{
+			name: "accessor",
+			expr: `(defstruct st-pair left right)
+(st-pair-right (make-st-pair :left 1 :right 2))`,
+			want: "2",
+		},
+		{
+			name: "setf accessor",
+			expr: `(defstruct st-cell value)
+(let ((c (make-st-cell)))
+  (setf (st-cell-value c) 'updated)
+  (st-cell-value c))`,
+			want: "updated",
+		},
+		{
+			name: "predicate and type-of",
+			expr: `(defstruct st-foo)
+(list (st-foo-p (make-st-foo)) (st-foo-p 1) (type-of (make-st-foo)))`,
+			want: "(t nil st-foo)",
+		},
+		{
+			name: "copier",
+			expr: `(defstruct st-box content)
+(let* ((a (make-st-box :content 1))
+       (b (copy-st-box a)))
+  (setf (st-box-content b) 2)
+  (list (st-box-content a) (st-box-content b)))`,
+			want: "(1 2)",
+		},
+		{
+			name: "BOA constructor",
+			expr: `(defstruct (st-vec (:constructor st-vec (x y &optional z))) x y (z 0))
+(list (st-vec 1 2) (st-vec 1 2 3))`,
+			want: "(#S(st-vec :x 1 :y 2 :z 0) #S(st-vec :x 1 :y 2 :z 3))",
+		},
+		{
+			name: "include",
+			expr: `(defstruct st-animal name (legs 4))
+(defstruct (st-bird (:include st-animal (legs 2))) wings)
+(let ((b (make-st-bird :name "pigeon")))
+  (list (st-animal-p b) (st-animal-legs b) (st-bird-name b) (typep b 'st-animal)))`,
+			want: `(t 2 "pigeon" t)`,
+		},
+		{
+			name: "conc-name",
+			expr: `(defstruct (st-node (:conc-name st-n-)) val next)
+(st-n-val (make-st-node :val 5))`,
+			want: "5",
+		},
+		{
+			name: "read structure",
+			expr: `(defstruct st-rgb r g b)
+(st-rgb-g #S(st-rgb :r 1 :g 2 :b 3))`,
+			want: "2",
+		},
+		{
+			name: "equalp structure",
+			expr: `(defstruct st-name first last)
+(equalp (make-st-name :first "a" :last "b") #S(st-name :first "A" :last "B"))`,
+			want: "t",
+		},
+		{
+			name: "slot type",
+			expr: `(defstruct st-typed (n 0 :type fixnum))
+(make-st-typed :n "foo")`,
+			wantErr: true,
+		},
+		{
+			name: "read only slot",
+			expr: `(defstruct st-const (n 0 :read-only t))
+(setf (st-const-n (make-st-const)) 1)`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEvalString(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("could not evaluate %s: %v", tt.expr, err)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("%s => got: %v, expected %s", tt.expr, *got, tt.want)
+				return
+			}
+		})
+	}
+}